@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/database"
+	"curso-go-clean-arch/internal/usecase"
+)
+
+// runMigrate applies the PostgreSQL migrations, SQLite migrating itself on connect
+func runMigrate(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("migrate")
+	dir := f.String("dir", "migrations", "directory of the PostgreSQL migrations")
+	baseline := f.Bool("baseline", false, "record the pending migrations as applied without running them")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	applied := []string{}
+	if app.config.Database.Driver != database.DriverSQLite {
+		var err error
+		applied, err = database.MigratePostgres(app.container.DB, os.DirFS(*dir), *baseline)
+		if err != nil {
+			return err
+		}
+	}
+
+	rows := make([][]string, 0, len(applied))
+	for _, version := range applied {
+		rows = append(rows, []string{version})
+	}
+	return f.print(result{
+		value:  map[string]any{"applied": applied, "baseline": *baseline},
+		header: []string{"APPLIED"},
+		rows:   rows,
+	})
+}
+
+// runSeed creates realistic fake orders with the seeder, the flags defaulting to the seed configuration
+func runSeed(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("seed")
+	cfg := app.config.Seed
+	if cfg.Tenant != "" {
+		f.tenant = cfg.Tenant
+	}
+	f.IntVar(&cfg.Count, "n", cfg.Count, "number of orders to create")
+	f.IntVar(&cfg.RandomSeed, "seed", cfg.RandomSeed, "random seed, the same seed creates the same orders")
+	f.StringVar(&cfg.From, "from", cfg.From, "start of created_at, RFC 3339 or YYYY-MM-DD, defaults to 90 days before -to")
+	f.StringVar(&cfg.To, "to", cfg.To, "end of created_at, RFC 3339 or YYYY-MM-DD, defaults to now")
+	f.StringVar(&cfg.TimeZone, "tz", cfg.TimeZone, "time zone of the dates and of the daily order peaks")
+	f.IntVar(&cfg.BatchSize, "batch-size", cfg.BatchSize, "orders written per transaction")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return usagef("%v", err)
+	}
+	plan, err := cfg.Plan(time.Now())
+	if err != nil {
+		return usagef("%v", err)
+	}
+	ctx, err = f.context(ctx)
+	if err != nil {
+		return err
+	}
+
+	output, err := app.container.Seeder.Run(ctx, plan)
+	if err != nil {
+		return err
+	}
+	return f.print(result{
+		value:  output,
+		header: []string{"TENANT", "SEEDED", "FROM", "TO"},
+		rows:   [][]string{{output.Tenant, strconv.Itoa(output.Seeded), output.From, output.To}},
+	})
+}
+
+// orderHeader is the table header of orders
+var orderHeader = []string{"ID", "STATUS", "CREATED_AT", "DELETED_AT", "DESCRIPTION"}
+
+// runList lists the orders of the tenant
+func runList(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("list")
+	includeDeleted := f.Bool("include-deleted", false, "also list soft deleted orders")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+	ctx, err := f.context(ctx)
+	if err != nil {
+		return err
+	}
+
+	output, err := app.container.ListOrdersUseCase.Execute(ctx, usecase.ListOrdersInput{IncludeDeleted: *includeDeleted})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(output))
+	for _, order := range output {
+		rows = append(rows, []string{order.ID, order.Status, order.CreatedAt, order.DeletedAt, order.Description})
+	}
+	if output == nil {
+		output = []*usecase.ListOrdersOutput{}
+	}
+	return f.print(result{value: output, header: orderHeader, rows: rows})
+}
+
+// runGet shows an order
+func runGet(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("get")
+	includeDeleted := f.Bool("include-deleted", false, "also find soft deleted orders")
+	if err := f.parse(args, 1); err != nil {
+		return err
+	}
+	ctx, err := f.context(ctx)
+	if err != nil {
+		return err
+	}
+
+	order, err := app.container.GetOrderUseCase.Execute(ctx, f.arguments[0], *includeDeleted)
+	if err != nil {
+		return err
+	}
+	return f.print(result{
+		value:  order,
+		header: orderHeader,
+		rows:   [][]string{{order.ID, order.Status, order.CreatedAt, order.DeletedAt, order.Description}},
+	})
+}
+
+// runUpdate changes the description, items or status of an order
+func runUpdate(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("update")
+	var input usecase.UpdateOrderInput
+	f.Func("description", "new description", func(value string) error {
+		input.Description = &value
+		return nil
+	})
+	f.Func("items", `new items as JSON, e.g. [{"name":"Coffee","quantity":2,"unit_price":1250}]`, func(value string) error {
+		items, err := parseItems(value)
+		if err != nil {
+			return err
+		}
+		input.Items = &items
+		return nil
+	})
+	f.Func("status", "new status: pending, confirmed, shipped, delivered or cancelled", func(value string) error {
+		input.Status = &value
+		return nil
+	})
+	if err := f.parse(args, 1); err != nil {
+		return err
+	}
+	ctx, err := f.context(ctx)
+	if err != nil {
+		return err
+	}
+
+	input.ID = f.arguments[0]
+	order, err := app.container.UpdateOrderUseCase.Execute(ctx, input)
+	if err != nil {
+		return err
+	}
+	return f.print(result{
+		value:  order,
+		header: orderHeader,
+		rows:   [][]string{{order.ID, order.Status, order.CreatedAt, "", order.Description}},
+	})
+}
+
+// runDelete soft deletes an order
+func runDelete(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("delete")
+	if err := f.parse(args, 1); err != nil {
+		return err
+	}
+	ctx, err := f.context(ctx)
+	if err != nil {
+		return err
+	}
+
+	id := f.arguments[0]
+	if err := app.container.DeleteOrderUseCase.Execute(ctx, id); err != nil {
+		return err
+	}
+	return f.print(result{
+		value:  map[string]string{"id": id, "deleted": "true"},
+		header: []string{"DELETED"},
+		rows:   [][]string{{id}},
+	})
+}
+
+// runRebuildProjections recomputes the order summaries of every tenant
+func runRebuildProjections(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("rebuild-projections")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+	// The rebuild spans every tenant, so it runs without one: with row level
+	// security the database user must bypass the policies
+	ctx = auth.WithPrincipal(ctx, auth.Principal{Actor: f.actor, Admin: true})
+
+	output, err := app.container.RebuildOrderProjectionsUseCase.Execute(ctx)
+	if err != nil {
+		return err
+	}
+	return f.print(result{
+		value:  output,
+		header: []string{"PROJECTION", "ORDERS"},
+		rows:   [][]string{{output.Projection, strconv.FormatInt(output.Orders, 10)}},
+	})
+}
+
+// runRotateToken generates an admin token and prints ADMIN_TOKENS with it
+// first. The tokens live in the configuration, so rotating is a two-step
+// deploy: add the new token, move clients to it, then -revoke the old one.
+func runRotateToken(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("rotate-token")
+	name := f.String("name", "", "actor the callers of the token are recorded as, admin when empty")
+	var revoke []string
+	f.Func("revoke", "token to drop from ADMIN_TOKENS, repeatable", func(value string) error {
+		revoke = append(revoke, value)
+		return nil
+	})
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	// 32 random bytes, far above the 16 characters the configuration requires
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("error generating token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	if *name != "" {
+		token = *name + ":" + token
+	}
+
+	// Tokens are revoked by their entry or by the token alone
+	tokens := []string{token}
+	for _, existing := range app.config.Auth.AdminTokens {
+		_, secret := auth.SplitAdminToken(existing)
+		if !slices.Contains(revoke, existing) && !slices.Contains(revoke, secret) {
+			tokens = append(tokens, existing)
+		}
+	}
+
+	return f.print(result{
+		value:  map[string]any{"token": token, "admin_tokens": tokens, "revoked": len(app.config.Auth.AdminTokens) + 1 - len(tokens)},
+		header: []string{"TOKEN", "ADMIN_TOKENS"},
+		rows:   [][]string{{token, strings.Join(tokens, ",")}},
+	})
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"curso-go-clean-arch/internal/usecase"
+)
+
+// errInvalidCSV is returned when the CSV to import is malformed
+var errInvalidCSV = errors.New("invalid CSV")
+
+// csvHeader is the header of exported orders, import reads the same columns.
+// items holds the items of an order as a JSON array, total_amount their sum.
+var csvHeader = []string{"id", "description", "items", "currency", "total_amount", "status", "created_at", "updated_at",
+	"deleted_at"}
+
+// runExport writes the orders of the tenant as CSV
+func runExport(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("export")
+	file := f.String("file", "-", "file to write, - for stdout")
+	includeDeleted := f.Bool("include-deleted", false, "also export soft deleted orders")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+	ctx, err := f.context(ctx)
+	if err != nil {
+		return err
+	}
+
+	orders, err := app.container.ListOrdersUseCase.Execute(ctx, usecase.ListOrdersInput{IncludeDeleted: *includeDeleted})
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *file != "-" {
+		out, err := os.Create(*file)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", *file, err)
+		}
+		defer out.Close()
+		w = out
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, order := range orders {
+		items, err := json.Marshal(order.Items)
+		if err != nil {
+			return fmt.Errorf("error encoding items: %w", err)
+		}
+		record := []string{order.ID, order.Description, string(items), order.Currency,
+			strconv.FormatInt(order.TotalAmount, 10), order.Status, order.CreatedAt, order.UpdatedAt, order.DeletedAt}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	// With the CSV on stdout the summary would corrupt it
+	if *file == "-" {
+		return nil
+	}
+	return f.print(result{
+		value:  map[string]any{"exported": len(orders), "file": *file},
+		header: []string{"EXPORTED", "FILE"},
+		rows:   [][]string{{strconv.Itoa(len(orders)), *file}},
+	})
+}
+
+// runImport creates orders from CSV with a header row. The id, description,
+// items, currency, status and created_at columns are read, others such as
+// total_amount of an export are ignored; only description is required.
+func runImport(ctx context.Context, app *app, args []string) error {
+	f := app.newFlags("import")
+	file := f.String("file", "-", "file to read, - for stdin")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+	ctx, err := f.context(ctx)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		in, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %w", *file, err)
+		}
+		defer in.Close()
+		r = in
+	}
+
+	inputs, err := readOrdersCSV(r)
+	if err != nil {
+		return err
+	}
+
+	output, err := app.container.ImportOrdersUseCase.Execute(ctx, inputs)
+	if err != nil {
+		return err
+	}
+	return f.print(result{
+		value:  output,
+		header: []string{"IMPORTED"},
+		rows:   [][]string{{strconv.Itoa(output.Imported)}},
+	})
+}
+
+// readOrdersCSV reads the orders to import from r
+func readOrdersCSV(r io.Reader) ([]usecase.ImportOrderInput, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: missing header row", errInvalidCSV)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidCSV, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["description"]; !ok {
+		return nil, fmt.Errorf("%w: missing description column", errInvalidCSV)
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var inputs []usecase.ImportOrderInput
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidCSV, err)
+		}
+		var items []usecase.OrderItem
+		if value := field(record, "items"); value != "" {
+			if items, err = parseItems(value); err != nil {
+				return nil, fmt.Errorf("%w: order %d: %v", errInvalidCSV, n, err)
+			}
+		}
+		inputs = append(inputs, usecase.ImportOrderInput{
+			ID:          field(record, "id"),
+			Description: field(record, "description"),
+			Items:       items,
+			Currency:    field(record, "currency"),
+			Status:      field(record, "status"),
+			CreatedAt:   field(record, "created_at"),
+		})
+	}
+	return inputs, nil
+}
+
+// parseItems decodes the items of an order from a JSON array
+func parseItems(value string) ([]usecase.OrderItem, error) {
+	var items []usecase.OrderItem
+	if err := json.Unmarshal([]byte(value), &items); err != nil {
+		return nil, fmt.Errorf("items must be a JSON array of {name, quantity, unit_price}: %v", err)
+	}
+	return items, nil
+}
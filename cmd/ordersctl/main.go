@@ -0,0 +1,253 @@
+// Command ordersctl operates the orders service from the command line: it runs
+// the migrations, seeds, inspects and changes orders, imports and exports them
+// as CSV, rebuilds the projections and rotates the admin tokens.
+//
+// It reads the same configuration as cmd/server, flags first, then a command:
+//
+//	ordersctl [config flags] <command> [command flags] [arguments]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/config"
+	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/logger"
+	"curso-go-clean-arch/internal/seed"
+	"curso-go-clean-arch/internal/tenant"
+	"curso-go-clean-arch/internal/usecase"
+)
+
+// Exit codes, stable so scripts can branch on them
+const (
+	exitOK       = 0
+	exitFailure  = 1
+	exitUsage    = 2
+	exitNotFound = 3
+	exitInvalid  = 4
+	exitConflict = 5
+)
+
+// command is a subcommand of ordersctl
+type command struct {
+	name    string
+	args    string
+	summary string
+	// offline commands run without connecting to the database
+	offline bool
+	run     func(ctx context.Context, app *app, args []string) error
+}
+
+// commands lists the subcommands, in help order
+var commands = []*command{
+	{name: "migrate", summary: "apply the pending database migrations", run: runMigrate},
+	{name: "seed", summary: "create realistic fake orders", run: runSeed},
+	{name: "list", summary: "list the orders of the tenant", run: runList},
+	{name: "get", args: "<id>", summary: "show an order", run: runGet},
+	{name: "update", args: "<id>", summary: "change the description, items or status of an order", run: runUpdate},
+	{name: "delete", args: "<id>", summary: "soft delete an order", run: runDelete},
+	{name: "export", summary: "write the orders of the tenant as CSV", run: runExport},
+	{name: "import", summary: "create orders from CSV", run: runImport},
+	{name: "rebuild-projections", summary: "recompute the order read model of every tenant", run: runRebuildProjections},
+	{name: "rotate-token", summary: "generate a new admin token and print the new ADMIN_TOKENS", offline: true, run: runRotateToken},
+}
+
+// usageError is returned for malformed command lines
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+// usagef builds a usageError
+func usagef(format string, args ...any) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// app holds what the commands share
+type app struct {
+	config    *config.Config
+	container *container.Container
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string) int {
+	cfg, rest, err := config.LoadWithArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		printUsage()
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	// Logs go to stderr, stdout carries the command output
+	slog.SetDefault(logger.New(&cfg.Log, os.Stderr))
+
+	if len(rest) == 0 {
+		printUsage()
+		return exitUsage
+	}
+	cmd := findCommand(rest[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "ordersctl: unknown command %q\n\n", rest[0])
+		printUsage()
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	app := &app{config: cfg}
+	if !cmd.offline {
+		app.container, err = container.NewContainer(cfg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ordersctl:", err)
+			return exitFailure
+		}
+		defer app.container.Close()
+	}
+
+	err = cmd.run(ctx, app, rest[1:])
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "ordersctl %s: %v\n", cmd.name, err)
+	}
+	return exitCode(err)
+}
+
+// exitCode maps the error of a command to the exit code of the process
+func exitCode(err error) int {
+	var usage *usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, repository.ErrOrderNotFound):
+		return exitNotFound
+	case errors.Is(err, repository.ErrInvalidOrderID), errors.Is(err, entity.ErrInvalidOrder), errors.Is(err, usecase.ErrValidation),
+		errors.Is(err, tenant.ErrInvalidTenant), errors.Is(err, errInvalidCSV):
+		return exitInvalid
+	case errors.Is(err, repository.ErrDuplicateIdempotencyKey), errors.Is(err, repository.ErrOrderConcurrentModification),
+		errors.Is(err, seed.ErrAlreadySeeded):
+		return exitConflict
+	default:
+		return exitFailure
+	}
+}
+
+// findCommand returns the command with the given name, nil when there is none
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// printUsage describes the commands on stderr
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: ordersctl [config flags] <command> [command flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-22s %s\n", cmd.name+" "+cmd.args, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun ordersctl <command> -h for the flags of a command, ordersctl -h lists the config flags.")
+	fmt.Fprintln(os.Stderr, "\nExit codes:")
+	codes := map[int]string{
+		exitOK:       "success",
+		exitFailure:  "failure",
+		exitUsage:    "invalid command line",
+		exitNotFound: "order not found",
+		exitInvalid:  "invalid input",
+		exitConflict: "conflicting change",
+	}
+	keys := make([]int, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Ints(keys)
+	for _, code := range keys {
+		fmt.Fprintf(os.Stderr, "  %d  %s\n", code, codes[code])
+	}
+}
+
+// flags holds the flags every command accepts
+type flags struct {
+	*flag.FlagSet
+	tenant string
+	actor  string
+	output string
+	// arguments are the positional arguments, set by parse
+	arguments []string
+}
+
+// newFlags creates the flag set of a command with the common flags
+func (a *app) newFlags(cmd string) *flags {
+	f := &flags{FlagSet: flag.NewFlagSet("ordersctl "+cmd, flag.ContinueOnError)}
+	f.StringVar(&f.tenant, "tenant", a.config.Tenant.Default, "tenant to operate on, defaults to tenant.default")
+	f.StringVar(&f.actor, "actor", "ordersctl", "actor recorded in the order history")
+	f.StringVar(&f.output, "o", "table", "output format: table, json or yaml")
+	return f
+}
+
+// parse parses args, expecting exactly positional arguments. Flags may come
+// before or after them, as in "update <id> -status shipped".
+func (f *flags) parse(args []string, positional int) error {
+	var arguments []string
+	for {
+		if err := f.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return err
+			}
+			return usagef("%v", err)
+		}
+		if f.NArg() == 0 {
+			break
+		}
+		arguments = append(arguments, f.Arg(0))
+		args = f.Args()[1:]
+	}
+	if len(arguments) != positional {
+		return usagef("expected %d argument(s), got %d", positional, len(arguments))
+	}
+	f.arguments = arguments
+	switch f.output {
+	case "table", "json", "yaml":
+	default:
+		return usagef("unknown output format %q, use table, json or yaml", f.output)
+	}
+	return nil
+}
+
+// context returns ctx scoped to the tenant with an admin principal: whoever
+// runs ordersctl already holds the database credentials
+func (f *flags) context(ctx context.Context) (context.Context, error) {
+	if f.tenant == "" {
+		return nil, usagef("no tenant, pass -tenant or set TENANT_DEFAULT")
+	}
+	if err := tenant.Validate(f.tenant); err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithTenant(ctx, f.tenant)
+	return auth.WithPrincipal(ctx, auth.Principal{Actor: f.actor, Admin: true}), nil
+}
@@ -3,10 +3,19 @@ package main
 import (
 	"context"
 	"curso-go-clean-arch/graph"
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/config"
 	"curso-go-clean-arch/internal/container"
 	"curso-go-clean-arch/internal/grpc"
+	"curso-go-clean-arch/internal/locale"
+	"curso-go-clean-arch/internal/logger"
+	"curso-go-clean-arch/internal/seed"
 	"curso-go-clean-arch/internal/server"
-	"log"
+	"curso-go-clean-arch/internal/tenant"
+	"curso-go-clean-arch/internal/tracing"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -21,21 +30,41 @@ import (
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
-const (
-	defaultGraphQLPort = "8080"
-	defaultRESTPort    = "8081"
-)
-
 func main() {
+	// Load configuration, failing fast on invalid values
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize structured logging
+	logger.Setup(&cfg.Log)
+
 	// Initialize container with dependencies
-	container, err := container.NewContainer()
+	container, err := container.NewContainer(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize container: %v", err)
+		slog.Error("Failed to initialize container", "error", err)
+		os.Exit(1)
 	}
 	defer container.Close()
 
+	// Seed fake orders for development, once per tenant and seed
+	if cfg.Seed.Enabled {
+		seedOrders(container)
+	}
+
 	// Create GraphQL server
-	graphQLServer := createGraphQLServer(container)
+	graphQLServer := &http.Server{
+		Addr:         ":" + cfg.Server.GraphQLPort,
+		Handler:      createGraphQLServer(container),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
 
 	// Create REST server
 	restServer := server.NewRESTServer(container)
@@ -43,30 +72,34 @@ func main() {
 	// Create gRPC server
 	grpcServer := grpc.NewGRPCServer(container)
 
+	// Start the purge job and the webhook dispatcher, stopped on shutdown
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go container.PurgeJob.Run(jobCtx)
+	go container.WebhookDispatcher.Run(jobCtx)
+
 	// Start servers in goroutines
 	go func() {
-		port := os.Getenv("GRAPHQL_PORT")
-		if port == "" {
-			port = defaultGraphQLPort
-		}
+		port := cfg.Server.GraphQLPort
+		slog.Info("GraphQL server starting", "port", port, "playground", cfg.Features.GraphQLPlayground)
 
-		log.Printf("GraphQL server starting on port %s", port)
-		log.Printf("GraphQL playground: http://localhost:%s/", port)
-
-		if err := http.ListenAndServe(":"+port, graphQLServer); err != nil {
-			log.Fatalf("GraphQL server failed: %v", err)
+		if err := graphQLServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("GraphQL server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	go func() {
 		if err := restServer.Start(); err != nil {
-			log.Fatalf("REST server failed: %v", err)
+			slog.Error("REST server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	go func() {
 		if err := grpcServer.Start(); err != nil {
-			log.Fatalf("gRPC server failed: %v", err)
+			slog.Error("gRPC server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -75,14 +108,22 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down servers...")
+	slog.Info("Shutting down servers...")
+	stopJobs()
 
 	// Graceful shutdown
-	_, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	// Here you could add graceful shutdown logic for both servers
-	log.Println("Servers stopped")
+	if err := graphQLServer.Shutdown(ctx); err != nil {
+		slog.Error("GraphQL server shutdown failed", "error", err)
+	}
+	if err := restServer.Shutdown(ctx); err != nil {
+		slog.Error("REST server shutdown failed", "error", err)
+	}
+	grpcServer.Shutdown(ctx)
+
+	slog.Info("Servers stopped")
 }
 
 func createGraphQLServer(container *container.Container) http.Handler {
@@ -96,16 +137,67 @@ func createGraphQLServer(container *container.Container) http.Handler {
 	srv.AddTransport(transport.POST{})
 
 	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.AroundOperations(resolver.HistoryLoader())
 
-	srv.Use(extension.Introspection{})
+	features := container.Config.Features
+	if features.GraphQLIntrospection {
+		srv.Use(extension.Introspection{})
+	}
+	srv.Use(logger.GraphQLExtension())
+	srv.Use(container.Metrics.GraphQLExtension())
+	srv.Use(tracing.GraphQLExtension())
+	srv.Use(container.RateLimiter.GraphQLExtension())
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
 
 	// Create mux for GraphQL
 	mux := http.NewServeMux()
-	mux.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	mux.Handle("/query", srv)
+	if features.GraphQLPlayground {
+		mux.Handle("/", playground.Handler("GraphQL playground", "/query"))
+	}
+	mux.Handle("/query", tracing.Middleware("graphql", graphQLSpanName)(
+		locale.Middleware(container.RateLimiter.IdentityMiddleware(container.TenantResolver.Middleware(container.Authenticator.Middleware(srv)))),
+	))
+
+	return logger.Middleware("graphql")(mux)
+}
+
+// graphQLSpanName names GraphQL request spans until the operation name is known
+func graphQLSpanName(r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
 
-	return mux
+// seedOrders fills the seed tenant with fake orders before the servers start,
+// skipping a tenant already seeded with the same seed
+func seedOrders(container *container.Container) {
+	cfg := container.Config
+	tenantID := cfg.Seed.Tenant
+	if tenantID == "" {
+		tenantID = cfg.Tenant.Default
+	}
+	if tenantID == "" {
+		slog.Error("Failed to seed orders", "error", "no tenant, set seed.tenant or tenant.default")
+		os.Exit(1)
+	}
+
+	plan, err := cfg.Seed.Plan(time.Now())
+	if err != nil {
+		slog.Error("Failed to seed orders", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := tenant.WithTenant(context.Background(), tenantID)
+	ctx = auth.WithPrincipal(ctx, auth.Principal{Actor: "seed"})
+	result, err := container.Seeder.Run(ctx, plan)
+	switch {
+	case errors.Is(err, seed.ErrAlreadySeeded):
+		slog.Info("Orders already seeded", "tenant", tenantID, "seed", plan.Seed)
+	case err != nil:
+		slog.Error("Failed to seed orders", "error", err)
+		os.Exit(1)
+	default:
+		slog.Info("Seeded orders", "tenant", tenantID, "seeded", result.Seeded, "from", result.From, "to", result.To)
+	}
 }
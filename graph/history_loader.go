@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"context"
+	"curso-go-clean-arch/internal/usecase"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// historyLoaderKey is the context key of the historyLoader of an operation
+type historyLoaderKey struct{}
+
+// historyLoader batches the history lookups of an operation. The resolvers
+// returning orders register their IDs, and the first history field resolved
+// loads the history of every registered order in one query, so a list of
+// orders costs one history query instead of one per order.
+type historyLoader struct {
+	useCase *usecase.GetOrderHistoryUseCase
+
+	mu sync.Mutex
+	// pending are registered orders whose history is not loaded yet
+	pending []string
+	loaded  map[string][]*usecase.OrderHistoryOutput
+}
+
+// newHistoryLoader creates an empty loader
+func newHistoryLoader(useCase *usecase.GetOrderHistoryUseCase) *historyLoader {
+	return &historyLoader{
+		useCase: useCase,
+		loaded:  map[string][]*usecase.OrderHistoryOutput{},
+	}
+}
+
+// HistoryLoader gives every operation a historyLoader, batching the history
+// lookups of the orders it returns. Add it with AroundOperations.
+func (r *Resolver) HistoryLoader() graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		return next(context.WithValue(ctx, historyLoaderKey{}, newHistoryLoader(r.container.GetOrderHistoryUseCase)))
+	}
+}
+
+// historyLoaderFor returns the loader of the operation of ctx, a loader for a
+// single lookup when the server has no HistoryLoader middleware
+func (r *Resolver) historyLoaderFor(ctx context.Context) *historyLoader {
+	if loader, ok := ctx.Value(historyLoaderKey{}).(*historyLoader); ok {
+		return loader
+	}
+	return newHistoryLoader(r.container.GetOrderHistoryUseCase)
+}
+
+// register queues orders whose history may be resolved later in the operation
+func (l *historyLoader) register(orderIDs ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, orderIDs...)
+}
+
+// load returns the history of an order, loading it together with the pending
+// orders when it is not loaded yet. Concurrent callers wait for the batch.
+func (l *historyLoader) load(ctx context.Context, orderID string) ([]*usecase.OrderHistoryOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entries, ok := l.loaded[orderID]; ok {
+		return entries, nil
+	}
+
+	batch := []string{orderID}
+	queued := map[string]bool{orderID: true}
+	for _, id := range l.pending {
+		if _, ok := l.loaded[id]; !ok && !queued[id] {
+			queued[id] = true
+			batch = append(batch, id)
+		}
+	}
+
+	history, err := l.useCase.ExecuteMany(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	l.pending = nil
+	for _, id := range batch {
+		l.loaded[id] = history[id]
+	}
+	return l.loaded[orderID], nil
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"context"
+	"curso-go-clean-arch/internal/config"
+	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/database"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+	"curso-go-clean-arch/internal/usecase"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// countingHistory is a history repository counting its lookups
+type countingHistory struct {
+	repository.OrderHistoryRepository
+	mu      sync.Mutex
+	single  int
+	batches [][]string
+}
+
+func (h *countingHistory) ListByOrderID(ctx context.Context, orderID string) ([]*entity.OrderHistoryEntry, error) {
+	h.mu.Lock()
+	h.single++
+	h.mu.Unlock()
+	return h.OrderHistoryRepository.ListByOrderID(ctx, orderID)
+}
+
+func (h *countingHistory) ListByOrderIDs(ctx context.Context, orderIDs []string) ([]*entity.OrderHistoryEntry, error) {
+	h.mu.Lock()
+	h.batches = append(h.batches, orderIDs)
+	h.mu.Unlock()
+	return h.OrderHistoryRepository.ListByOrderIDs(ctx, orderIDs)
+}
+
+// TestHistoryIsBatched checks that the history of a list of orders is read
+// with one query, whatever the number of orders
+func TestHistoryIsBatched(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Driver = database.DriverSQLite
+	cfg.Database.SQLitePath = filepath.Join(t.TempDir(), "orders.db")
+
+	c, err := container.NewContainer(cfg)
+	if err != nil {
+		t.Fatalf("creating container: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	history := &countingHistory{OrderHistoryRepository: c.OrderHistory}
+	c.GetOrderHistoryUseCase = usecase.NewGetOrderHistoryUseCase(c.OrderRepository, history, usecase.NoopObserver{})
+
+	ctx := tenant.WithTenant(context.Background(), "history-loader")
+	const orders = 5
+	for i := range orders {
+		order, err := c.CreateOrderUseCase.Execute(ctx, usecase.CreateOrderInput{Description: "order " + string(rune('a'+i))})
+		if err != nil {
+			t.Fatalf("creating order: %v", err)
+		}
+		// A second entry, so each order has its own history to return
+		if err := c.DeleteOrderUseCase.Execute(ctx, order.ID); err != nil {
+			t.Fatalf("deleting order: %v", err)
+		}
+	}
+
+	resolver := NewResolver(c)
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	srv.AddTransport(transport.POST{})
+	srv.AroundOperations(resolver.HistoryLoader())
+
+	body := `{"query": "{ listOrders(includeDeleted: true) { id history { action } } }"}`
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var response struct {
+		Data struct {
+			ListOrders []struct {
+				ID      string
+				History []struct{ Action string }
+			}
+		}
+		Errors []any
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding response %s: %v", rec.Body, err)
+	}
+	if len(response.Errors) > 0 {
+		t.Fatalf("query failed: %v", response.Errors)
+	}
+
+	if len(response.Data.ListOrders) != orders {
+		t.Fatalf("listed %d orders, want %d", len(response.Data.ListOrders), orders)
+	}
+	for _, order := range response.Data.ListOrders {
+		var actions []string
+		for _, entry := range order.History {
+			actions = append(actions, entry.Action)
+		}
+		if strings.Join(actions, ",") != "created,deleted" {
+			t.Errorf("history of order %s = %q, want created,deleted", order.ID, actions)
+		}
+	}
+
+	if history.single != 0 || len(history.batches) != 1 || len(history.batches[0]) != orders {
+		t.Errorf("history read with %d single lookups and batches %q, want one batch of the %d orders",
+			history.single, history.batches, orders)
+	}
+}
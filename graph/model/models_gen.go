@@ -2,19 +2,162 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Sum of the totals of the orders in a currency, in minor units
+type CurrencyAmount struct {
+	Currency string `json:"currency"`
+	Amount   int    `json:"amount"`
+}
+
+type FieldChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before,omitempty"`
+	After  *string `json:"after,omitempty"`
+}
+
 type Mutation struct {
 }
 
 type NewOrder struct {
-	Desc string `json:"desc"`
+	Desc  string            `json:"desc"`
+	Items []*OrderItemInput `json:"items,omitempty"`
+	// ISO 4217 code of the prices, BRL by default
+	Currency       *string `json:"currency,omitempty"`
+	IdempotencyKey *string `json:"idempotencyKey,omitempty"`
 }
 
 type Order struct {
-	ID        string `json:"id"`
-	Desc      string `json:"desc"`
-	CreatedAt string `json:"createdAt"`
-	UpdatedAt string `json:"updatedAt"`
+	ID    string       `json:"id"`
+	Desc  string       `json:"desc"`
+	Items []*OrderItem `json:"items"`
+	// ISO 4217 code of the prices, e.g. BRL
+	Currency string `json:"currency"`
+	// Sum of the items in minor units of the currency
+	TotalAmount int                  `json:"totalAmount"`
+	Status      string               `json:"status"`
+	CreatedAt   string               `json:"createdAt"`
+	UpdatedAt   string               `json:"updatedAt"`
+	DeletedAt   *string              `json:"deletedAt,omitempty"`
+	History     []*OrderHistoryEntry `json:"history"`
+}
+
+type OrderHistoryEntry struct {
+	ID           string         `json:"id"`
+	Action       string         `json:"action"`
+	Actor        string         `json:"actor"`
+	ClaimedActor *string        `json:"claimedActor,omitempty"`
+	RequestID    *string        `json:"requestId,omitempty"`
+	Changes      []*FieldChange `json:"changes"`
+	CreatedAt    string         `json:"createdAt"`
+}
+
+type OrderItem struct {
+	Name     string `json:"name"`
+	Quantity int32  `json:"quantity"`
+	// Price of one unit in minor units of the currency, e.g. cents
+	UnitPrice int32 `json:"unitPrice"`
+}
+
+type OrderItemInput struct {
+	Name     string `json:"name"`
+	Quantity int32  `json:"quantity"`
+	// Price of one unit in minor units of the currency, e.g. cents
+	UnitPrice int32 `json:"unitPrice"`
+}
+
+type OrderSearchResult struct {
+	Order   *Order  `json:"order"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type OrderStats struct {
+	GroupBy  StatsGroupBy        `json:"groupBy"`
+	TimeZone string              `json:"timeZone"`
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Total    int32               `json:"total"`
+	ByStatus []*StatusCount      `json:"byStatus"`
+	Revenue  []*CurrencyAmount   `json:"revenue"`
+	Buckets  []*OrderStatsBucket `json:"buckets"`
+}
+
+type OrderStatsBucket struct {
+	Start    string         `json:"start"`
+	End      string         `json:"end"`
+	Total    int32          `json:"total"`
+	ByStatus []*StatusCount `json:"byStatus"`
+	// Revenue of the orders not cancelled, per currency
+	Revenue []*CurrencyAmount `json:"revenue"`
 }
 
 type Query struct {
 }
+
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int32  `json:"count"`
+}
+
+type StatsGroupBy string
+
+const (
+	StatsGroupByDay   StatsGroupBy = "DAY"
+	StatsGroupByWeek  StatsGroupBy = "WEEK"
+	StatsGroupByMonth StatsGroupBy = "MONTH"
+)
+
+var AllStatsGroupBy = []StatsGroupBy{
+	StatsGroupByDay,
+	StatsGroupByWeek,
+	StatsGroupByMonth,
+}
+
+func (e StatsGroupBy) IsValid() bool {
+	switch e {
+	case StatsGroupByDay, StatsGroupByWeek, StatsGroupByMonth:
+		return true
+	}
+	return false
+}
+
+func (e StatsGroupBy) String() string {
+	return string(e)
+}
+
+func (e *StatsGroupBy) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StatsGroupBy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StatsGroupBy", str)
+	}
+	return nil
+}
+
+func (e StatsGroupBy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StatsGroupBy) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StatsGroupBy) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
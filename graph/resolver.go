@@ -1,7 +1,17 @@
 package graph
 
 import (
+	"context"
+	"curso-go-clean-arch/graph/model"
 	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/usecase"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 // This file will not be regenerated automatically.
@@ -18,3 +28,50 @@ func NewResolver(container *container.Container) *Resolver {
 		container: container,
 	}
 }
+
+// statusCounts converts the counts per status of a report, in the order of entity.OrderStatuses
+func statusCounts(counts map[string]int64) []*model.StatusCount {
+	result := make([]*model.StatusCount, 0, len(counts))
+	for _, status := range entity.OrderStatuses {
+		result = append(result, &model.StatusCount{Status: string(status), Count: int32(counts[string(status)])})
+	}
+	return result
+}
+
+// currencyAmounts converts the amounts per currency of a report, ordered by currency
+func currencyAmounts(amounts map[string]int64) []*model.CurrencyAmount {
+	result := make([]*model.CurrencyAmount, 0, len(amounts))
+	for _, currency := range slices.Sorted(maps.Keys(amounts)) {
+		result = append(result, &model.CurrencyAmount{Currency: currency, Amount: int(amounts[currency])})
+	}
+	return result
+}
+
+// orderItems converts the items of a use case output to GraphQL models
+func orderItems(items []usecase.OrderItem) []*model.OrderItem {
+	result := make([]*model.OrderItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, &model.OrderItem{
+			Name:      item.Name,
+			Quantity:  int32(item.Quantity),
+			UnitPrice: int32(item.UnitPrice),
+		})
+	}
+	return result
+}
+
+// ErrorPresenter presents use case validation errors with the code
+// VALIDATION_FAILED and the invalid fields as extensions
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+
+	var validationErr *usecase.ValidationError
+	if errors.As(err, &validationErr) {
+		if presented.Extensions == nil {
+			presented.Extensions = map[string]any{}
+		}
+		presented.Extensions["code"] = "VALIDATION_FAILED"
+		presented.Extensions["fields"] = validationErr.Fields
+	}
+	return presented
+}
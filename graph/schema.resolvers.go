@@ -7,7 +7,11 @@ package graph
 import (
 	"context"
 	"curso-go-clean-arch/graph/model"
+	"curso-go-clean-arch/internal/domain/entity"
 	"curso-go-clean-arch/internal/usecase"
+	"errors"
+	"log/slog"
+	"strings"
 )
 
 // CreateOrder is the resolver for the createOrder field.
@@ -16,49 +20,260 @@ func (r *mutationResolver) CreateOrder(ctx context.Context, input model.NewOrder
 	createInput := usecase.CreateOrderInput{
 		Description: input.Desc,
 	}
+	for _, item := range input.Items {
+		createInput.Items = append(createInput.Items, usecase.OrderItem{
+			Name:      item.Name,
+			Quantity:  int(item.Quantity),
+			UnitPrice: int64(item.UnitPrice),
+		})
+	}
+	if input.Currency != nil {
+		createInput.Currency = *input.Currency
+	}
+	if input.IdempotencyKey != nil {
+		createInput.IdempotencyKey = *input.IdempotencyKey
+	}
 
 	// Execute use case
 	output, err := r.Resolver.container.CreateOrderUseCase.Execute(ctx, createInput)
 	if err != nil {
+		if !errors.Is(err, usecase.ErrValidation) && !errors.Is(err, entity.ErrInvalidOrder) {
+			slog.ErrorContext(ctx, "Failed to create order", "error", err)
+		}
+		return nil, err
+	}
+
+	// Convert use case output to GraphQL model
+	return &model.Order{
+		ID:          output.ID,
+		Desc:        output.Description,
+		Items:       orderItems(output.Items),
+		Currency:    output.Currency,
+		TotalAmount: int(output.TotalAmount),
+		Status:      output.Status,
+		CreatedAt:   output.CreatedAt,
+		UpdatedAt:   output.UpdatedAt,
+	}, nil
+}
+
+// DeleteOrder is the resolver for the deleteOrder field.
+func (r *mutationResolver) DeleteOrder(ctx context.Context, id string) (bool, error) {
+	if err := r.Resolver.container.DeleteOrderUseCase.Execute(ctx, id); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete order", "error", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RestoreOrder is the resolver for the restoreOrder field.
+func (r *mutationResolver) RestoreOrder(ctx context.Context, id string) (*model.Order, error) {
+	output, err := r.Resolver.container.RestoreOrderUseCase.Execute(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to restore order", "error", err)
 		return nil, err
 	}
 
 	// Convert use case output to GraphQL model
 	return &model.Order{
-		ID:        output.ID,
-		Desc:      output.Description,
-		CreatedAt: output.CreatedAt,
-		UpdatedAt: output.UpdatedAt,
+		ID:          output.ID,
+		Desc:        output.Description,
+		Items:       orderItems(output.Items),
+		Currency:    output.Currency,
+		TotalAmount: int(output.TotalAmount),
+		Status:      output.Status,
+		CreatedAt:   output.CreatedAt,
+		UpdatedAt:   output.UpdatedAt,
 	}, nil
 }
 
+// PurgeOrder is the resolver for the purgeOrder field.
+func (r *mutationResolver) PurgeOrder(ctx context.Context, id string) (bool, error) {
+	if err := r.Resolver.container.PurgeOrderUseCase.Execute(ctx, id); err != nil {
+		slog.ErrorContext(ctx, "Failed to purge order", "error", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// History is the resolver for the history field.
+func (r *orderResolver) History(ctx context.Context, obj *model.Order) ([]*model.OrderHistoryEntry, error) {
+	// Load the history with the other orders of the operation
+	output, err := r.Resolver.historyLoaderFor(ctx).load(ctx, obj.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get order history", "error", err)
+		return nil, err
+	}
+
+	// Convert use case output to GraphQL models
+	entries := make([]*model.OrderHistoryEntry, 0, len(output))
+	for _, entry := range output {
+		item := &model.OrderHistoryEntry{
+			ID:        entry.ID,
+			Action:    entry.Action,
+			Actor:     entry.Actor,
+			Changes:   make([]*model.FieldChange, 0, len(entry.Changes)),
+			CreatedAt: entry.CreatedAt,
+		}
+		if entry.RequestID != "" {
+			item.RequestID = &entry.RequestID
+		}
+		if entry.ClaimedActor != "" {
+			item.ClaimedActor = &entry.ClaimedActor
+		}
+		for _, change := range entry.Changes {
+			item.Changes = append(item.Changes, &model.FieldChange{
+				Field:  change.Field,
+				Before: change.Before,
+				After:  change.After,
+			})
+		}
+		entries = append(entries, item)
+	}
+
+	return entries, nil
+}
+
 // ListOrders is the resolver for the listOrders field.
-func (r *queryResolver) ListOrders(ctx context.Context) ([]*model.Order, error) {
+func (r *queryResolver) ListOrders(ctx context.Context, includeDeleted *bool) ([]*model.Order, error) {
 	// Execute use case
-	output, err := r.Resolver.container.ListOrdersUseCase.Execute(ctx)
+	var input usecase.ListOrdersInput
+	if includeDeleted != nil {
+		input.IncludeDeleted = *includeDeleted
+	}
+	output, err := r.Resolver.container.ListOrdersUseCase.Execute(ctx, input)
 	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list orders", "error", err)
 		return nil, err
 	}
 
 	// Convert use case output to GraphQL models
 	var orders []*model.Order
+	loader := r.Resolver.historyLoaderFor(ctx)
 	for _, order := range output {
-		orders = append(orders, &model.Order{
-			ID:        order.ID,
-			Desc:      order.Description,
-			CreatedAt: order.CreatedAt,
-			UpdatedAt: order.UpdatedAt,
-		})
+		loader.register(order.ID)
+		item := &model.Order{
+			ID:          order.ID,
+			Desc:        order.Description,
+			Items:       orderItems(order.Items),
+			Currency:    order.Currency,
+			TotalAmount: int(order.TotalAmount),
+			Status:      order.Status,
+			CreatedAt:   order.CreatedAt,
+			UpdatedAt:   order.UpdatedAt,
+		}
+		if order.DeletedAt != "" {
+			item.DeletedAt = &order.DeletedAt
+		}
+		orders = append(orders, item)
 	}
 
 	return orders, nil
 }
 
+// SearchOrders is the resolver for the searchOrders field.
+func (r *queryResolver) SearchOrders(ctx context.Context, query string, limit *int32, includeDeleted *bool) ([]*model.OrderSearchResult, error) {
+	// Execute use case
+	input := usecase.SearchOrdersInput{Query: query}
+	if limit != nil {
+		input.Limit = int(*limit)
+	}
+	if includeDeleted != nil {
+		input.IncludeDeleted = *includeDeleted
+	}
+	output, err := r.Resolver.container.SearchOrdersUseCase.Execute(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to search orders", "error", err)
+		return nil, err
+	}
+
+	// Convert use case output to GraphQL models
+	results := make([]*model.OrderSearchResult, 0, len(output))
+	loader := r.Resolver.historyLoaderFor(ctx)
+	for _, result := range output {
+		loader.register(result.ID)
+		order := &model.Order{
+			ID:          result.ID,
+			Desc:        result.Description,
+			Items:       orderItems(result.Items),
+			Currency:    result.Currency,
+			TotalAmount: int(result.TotalAmount),
+			Status:      result.Status,
+			CreatedAt:   result.CreatedAt,
+			UpdatedAt:   result.UpdatedAt,
+		}
+		if result.DeletedAt != "" {
+			order.DeletedAt = &result.DeletedAt
+		}
+		results = append(results, &model.OrderSearchResult{
+			Order:   order,
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		})
+	}
+
+	return results, nil
+}
+
+// OrderStats is the resolver for the orderStats field.
+func (r *queryResolver) OrderStats(ctx context.Context, groupBy *model.StatsGroupBy, from *string, to *string, timeZone *string, includeDeleted *bool) (*model.OrderStats, error) {
+	// Execute use case
+	var input usecase.GetOrderStatsInput
+	if groupBy != nil {
+		input.GroupBy = strings.ToLower(string(*groupBy))
+	}
+	if from != nil {
+		input.From = *from
+	}
+	if to != nil {
+		input.To = *to
+	}
+	if timeZone != nil {
+		input.TimeZone = *timeZone
+	}
+	if includeDeleted != nil {
+		input.IncludeDeleted = *includeDeleted
+	}
+	output, err := r.Resolver.container.GetOrderStatsUseCase.Execute(ctx, input)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get order stats", "error", err)
+		return nil, err
+	}
+
+	// Convert use case output to GraphQL models
+	stats := &model.OrderStats{
+		GroupBy:  model.StatsGroupBy(strings.ToUpper(output.GroupBy)),
+		TimeZone: output.TimeZone,
+		From:     output.From,
+		To:       output.To,
+		Total:    int32(output.Total),
+		ByStatus: statusCounts(output.ByStatus),
+		Revenue:  currencyAmounts(output.Revenue),
+		Buckets:  make([]*model.OrderStatsBucket, 0, len(output.Buckets)),
+	}
+	for _, bucket := range output.Buckets {
+		stats.Buckets = append(stats.Buckets, &model.OrderStatsBucket{
+			Start:    bucket.Start,
+			End:      bucket.End,
+			Total:    int32(bucket.Total),
+			ByStatus: statusCounts(bucket.ByStatus),
+			Revenue:  currencyAmounts(bucket.Revenue),
+		})
+	}
+
+	return stats, nil
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Order returns OrderResolver implementation.
+func (r *Resolver) Order() OrderResolver { return &orderResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
 type mutationResolver struct{ *Resolver }
+type orderResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrForbidden is returned when an operation requires admin privileges the caller does not have
+var ErrForbidden = errors.New("admin privileges required")
+
+// ErrInvalidToken is returned when a request presents an admin token that is not configured
+var ErrInvalidToken = errors.New("invalid admin token")
+
+// ErrInvalidMetricsToken is returned when a scrape does not present a configured metrics token
+var ErrInvalidMetricsToken = errors.New("missing or invalid metrics token")
+
+// Config holds caller identification configuration
+type Config struct {
+	// AdminTokens are the tokens accepted in AdminHeader, none disables admin
+	// access. An entry "name:token" names the actor its callers are recorded
+	// as, a bare token is recorded as admin.
+	AdminTokens []string `yaml:"admin_tokens" toml:"admin_tokens" env:"ADMIN_TOKENS"`
+	AdminHeader string   `yaml:"admin_header" toml:"admin_header" env:"ADMIN_HEADER"`
+	// ActorHeader names the caller as it claims to be, recorded apart from the
+	// verified actor as nothing authenticates it
+	ActorHeader string `yaml:"actor_header" toml:"actor_header" env:"ACTOR_HEADER"`
+	// MetricsTokens are the bearer tokens accepted on /metrics, which reports
+	// the orders of every tenant. None rejects every scrape.
+	MetricsTokens []string `yaml:"metrics_tokens" toml:"metrics_tokens" env:"METRICS_TOKENS"`
+}
+
+// DefaultConfig returns the default caller identification configuration
+func DefaultConfig() Config {
+	return Config{
+		AdminHeader: "X-Admin-Token",
+		ActorHeader: "X-User-ID",
+	}
+}
+
+// Validate checks the caller identification configuration
+func (c *Config) Validate() error {
+	var errs []error
+	if c.AdminHeader == "" {
+		errs = append(errs, errors.New("admin_header: must not be empty"))
+	}
+	if c.ActorHeader == "" {
+		errs = append(errs, errors.New("actor_header: must not be empty"))
+	}
+	for _, entry := range c.AdminTokens {
+		name, token := SplitAdminToken(entry)
+		if name == "" {
+			errs = append(errs, errors.New("admin_tokens: token names must not be empty"))
+			break
+		}
+		if len(token) < 16 {
+			errs = append(errs, errors.New("admin_tokens: tokens must have at least 16 characters"))
+			break
+		}
+	}
+	for _, token := range c.MetricsTokens {
+		if len(token) < 16 {
+			errs = append(errs, errors.New("metrics_tokens: tokens must have at least 16 characters"))
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// defaultAdminName is the actor of admin tokens configured without a name
+const defaultAdminName = "admin"
+
+// SplitAdminToken splits an AdminTokens entry into the actor name and the token
+func SplitAdminToken(entry string) (name, token string) {
+	if name, token, ok := strings.Cut(entry, ":"); ok {
+		return name, token
+	}
+	return defaultAdminName, entry
+}
+
+// Principal identifies the caller of an operation
+type Principal struct {
+	// Actor is the verified identity of the caller, such as the name of its
+	// admin token, empty when it presented no credential
+	Actor string
+	// ClaimedActor is the caller as reported by the actor header, not verified
+	ClaimedActor string
+	// Admin is true when the caller presented a valid admin token
+	Admin bool
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the given principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal stored in ctx, the zero Principal when none is present
+func FromContext(ctx context.Context) Principal {
+	principal, _ := ctx.Value(contextKey{}).(Principal)
+	return principal
+}
+
+// RequireAdmin returns ErrForbidden unless the caller in ctx is an admin
+func RequireAdmin(ctx context.Context) error {
+	if !FromContext(ctx).Admin {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Authenticator identifies callers from request headers
+type Authenticator struct {
+	config *Config
+}
+
+// NewAuthenticator creates a new authenticator
+func NewAuthenticator(config *Config) *Authenticator {
+	return &Authenticator{
+		config: config,
+	}
+}
+
+// Authenticate builds the principal of a request. Presenting an unknown admin
+// token is an error, not presenting one yields an anonymous non-admin principal.
+func (a *Authenticator) Authenticate(header http.Header) (Principal, error) {
+	principal := Principal{ClaimedActor: strings.TrimSpace(header.Get(a.config.ActorHeader))}
+
+	token := header.Get(a.config.AdminHeader)
+	if token == "" {
+		return principal, nil
+	}
+
+	name, ok := a.adminToken(token)
+	if !ok {
+		return Principal{}, ErrInvalidToken
+	}
+	principal.Actor = name
+	principal.Admin = true
+	return principal, nil
+}
+
+// adminToken returns the name of the configured admin token equal to token
+func (a *Authenticator) adminToken(token string) (string, bool) {
+	for _, entry := range a.config.AdminTokens {
+		name, valid := SplitAdminToken(entry)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(valid)) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Identity returns a stable identity of the valid admin token in header, empty
+// when there is none. The token itself is not exposed.
+func (a *Authenticator) Identity(header http.Header) string {
+	token := header.Get(a.config.AdminHeader)
+	if token == "" {
+		return ""
+	}
+	if _, ok := a.adminToken(token); !ok {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "admin:" + hex.EncodeToString(sum[:8])
+}
+
+// AuthenticateMetrics checks that header carries one of the metrics tokens as
+// an Authorization bearer token, the credential Prometheus scrapes send
+func (a *Authenticator) AuthenticateMetrics(header http.Header) error {
+	token, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return ErrInvalidMetricsToken
+	}
+	for _, valid := range a.config.MetricsTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(valid)) == 1 {
+			return nil
+		}
+	}
+	return ErrInvalidMetricsToken
+}
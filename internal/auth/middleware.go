@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"curso-go-clean-arch/internal/problem"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Middleware identifies the caller of every HTTP request and stores it in the request context
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.Authenticate(r.Header)
+		if err != nil {
+			problem.Write(w, r, problem.New(problem.TypeUnauthorized, http.StatusUnauthorized, "Authentication failed: "+err.Error()))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// MetricsMiddleware rejects requests without a valid metrics token
+func (a *Authenticator) MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := a.AuthenticateMetrics(r.Header); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			problem.Write(w, r, problem.New(problem.TypeUnauthorized, http.StatusUnauthorized, "Authentication failed: "+err.Error()))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryServerInterceptor identifies the caller from gRPC metadata for every unary call
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.fromIncomingContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor identifies the caller from gRPC metadata for every streaming call
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.fromIncomingContext(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// fromIncomingContext identifies the caller from incoming gRPC metadata
+func (a *Authenticator) fromIncomingContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	header := http.Header{}
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	principal, err := a.Authenticate(header)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "authentication failed: "+err.Error())
+	}
+
+	return WithPrincipal(ctx, principal), nil
+}
+
+// principalStream overrides the context of a server stream
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the principal-aware context
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Config holds repository caching configuration
+type Config struct {
+	Enabled bool          `yaml:"enabled" toml:"enabled" env:"CACHE_ENABLED"`
+	Size    int           `yaml:"size" toml:"size" env:"CACHE_SIZE"`
+	TTL     time.Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL"`
+	// ReportTTL is how long order reports are cached, independently of Enabled, zero disables it
+	ReportTTL time.Duration `yaml:"report_ttl" toml:"report_ttl" env:"CACHE_REPORT_TTL"`
+}
+
+// DefaultConfig returns the default caching configuration
+func DefaultConfig() Config {
+	return Config{
+		Enabled:   false,
+		Size:      10000,
+		TTL:       time.Minute,
+		ReportTTL: 30 * time.Second,
+	}
+}
+
+// Validate checks the caching configuration
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Size <= 0 {
+		errs = append(errs, fmt.Errorf("size: must be positive, got %d", c.Size))
+	}
+	if c.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("ttl: must be positive, got %s", c.TTL))
+	}
+	if c.ReportTTL < 0 {
+		errs = append(errs, fmt.Errorf("report_ttl: must not be negative, got %s", c.ReportTTL))
+	}
+	return errors.Join(errs...)
+}
+
+// Store keeps cached values. Implement it on top of Redis, Memcached or another
+// shared cache so instances of the service see each other's invalidations.
+type Store interface {
+	// Get returns the value of key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the given keys, missing keys are ignored
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// entry is a value held by MemoryStore
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-process Store evicting the least recently used entry
+// once size entries are held, and expired entries when they are read
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an LRU store holding at most size entries
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value of key when present and not expired
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := element.Value.(*entry)
+	if s.now().After(e.expiresAt) {
+		s.remove(element)
+		return nil, false, nil
+	}
+
+	s.order.MoveToFront(element)
+	return e.value, true, nil
+}
+
+// Set stores value under key, evicting the least recently used entry when full
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := s.now().Add(ttl)
+	if element, ok := s.entries[key]; ok {
+		e := element.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for s.order.Len() > s.size {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+// Delete removes the given keys
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := s.entries[key]; ok {
+			s.remove(element)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// remove drops an element from both the list and the index
+func (s *MemoryStore) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*entry).key)
+}
+
+// Observer is notified of every cache lookup, metrics.Metrics implements it
+type Observer interface {
+	ObserveCache(operation string, hit bool)
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/cache"
+	"curso-go-clean-arch/internal/database"
+	"curso-go-clean-arch/internal/events"
+	"curso-go-clean-arch/internal/logger"
+	"curso-go-clean-arch/internal/purge"
+	"curso-go-clean-arch/internal/ratelimit"
+	"curso-go-clean-arch/internal/seed"
+	"curso-go-clean-arch/internal/tenant"
+	"curso-go-clean-arch/internal/tracing"
+	"curso-go-clean-arch/internal/webhook"
+)
+
+// Config is the complete application configuration
+type Config struct {
+	Server    ServerConfig     `yaml:"server" toml:"server"`
+	Database  database.Config  `yaml:"database" toml:"database"`
+	Cache     cache.Config     `yaml:"cache" toml:"cache"`
+	Purge     purge.Config     `yaml:"purge" toml:"purge"`
+	CORS      CORSConfig       `yaml:"cors" toml:"cors"`
+	Features  FeaturesConfig   `yaml:"features" toml:"features"`
+	Log       logger.Config    `yaml:"log" toml:"log"`
+	Tracing   tracing.Config   `yaml:"tracing" toml:"tracing"`
+	RateLimit ratelimit.Config `yaml:"rate_limit" toml:"rate_limit"`
+	Tenant    tenant.Config    `yaml:"tenant" toml:"tenant"`
+	Auth      auth.Config      `yaml:"auth" toml:"auth"`
+	Webhook   webhook.Config   `yaml:"webhook" toml:"webhook"`
+	Events    events.Config    `yaml:"events" toml:"events"`
+	Seed      seed.Config      `yaml:"seed" toml:"seed"`
+}
+
+// ServerConfig holds listener ports, HTTP timeouts and gRPC deadlines
+type ServerConfig struct {
+	GraphQLPort     string        `yaml:"graphql_port" toml:"graphql_port" env:"GRAPHQL_PORT"`
+	RESTPort        string        `yaml:"rest_port" toml:"rest_port" env:"REST_PORT"`
+	GRPCPort        string        `yaml:"grpc_port" toml:"grpc_port" env:"GRPC_PORT"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// GRPCDefaultDeadline applies to gRPC calls sent without a deadline, 0 leaves them unbounded
+	GRPCDefaultDeadline time.Duration `yaml:"grpc_default_deadline" toml:"grpc_default_deadline" env:"GRPC_DEFAULT_DEADLINE"`
+	// GRPCMaxDeadline shortens longer deadlines of gRPC calls, 0 accepts any deadline
+	GRPCMaxDeadline time.Duration `yaml:"grpc_max_deadline" toml:"grpc_max_deadline" env:"GRPC_MAX_DEADLINE"`
+}
+
+// CORSConfig holds the CORS policy of the REST API
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods []string `yaml:"allowed_methods" toml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders []string `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders []string `yaml:"exposed_headers" toml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+}
+
+// FeaturesConfig holds feature toggles
+type FeaturesConfig struct {
+	GraphQLPlayground    bool `yaml:"graphql_playground" toml:"graphql_playground" env:"FEATURE_GRAPHQL_PLAYGROUND"`
+	GraphQLIntrospection bool `yaml:"graphql_introspection" toml:"graphql_introspection" env:"FEATURE_GRAPHQL_INTROSPECTION"`
+	Metrics              bool `yaml:"metrics" toml:"metrics" env:"FEATURE_METRICS"`
+}
+
+// Default returns the configuration used when no source overrides a value
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			GraphQLPort:         "8080",
+			RESTPort:            "8081",
+			GRPCPort:            "8082",
+			ReadTimeout:         15 * time.Second,
+			WriteTimeout:        15 * time.Second,
+			IdleTimeout:         60 * time.Second,
+			ShutdownTimeout:     30 * time.Second,
+			GRPCDefaultDeadline: 15 * time.Second,
+			GRPCMaxDeadline:     time.Minute,
+		},
+		Database: database.DefaultConfig(),
+		Cache:    cache.DefaultConfig(),
+		Purge:    purge.DefaultConfig(),
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "POST", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{
+				"Content-Type", "Authorization", "Idempotency-Key", "X-Tenant-ID",
+				"X-API-Key", "X-User-ID", "X-Admin-Token", "X-Request-ID", "traceparent", "tracestate",
+			},
+			ExposedHeaders: []string{"Retry-After", "X-RateLimit-Remaining", "X-Request-ID"},
+		},
+		Features: FeaturesConfig{
+			GraphQLPlayground:    true,
+			GraphQLIntrospection: true,
+			Metrics:              true,
+		},
+		Log:       logger.DefaultConfig(),
+		Tracing:   tracing.DefaultConfig(),
+		RateLimit: ratelimit.DefaultConfig(),
+		Tenant:    tenant.DefaultConfig(),
+		Auth:      auth.DefaultConfig(),
+		Webhook:   webhook.DefaultConfig(),
+		Events:    events.DefaultConfig(),
+		Seed:      seed.DefaultConfig(),
+	}
+}
+
+// Validate checks every section and reports all problems at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	section := func(name string, err error) {
+		if err == nil {
+			return
+		}
+		// Prefix every entry with its section so messages read like "database.sslmode: ..."
+		for _, e := range unwrapJoined(err) {
+			errs = append(errs, fmt.Errorf("%s.%w", name, e))
+		}
+	}
+
+	section("server", c.Server.Validate())
+	section("database", c.Database.Validate())
+	section("cache", c.Cache.Validate())
+	section("purge", c.Purge.Validate())
+	section("cors", c.CORS.Validate())
+	section("log", c.Log.Validate())
+	section("tracing", c.Tracing.Validate())
+	section("rate_limit", c.RateLimit.Validate())
+	section("tenant", c.Tenant.Validate())
+	section("auth", c.Auth.Validate())
+	section("webhook", c.Webhook.Validate())
+	section("events", c.Events.Validate())
+	section("seed", c.Seed.Validate())
+
+	return errors.Join(errs...)
+}
+
+// Validate checks ports and timeouts
+func (c *ServerConfig) Validate() error {
+	var errs []error
+	ports := map[string]string{
+		"graphql_port": c.GraphQLPort,
+		"rest_port":    c.RESTPort,
+		"grpc_port":    c.GRPCPort,
+	}
+	seen := make(map[string]string)
+	for _, name := range []string{"graphql_port", "rest_port", "grpc_port"} {
+		port := ports[name]
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			errs = append(errs, fmt.Errorf("%s: must be a TCP port between 1 and 65535, got %q", name, port))
+			continue
+		}
+		if other, ok := seen[port]; ok {
+			errs = append(errs, fmt.Errorf("%s: port %s is already used by %s", name, port, other))
+		}
+		seen[port] = name
+	}
+	for name, timeout := range map[string]time.Duration{
+		"read_timeout":          c.ReadTimeout,
+		"write_timeout":         c.WriteTimeout,
+		"idle_timeout":          c.IdleTimeout,
+		"shutdown_timeout":      c.ShutdownTimeout,
+		"grpc_default_deadline": c.GRPCDefaultDeadline,
+		"grpc_max_deadline":     c.GRPCMaxDeadline,
+	} {
+		if timeout < 0 {
+			errs = append(errs, fmt.Errorf("%s: must not be negative, got %s", name, timeout))
+		}
+	}
+	if c.GRPCMaxDeadline > 0 && c.GRPCDefaultDeadline > c.GRPCMaxDeadline {
+		errs = append(errs, fmt.Errorf("grpc_default_deadline: must not exceed grpc_max_deadline %s, got %s", c.GRPCMaxDeadline, c.GRPCDefaultDeadline))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the CORS policy
+func (c *CORSConfig) Validate() error {
+	if len(c.AllowedOrigins) == 0 {
+		return errors.New("allowed_origins: must not be empty, use \"*\" to allow any origin")
+	}
+	if len(c.AllowedMethods) == 0 {
+		return errors.New("allowed_methods: must not be empty")
+	}
+	return nil
+}
+
+// unwrapJoined splits an error created by errors.Join into its parts
+func unwrapJoined(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, unwrapJoined(e)...)
+		}
+		return errs
+	}
+	return []error{err}
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv is the environment variable naming the configuration file
+const FileEnv = "CONFIG_FILE"
+
+// Load builds the configuration from defaults, an optional YAML or TOML file,
+// environment variables and command-line flags, in increasing precedence.
+//
+// The file is given with -config or CONFIG_FILE. Every setting also has a flag
+// named after its file path, e.g. -server.rest-port or -database.max-open-conns.
+func Load(args []string) (*Config, error) {
+	cfg, _, err := LoadWithArgs(args)
+	return cfg, err
+}
+
+// LoadWithArgs is Load that also returns the arguments following the flags,
+// such as the subcommand of a command-line tool
+func LoadWithArgs(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "")
+
+	fs := flag.NewFlagSet("orders", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv(FileEnv), "path to a YAML or TOML configuration file")
+
+	flagValues := make(map[string]string)
+	for _, f := range fields {
+		name := f.flagName()
+		setFlag := func(value string) error {
+			flagValues[name] = value
+			return nil
+		}
+		// Boolean settings may be given alone, as in -seed.enabled
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(name, f.usage(), setFlag)
+		} else {
+			fs.Func(name, f.usage(), setFlag)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// File
+	if *configFile != "" {
+		if err := loadFile(*configFile, cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Environment variables
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if value, ok := os.LookupEnv(f.env); ok && value != "" {
+			if err := f.set(value); err != nil {
+				return nil, nil, fmt.Errorf("config: invalid %s environment variable: %w", f.env, err)
+			}
+		}
+	}
+
+	// Flags
+	for _, f := range fields {
+		if value, ok := flagValues[f.flagName()]; ok {
+			if err := f.set(value); err != nil {
+				return nil, nil, fmt.Errorf("config: invalid -%s flag: %w", f.flagName(), err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("config: invalid configuration:\n%w", err)
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// loadFile decodes a YAML or TOML file on top of cfg, chosen by extension
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: error reading %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("config: error parsing %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("config: error parsing %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config: unknown key %q in %s", undecoded[0].String(), path)
+		}
+	default:
+		return fmt.Errorf("config: unsupported file type %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+
+	return nil
+}
+
+// field is a single configurable setting
+type field struct {
+	path  string
+	env   string
+	value reflect.Value
+}
+
+// settable is implemented by types parsing their own text form, such as ratelimit.Operations
+type settable interface {
+	Set(string) error
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// collectFields walks the config struct and returns its leaf settings
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		_, isSettable := fv.Addr().Interface().(settable)
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType && !isSettable {
+			fields = append(fields, collectFields(fv, path)...)
+			continue
+		}
+
+		fields = append(fields, field{path: path, env: sf.Tag.Get("env"), value: fv})
+	}
+
+	return fields
+}
+
+// flagName returns the command-line flag of the setting, e.g. database.max-open-conns
+func (f field) flagName() string {
+	return strings.ReplaceAll(f.path, "_", "-")
+}
+
+// usage describes the setting for -help
+func (f field) usage() string {
+	if f.env != "" {
+		return fmt.Sprintf("%s (env %s)", f.path, f.env)
+	}
+	return f.path
+}
+
+// set parses a textual value into the setting
+func (f field) set(value string) error {
+	if s, ok := f.value.Addr().Interface().(settable); ok {
+		return s.Set(value)
+	}
+
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: expected a duration such as 30s or 5m, got %q", f.path, value)
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(value)
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: expected true or false, got %q", f.path, value)
+		}
+		f.value.SetBool(b)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: expected an integer, got %q", f.path, value)
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s: expected a number, got %q", f.path, value)
+		}
+		f.value.SetFloat(n)
+	case f.value.Kind() == reflect.Slice && f.value.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("%s: unsupported setting type %s", f.path, f.value.Type())
+	}
+
+	return nil
+}
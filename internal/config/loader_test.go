@@ -0,0 +1,201 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"curso-go-clean-arch/internal/ratelimit"
+)
+
+// writeFile writes a configuration file named name in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+	return path
+}
+
+// clearEnv unsets the variables the tests set, so the environment running the
+// tests does not leak into them. The loader ignores empty variables.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{FileEnv, "REST_PORT", "LOG_LEVEL", "DB_MAX_OPEN_CONNS", "HTTP_READ_TIMEOUT",
+		"CORS_ALLOWED_ORIGINS", "RATE_LIMIT_OPERATIONS", "FEATURE_METRICS", "GRPC_PORT"} {
+		t.Setenv(name, "")
+	}
+}
+
+// TestLoadPrecedence checks that flags override environment variables, which
+// override the file, which overrides the defaults
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := "server:\n  rest_port: \"9001\"\nlog:\n  level: warn\n"
+	tomlFile := "[server]\nrest_port = \"9001\"\n\n[log]\nlevel = \"warn\"\n"
+
+	tests := []struct {
+		name     string
+		file     string
+		fileName string
+		env      string
+		flag     string
+		wantPort string
+	}{
+		{name: "defaults", wantPort: "8081"},
+		{name: "yaml file", file: yamlFile, fileName: "config.yaml", wantPort: "9001"},
+		{name: "toml file", file: tomlFile, fileName: "config.toml", wantPort: "9001"},
+		{name: "env over defaults", env: "9002", wantPort: "9002"},
+		{name: "env over file", file: yamlFile, fileName: "config.yaml", env: "9002", wantPort: "9002"},
+		{name: "flag over env", env: "9002", flag: "9003", wantPort: "9003"},
+		{name: "flag over env and file", file: tomlFile, fileName: "config.toml", env: "9002", flag: "9003", wantPort: "9003"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("REST_PORT", tt.env)
+
+			var args []string
+			if tt.file != "" {
+				args = append(args, "-config", writeFile(t, tt.fileName, tt.file))
+			}
+			if tt.flag != "" {
+				args = append(args, "-server.rest-port", tt.flag)
+			}
+
+			cfg, err := Load(args)
+			if err != nil {
+				t.Fatalf("loading: %v", err)
+			}
+			if cfg.Server.RESTPort != tt.wantPort {
+				t.Errorf("rest_port = %q, want %q", cfg.Server.RESTPort, tt.wantPort)
+			}
+
+			// The other settings of the file survive the layers above it
+			wantLevel := Default().Log.Level
+			if tt.file != "" {
+				wantLevel = "warn"
+			}
+			if cfg.Log.Level != wantLevel {
+				t.Errorf("log.level = %q, want %q", cfg.Log.Level, wantLevel)
+			}
+			if cfg.Server.GRPCPort != "8082" {
+				t.Errorf("grpc_port = %q, want the default 8082", cfg.Server.GRPCPort)
+			}
+		})
+	}
+}
+
+// TestLoadConfigFileEnv checks that CONFIG_FILE names the file unless -config is given
+func TestLoadConfigFileEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(FileEnv, writeFile(t, "env.yaml", "server:\n  rest_port: \"9101\"\n"))
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	if cfg.Server.RESTPort != "9101" {
+		t.Errorf("rest_port = %q, want 9101 from CONFIG_FILE", cfg.Server.RESTPort)
+	}
+
+	cfg, err = Load([]string{"-config", writeFile(t, "flag.yaml", "server:\n  rest_port: \"9102\"\n")})
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	if cfg.Server.RESTPort != "9102" {
+		t.Errorf("rest_port = %q, want 9102 from -config", cfg.Server.RESTPort)
+	}
+}
+
+// TestLoadValueTypes checks the parsing of every kind of setting from the environment and flags
+func TestLoadValueTypes(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("HTTP_READ_TIMEOUT", "3s")
+	t.Setenv("DB_MAX_OPEN_CONNS", "40")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example, ,https://b.example,")
+	t.Setenv("RATE_LIMIT_OPERATIONS", "CreateOrder=5:10, ListOrders=50:100")
+	t.Setenv("FEATURE_METRICS", "true")
+
+	cfg, err := Load([]string{"-features.metrics=false", "-rate-limit.rps", "2.5"})
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+
+	if cfg.Server.ReadTimeout != 3*time.Second {
+		t.Errorf("read_timeout = %s, want 3s", cfg.Server.ReadTimeout)
+	}
+	if cfg.Database.MaxOpenConns != 40 {
+		t.Errorf("max_open_conns = %d, want 40", cfg.Database.MaxOpenConns)
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !slices.Equal(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("allowed_origins = %q, want %q", cfg.CORS.AllowedOrigins, want)
+	}
+	wantOperations := ratelimit.Operations{"CreateOrder": {Rate: 5, Burst: 10}, "ListOrders": {Rate: 50, Burst: 100}}
+	if cfg.RateLimit.Operations.String() != wantOperations.String() {
+		t.Errorf("operations = %s, want %s", cfg.RateLimit.Operations, wantOperations)
+	}
+	if cfg.Features.Metrics {
+		t.Error("features.metrics = true, want the flag to override the environment")
+	}
+	if cfg.RateLimit.RPS != 2.5 {
+		t.Errorf("rps = %g, want 2.5", cfg.RateLimit.RPS)
+	}
+}
+
+// TestLoadWithArgs checks that the arguments after the flags are returned
+func TestLoadWithArgs(t *testing.T) {
+	clearEnv(t)
+
+	_, rest, err := LoadWithArgs([]string{"-log.level", "debug", "seed", "-n", "5"})
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	if want := []string{"seed", "-n", "5"}; !slices.Equal(rest, want) {
+		t.Errorf("args = %q, want %q", rest, want)
+	}
+}
+
+// TestLoadErrors checks that invalid sources are reported with the setting at fault
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{name: "unknown yaml key", file: "config.yaml:server:\n  rest_prot: \"9001\"\n", wantErr: "rest_prot"},
+		{name: "unknown toml key", file: "config.toml:[server]\nrest_prot = \"9001\"\n", wantErr: "rest_prot"},
+		{name: "unsupported file type", file: "config.json:{}", wantErr: "unsupported file type"},
+		{name: "missing file", args: []string{"-config", "/nonexistent/config.yaml"}, wantErr: "error reading"},
+		{name: "invalid env duration", env: map[string]string{"HTTP_READ_TIMEOUT": "soon"}, wantErr: "HTTP_READ_TIMEOUT"},
+		{name: "invalid flag integer", args: []string{"-database.max-open-conns", "many"}, wantErr: "-database.max-open-conns"},
+		{name: "invalid configuration", env: map[string]string{"GRPC_PORT": "8081"}, wantErr: "invalid configuration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			args := tt.args
+			if tt.file != "" {
+				name, content, _ := strings.Cut(tt.file, ":")
+				args = append([]string{"-config", writeFile(t, name, content)}, args...)
+			}
+
+			_, err := Load(args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,50 +1,252 @@
 package container
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/cache"
+	"curso-go-clean-arch/internal/config"
 	"curso-go-clean-arch/internal/database"
 	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/events"
 	postgres "curso-go-clean-arch/internal/infrastructure/repository"
+	"curso-go-clean-arch/internal/metrics"
+	"curso-go-clean-arch/internal/projection"
+	"curso-go-clean-arch/internal/purge"
+	"curso-go-clean-arch/internal/ratelimit"
+	"curso-go-clean-arch/internal/seed"
+	"curso-go-clean-arch/internal/tenant"
+	"curso-go-clean-arch/internal/tracing"
 	"curso-go-clean-arch/internal/usecase"
+	"curso-go-clean-arch/internal/webhook"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 // Container holds all dependencies
 type Container struct {
-	DB                 *sql.DB
-	OrderRepository    repository.OrderRepository
-	CreateOrderUseCase *usecase.CreateOrderUseCase
-	ListOrdersUseCase  *usecase.ListOrdersUseCase
+	Config                         *config.Config
+	DB                             *sql.DB
+	Pool                           *pgxpool.Pool
+	OrderRepository                repository.OrderRepository
+	OrderHistory                   repository.OrderHistoryRepository
+	OrderCache                     *postgres.CachedOrderRepository
+	TransactionManager             repository.TransactionManager
+	CreateOrderUseCase             *usecase.CreateOrderUseCase
+	ListOrdersUseCase              *usecase.ListOrdersUseCase
+	SearchOrdersUseCase            *usecase.SearchOrdersUseCase
+	GetOrderStatsUseCase           *usecase.GetOrderStatsUseCase
+	GetOrderUseCase                *usecase.GetOrderUseCase
+	UpdateOrderUseCase             *usecase.UpdateOrderUseCase
+	ImportOrdersUseCase            *usecase.ImportOrdersUseCase
+	DeleteOrderUseCase             *usecase.DeleteOrderUseCase
+	RestoreOrderUseCase            *usecase.RestoreOrderUseCase
+	PurgeOrderUseCase              *usecase.PurgeOrderUseCase
+	GetOrderHistoryUseCase         *usecase.GetOrderHistoryUseCase
+	OrderSummaries                 repository.OrderSummaryRepository
+	RebuildOrderProjectionsUseCase *usecase.RebuildOrderProjectionsUseCase
+	WebhookRepository              repository.WebhookRepository
+	RegisterWebhookUseCase         *usecase.RegisterWebhookUseCase
+	ListWebhooksUseCase            *usecase.ListWebhooksUseCase
+	DeleteWebhookUseCase           *usecase.DeleteWebhookUseCase
+	ListWebhookDeliveriesUseCase   *usecase.ListWebhookDeliveriesUseCase
+	RetryWebhookDeliveryUseCase    *usecase.RetryWebhookDeliveryUseCase
+	WebhookDispatcher              *webhook.Dispatcher
+	EventPublisher                 events.Publisher
+	PurgeJob                       *purge.Job
+	Seeder                         *seed.Seeder
+	Authenticator                  *auth.Authenticator
+	TenantResolver                 *tenant.Resolver
+	RateLimiter                    *ratelimit.Limiter
+	Metrics                        *metrics.Metrics
+	Tracing                        *tracing.Provider
 }
 
 // NewContainer creates and configures all dependencies
-func NewContainer() (*Container, error) {
-	// Database connection
-	dbConfig := database.NewConfig()
-	db, err := database.Connect(dbConfig)
+func NewContainer(cfg *config.Config) (*Container, error) {
+	// Tracing
+	tracingProvider, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
+	// Database connection and repository
+	var (
+		db                 *sql.DB
+		pool               *pgxpool.Pool
+		orderRepository    repository.OrderRepository
+		orderHistory       repository.OrderHistoryRepository
+		transactionManager repository.TransactionManager
+		webhookRepository  repository.WebhookRepository
+		orderEvents        repository.OrderEventStore
+		orderSummaries     repository.OrderSummaryRepository
+	)
+	switch cfg.Database.Driver {
+	case database.DriverPgx:
+		pool, err = database.ConnectPool(&cfg.Database, postgres.ConfigurePgxPool)
+		if err != nil {
+			return nil, err
+		}
+		// database/sql view of the pool for metrics and health checks
+		db = stdlib.OpenDBFromPool(pool)
+		orderRepository = postgres.NewPgxOrderRepository(pool, cfg.Database.RowLevelSecurity)
+		orderHistory = postgres.NewPgxOrderHistoryRepository(pool, cfg.Database.RowLevelSecurity)
+		webhookRepository = postgres.NewPgxWebhookRepository(pool, cfg.Database.RowLevelSecurity)
+		orderEvents = postgres.NewPgxOrderEventStore(pool, cfg.Database.RowLevelSecurity)
+		orderSummaries = postgres.NewPgxOrderSummaryRepository(pool, cfg.Database.RowLevelSecurity)
+		transactionManager = postgres.NewPgxTransactionManager(pool, cfg.Database.RowLevelSecurity)
+	case database.DriverSQLite:
+		db, err = database.ConnectSQLite(&cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+		orderRepository = postgres.NewSQLiteOrderRepository(db)
+		orderHistory = postgres.NewSQLiteOrderHistoryRepository(db)
+		webhookRepository = postgres.NewSQLiteWebhookRepository(db)
+		orderEvents = postgres.NewSQLiteOrderEventStore(db)
+		orderSummaries = postgres.NewSQLiteOrderSummaryRepository(db)
+		transactionManager = postgres.NewSQLTransactionManager(db, false)
+	default:
+		db, err = database.Connect(&cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+		orderRepository = postgres.NewPostgresOrderRepository(db, cfg.Database.RowLevelSecurity)
+		orderHistory = postgres.NewPostgresOrderHistoryRepository(db, cfg.Database.RowLevelSecurity)
+		webhookRepository = postgres.NewPostgresWebhookRepository(db, cfg.Database.RowLevelSecurity)
+		orderEvents = postgres.NewPostgresOrderEventStore(db, cfg.Database.RowLevelSecurity)
+		orderSummaries = postgres.NewPostgresOrderSummaryRepository(db, cfg.Database.RowLevelSecurity)
+		transactionManager = postgres.NewSQLTransactionManager(db, cfg.Database.RowLevelSecurity)
+	}
+
+	// Event-sourced orders, the orders table becoming the projection of their streams
+	if cfg.Database.EventSourcing {
+		orderRepository, err = postgres.NewEventSourcedOrderRepository(orderRepository, orderEvents, transactionManager, cfg.Database.SnapshotInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Metrics
+	appMetrics := metrics.New(db)
+
+	// Order summaries of the query side and webhook deliveries, written with each change,
+	// and order events published to the message broker once their change commits
+	orderHooks := []postgres.OrderChangeHook{
+		projection.NewOrderSummaryProjector(orderSummaries),
+		webhook.NewNotifier(webhookRepository),
+	}
+	// Cached orders, dropped once the change of each commits
+	var orderCacheStore cache.Store
+	if cfg.Cache.Enabled {
+		orderCacheStore = cache.NewMemoryStore(cfg.Cache.Size)
+		orderHooks = append(orderHooks, postgres.NewOrderCacheInvalidator(orderCacheStore, transactionManager))
+	}
+	eventPublisher, err := events.NewPublisher(&cfg.Events)
 	if err != nil {
 		return nil, err
 	}
+	if eventPublisher != nil {
+		eventPublisher = events.NewAsyncPublisher(&cfg.Events, eventPublisher, appMetrics)
+		orderHooks = append(orderHooks, events.NewOrderEventHook(eventPublisher, transactionManager, appMetrics))
+	}
+
+	// Audit history of every order change, written in the transaction of the
+	// change along with the webhook deliveries it triggers
+	orderRepository = postgres.NewAuditedOrderRepository(orderRepository, orderHistory, transactionManager, orderHooks...)
+
+	// Read-through cache in front of the repository
+	var orderCache *postgres.CachedOrderRepository
+	if cfg.Cache.Enabled {
+		orderCache = postgres.NewCachedOrderRepository(orderRepository, orderCacheStore, cfg.Cache.TTL, appMetrics)
+		orderRepository = orderCache
+	}
 
-	// Repository
-	orderRepository := postgres.NewPostgresOrderRepository(db)
+	// Order reports, cached for a short TTL
+	var orderStats repository.OrderQueryService = orderSummaries
+	if cfg.Cache.ReportTTL > 0 {
+		orderStats = postgres.NewCachedOrderStats(orderSummaries, cache.NewMemoryStore(cfg.Cache.Size), cfg.Cache.ReportTTL, appMetrics)
+	}
 
 	// Use cases
-	createOrderUseCase := usecase.NewCreateOrderUseCase(orderRepository)
-	listOrdersUseCase := usecase.NewListOrdersUseCase(orderRepository)
+	observer := usecase.Observers{appMetrics, tracing.UseCaseObserver{}}
+	createOrderUseCase := usecase.NewCreateOrderUseCase(orderRepository, transactionManager, observer)
+	listOrdersUseCase := usecase.NewListOrdersUseCase(orderSummaries, observer)
+	searchOrdersUseCase := usecase.NewSearchOrdersUseCase(orderSummaries, observer)
+	getOrderStatsUseCase := usecase.NewGetOrderStatsUseCase(orderStats, observer)
+	getOrderUseCase := usecase.NewGetOrderUseCase(orderRepository, observer)
+	updateOrderUseCase := usecase.NewUpdateOrderUseCase(orderRepository, transactionManager, observer)
+	importOrdersUseCase := usecase.NewImportOrdersUseCase(orderRepository, transactionManager, observer)
+	deleteOrderUseCase := usecase.NewDeleteOrderUseCase(orderRepository, observer)
+	restoreOrderUseCase := usecase.NewRestoreOrderUseCase(orderRepository, transactionManager, observer)
+	purgeOrderUseCase := usecase.NewPurgeOrderUseCase(orderRepository, observer)
+	getOrderHistoryUseCase := usecase.NewGetOrderHistoryUseCase(orderRepository, orderHistory, observer)
+	rebuildOrderProjectionsUseCase := usecase.NewRebuildOrderProjectionsUseCase(orderSummaries, transactionManager, observer)
+	registerWebhookUseCase := usecase.NewRegisterWebhookUseCase(webhookRepository, observer)
+	listWebhooksUseCase := usecase.NewListWebhooksUseCase(webhookRepository, observer)
+	deleteWebhookUseCase := usecase.NewDeleteWebhookUseCase(webhookRepository, observer)
+	listWebhookDeliveriesUseCase := usecase.NewListWebhookDeliveriesUseCase(webhookRepository, observer)
+	retryWebhookDeliveryUseCase := usecase.NewRetryWebhookDeliveryUseCase(webhookRepository, observer)
+	authenticator := auth.NewAuthenticator(&cfg.Auth)
 
 	return &Container{
-		DB:                 db,
-		OrderRepository:    orderRepository,
-		CreateOrderUseCase: createOrderUseCase,
-		ListOrdersUseCase:  listOrdersUseCase,
+		Config:                         cfg,
+		DB:                             db,
+		Pool:                           pool,
+		OrderRepository:                orderRepository,
+		OrderHistory:                   orderHistory,
+		OrderCache:                     orderCache,
+		TransactionManager:             transactionManager,
+		CreateOrderUseCase:             createOrderUseCase,
+		ListOrdersUseCase:              listOrdersUseCase,
+		SearchOrdersUseCase:            searchOrdersUseCase,
+		GetOrderStatsUseCase:           getOrderStatsUseCase,
+		GetOrderUseCase:                getOrderUseCase,
+		UpdateOrderUseCase:             updateOrderUseCase,
+		ImportOrdersUseCase:            importOrdersUseCase,
+		DeleteOrderUseCase:             deleteOrderUseCase,
+		RestoreOrderUseCase:            restoreOrderUseCase,
+		PurgeOrderUseCase:              purgeOrderUseCase,
+		GetOrderHistoryUseCase:         getOrderHistoryUseCase,
+		OrderSummaries:                 orderSummaries,
+		RebuildOrderProjectionsUseCase: rebuildOrderProjectionsUseCase,
+		WebhookRepository:              webhookRepository,
+		RegisterWebhookUseCase:         registerWebhookUseCase,
+		ListWebhooksUseCase:            listWebhooksUseCase,
+		DeleteWebhookUseCase:           deleteWebhookUseCase,
+		ListWebhookDeliveriesUseCase:   listWebhookDeliveriesUseCase,
+		RetryWebhookDeliveryUseCase:    retryWebhookDeliveryUseCase,
+		WebhookDispatcher:              webhook.NewDispatcher(&cfg.Webhook, webhookRepository, nil, appMetrics),
+		EventPublisher:                 eventPublisher,
+		PurgeJob:                       purge.NewJob(&cfg.Purge, purgeOrderUseCase),
+		Seeder:                         seed.NewSeeder(orderRepository, transactionManager),
+		Authenticator:                  authenticator,
+		TenantResolver:                 tenant.NewResolver(&cfg.Tenant),
+		RateLimiter:                    ratelimit.NewLimiter(&cfg.RateLimit, ratelimit.NewMemoryStore(), authenticator.Identity),
+		Metrics:                        appMetrics,
+		Tracing:                        tracingProvider,
 	}, nil
 }
 
 // Close closes all resources
 func (c *Container) Close() error {
+	if c.Tracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		c.Tracing.Shutdown(ctx)
+	}
+	if c.EventPublisher != nil {
+		c.EventPublisher.Close()
+	}
+	var err error
 	if c.DB != nil {
-		return c.DB.Close()
+		err = c.DB.Close()
+	}
+	if c.Pool != nil {
+		c.Pool.Close()
 	}
-	return nil
+	return err
 }
@@ -2,57 +2,260 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
+// Drivers supported by DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverPgx      = "pgx"
+	DriverSQLite   = "sqlite"
+)
+
 // Config holds database configuration
 type Config struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	// Driver selects the backend: postgres (database/sql with lib/pq), pgx (pgxpool) or sqlite
+	Driver string `yaml:"driver" toml:"driver" env:"DB_DRIVER"`
+
+	// SQLite database file and how long a connection waits for a lock before failing with SQLITE_BUSY
+	SQLitePath        string        `yaml:"sqlite_path" toml:"sqlite_path" env:"DB_SQLITE_PATH"`
+	SQLiteBusyTimeout time.Duration `yaml:"sqlite_busy_timeout" toml:"sqlite_busy_timeout" env:"DB_SQLITE_BUSY_TIMEOUT"`
+
+	// URL is a postgres:// connection URL, it takes precedence over the individual fields
+	URL string `yaml:"url" toml:"url" env:"DATABASE_URL"`
+
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     string `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	DBName   string `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+
+	// RowLevelSecurity sets app.tenant_id on each connection so PostgreSQL policies apply
+	RowLevelSecurity bool `yaml:"row_level_security" toml:"row_level_security" env:"DB_ROW_LEVEL_SECURITY"`
+
+	// EventSourcing stores order changes as events in order_events, the orders table
+	// becoming a projection of them. A snapshot is saved every SnapshotInterval events of an order.
+	EventSourcing    bool `yaml:"event_sourcing" toml:"event_sourcing" env:"DB_EVENT_SOURCING"`
+	SnapshotInterval int  `yaml:"snapshot_interval" toml:"snapshot_interval" env:"DB_SNAPSHOT_INTERVAL"`
+
+	// Connection pool settings, zero means unlimited
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+
+	// StatementTimeout aborts statements running longer than this on every connection, zero disables it
+	StatementTimeout time.Duration `yaml:"statement_timeout" toml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+
+	// Startup retry while PostgreSQL comes up, the delay doubles after each attempt up to ConnectMaxBackoff
+	ConnectAttempts   int           `yaml:"connect_attempts" toml:"connect_attempts" env:"DB_CONNECT_ATTEMPTS"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" toml:"connect_backoff" env:"DB_CONNECT_BACKOFF"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff" toml:"connect_max_backoff" env:"DB_CONNECT_MAX_BACKOFF"`
 }
 
-// NewConfig creates a new database config from environment variables
-func NewConfig() *Config {
-	return &Config{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "5432"),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "postgres"),
-		DBName:   getEnv("DB_NAME", "orders_db"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
+// DefaultConfig returns the default database configuration
+func DefaultConfig() Config {
+	return Config{
+		Driver:            DriverPostgres,
+		SQLitePath:        "orders.db",
+		SQLiteBusyTimeout: 5 * time.Second,
+		Host:              "localhost",
+		Port:              "5432",
+		User:              "postgres",
+		Password:          "postgres",
+		DBName:            "orders_db",
+		SSLMode:           "disable",
+		SnapshotInterval:  50,
+		MaxOpenConns:      25,
+		MaxIdleConns:      25,
+		ConnMaxLifetime:   5 * time.Minute,
+		ConnMaxIdleTime:   5 * time.Minute,
+		StatementTimeout:  30 * time.Second,
+		ConnectAttempts:   10,
+		ConnectBackoff:    500 * time.Millisecond,
+		ConnectMaxBackoff: 10 * time.Second,
 	}
 }
 
-// Connect establishes a connection to the database
+// Validate checks the database configuration
+func (c *Config) Validate() error {
+	var errs []error
+	switch c.Driver {
+	case DriverPostgres, DriverPgx:
+	case DriverSQLite:
+		if c.SQLitePath == "" {
+			errs = append(errs, errors.New("sqlite_path: must not be empty"))
+		}
+		if c.SQLiteBusyTimeout < 0 {
+			errs = append(errs, errors.New("sqlite_busy_timeout: must not be negative"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("driver: must be postgres, pgx or sqlite, got %q", c.Driver))
+	}
+	// PostgreSQL connection settings do not apply to SQLite
+	switch {
+	case c.Driver == DriverSQLite:
+	case c.URL != "":
+		if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			errs = append(errs, errors.New("url: must be a postgres:// or postgresql:// URL"))
+		}
+	default:
+		if c.Host == "" {
+			errs = append(errs, errors.New("host: must not be empty"))
+		}
+		if c.DBName == "" {
+			errs = append(errs, errors.New("name: must not be empty"))
+		}
+		switch c.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			errs = append(errs, fmt.Errorf("sslmode: unsupported value %q", c.SSLMode))
+		}
+	}
+	if c.SnapshotInterval < 1 {
+		errs = append(errs, errors.New("snapshot_interval: must be at least 1"))
+	}
+	if c.MaxOpenConns < 0 {
+		errs = append(errs, errors.New("max_open_conns: must not be negative"))
+	}
+	if c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("max_idle_conns: must not be negative"))
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, errors.New("max_idle_conns: must not exceed max_open_conns"))
+	}
+	if c.ConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("conn_max_lifetime: must not be negative"))
+	}
+	if c.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("conn_max_idle_time: must not be negative"))
+	}
+	if c.StatementTimeout < 0 {
+		errs = append(errs, errors.New("statement_timeout: must not be negative"))
+	}
+	if c.ConnectAttempts < 1 {
+		errs = append(errs, errors.New("connect_attempts: must be at least 1"))
+	}
+	if c.ConnectBackoff <= 0 {
+		errs = append(errs, errors.New("connect_backoff: must be positive"))
+	}
+	if c.ConnectMaxBackoff < c.ConnectBackoff {
+		errs = append(errs, errors.New("connect_max_backoff: must not be lower than connect_backoff"))
+	}
+	return errors.Join(errs...)
+}
+
+// DSN returns the connection string, adding the statement timeout as a run-time
+// parameter so PostgreSQL applies it to every connection opened by the pool
+func (c *Config) DSN() (string, error) {
+	timeout := ""
+	if c.StatementTimeout > 0 {
+		timeout = strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10)
+	}
+
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return "", fmt.Errorf("error parsing database URL: %w", err)
+		}
+		if timeout != "" {
+			query := u.Query()
+			if query.Get("statement_timeout") == "" {
+				query.Set("statement_timeout", timeout)
+			}
+			u.RawQuery = query.Encode()
+		}
+		return u.String(), nil
+	}
+
+	params := []string{
+		"host=" + quoteValue(c.Host),
+		"port=" + quoteValue(c.Port),
+		"user=" + quoteValue(c.User),
+		"password=" + quoteValue(c.Password),
+		"dbname=" + quoteValue(c.DBName),
+		"sslmode=" + quoteValue(c.SSLMode),
+	}
+	if timeout != "" {
+		params = append(params, "statement_timeout="+timeout)
+	}
+	return strings.Join(params, " "), nil
+}
+
+// Target describes the database for logs without exposing credentials
+func (c *Config) Target() (host, name string) {
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err == nil {
+			return u.Host, strings.TrimPrefix(u.Path, "/")
+		}
+	}
+	return c.Host, c.DBName
+}
+
+// quoteValue quotes a key=value connection parameter when it is empty or contains spaces or quotes
+func quoteValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// Connect establishes a connection to the database, retrying with exponential
+// backoff while PostgreSQL is not yet accepting connections
 func Connect(config *Config) (*sql.DB, error) {
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+	dsn, err := config.DSN()
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := sql.Open("postgres", psqlInfo)
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %v", err)
 	}
 
-	if err = db.Ping(); err != nil {
-		return nil, fmt.Errorf("error connecting to the database: %v", err)
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	if err := waitForDatabase(config, db.Ping); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	log.Println("Successfully connected to database")
 	return db, nil
 }
 
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// waitForDatabase calls ping until it succeeds, doubling the delay between attempts
+func waitForDatabase(config *Config, ping func() error) error {
+	host, name := config.Target()
+	backoff := config.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := ping()
+		if err == nil {
+			break
+		}
+		if attempt >= config.ConnectAttempts {
+			return fmt.Errorf("error connecting to the database after %d attempts: %v", attempt, err)
+		}
+
+		slog.Warn("Database not ready, retrying",
+			"host", host, "attempt", attempt, "retry_in", backoff.String(), "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, config.ConnectMaxBackoff)
 	}
-	return defaultValue
+
+	slog.Info("Successfully connected to database", "host", host, "database", name, "driver", config.Driver)
+	return nil
 }
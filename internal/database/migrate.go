@@ -0,0 +1,81 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// MigratePostgres applies the *.sql files of migrations not yet recorded in
+// schema_migrations, in file name order, returning the versions applied. With
+// baseline the pending files are recorded without running them, for databases
+// whose schema was created by the docker-entrypoint-initdb.d mount.
+func MigratePostgres(db *sql.DB, migrations fs.FS, baseline bool) ([]string, error) {
+	return applyMigrations(db, migrations, "$1", baseline)
+}
+
+// applyMigrations applies the *.sql files at the root of files not yet recorded
+// in schema_migrations, each in its own transaction. placeholder is the bind
+// parameter syntax of the driver.
+func applyMigrations(db *sql.DB, files fs.FS, placeholder string, baseline bool) ([]string, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("error listing migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	var applied []string
+	for _, name := range names {
+		version := strings.TrimSuffix(name, ".sql")
+
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = `+placeholder, version).Scan(&count)
+		if err != nil {
+			return applied, fmt.Errorf("error checking migration %s: %w", version, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		script, err := fs.ReadFile(files, name)
+		if err != nil {
+			return applied, fmt.Errorf("error reading migration %s: %w", version, err)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return applied, fmt.Errorf("error starting migration %s: %w", version, err)
+		}
+		if !baseline {
+			if _, err := tx.Exec(string(script)); err != nil {
+				tx.Rollback()
+				return applied, fmt.Errorf("error applying migration %s: %w", version, err)
+			}
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (`+placeholder+`)`, version); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("error recording migration %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return applied, fmt.Errorf("error committing migration %s: %w", version, err)
+		}
+
+		slog.Info("Applied migration", "version", version, "baseline", baseline)
+		applied = append(applied, version)
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,23 @@
+-- Create orders table, SQLite equivalent of migrations/001 to 003.
+-- IDs are stored as text UUIDs and timestamps as fixed-width UTC RFC 3339 text so they sort correctly.
+CREATE TABLE IF NOT EXISTS orders (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    description TEXT NOT NULL CHECK (length(description) <= 255),
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'confirmed', 'shipped', 'delivered', 'cancelled')),
+    idempotency_key TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+-- Create index on tenant_id and created_at for tenant scoped list queries
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_created_at ON orders(tenant_id, created_at);
+
+-- Idempotency keys are unique per tenant only
+CREATE UNIQUE INDEX IF NOT EXISTS uq_orders_tenant_idempotency_key
+    ON orders(tenant_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
+
+-- Create index on tenant_id and status for status breakdowns
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_status ON orders(tenant_id, status);
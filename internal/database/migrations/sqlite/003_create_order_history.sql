@@ -0,0 +1,30 @@
+-- Create order_history, SQLite equivalent of migrations/005_create_order_history.sql.
+-- There is no foreign key to orders so the history outlives purged orders.
+CREATE TABLE IF NOT EXISTS order_history (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    order_id TEXT NOT NULL,
+    action TEXT NOT NULL
+        CHECK (action IN ('created', 'updated', 'status_changed', 'deleted', 'restored', 'purged')),
+    actor TEXT NOT NULL,
+    request_id TEXT,
+    changes TEXT NOT NULL DEFAULT '[]',
+    created_at TEXT NOT NULL
+);
+
+-- Create index on tenant_id, order_id and created_at for the history of an order
+CREATE INDEX IF NOT EXISTS idx_order_history_tenant_order
+    ON order_history(tenant_id, order_id, created_at);
+
+-- Entries are immutable, reject any update or delete
+CREATE TRIGGER IF NOT EXISTS trg_order_history_no_update
+    BEFORE UPDATE ON order_history
+BEGIN
+    SELECT RAISE(ABORT, 'order_history entries are immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_order_history_no_delete
+    BEFORE DELETE ON order_history
+BEGIN
+    SELECT RAISE(ABORT, 'order_history entries are immutable');
+END;
@@ -0,0 +1,40 @@
+-- Create order_events and order_snapshots, SQLite equivalent of migrations/007_create_order_events.sql.
+-- There is no foreign key to orders, which is only a projection of the streams.
+CREATE TABLE IF NOT EXISTS order_events (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    order_id TEXT NOT NULL,
+    sequence INTEGER NOT NULL CHECK (sequence > 0),
+    type TEXT NOT NULL
+        CHECK (type IN ('order.created', 'order.imported', 'order.updated', 'order.status_changed',
+                        'order.deleted', 'order.restored', 'order.purged')),
+    data TEXT NOT NULL DEFAULT '{}',
+    occurred_at TEXT NOT NULL,
+    -- A single event per position, concurrent writers of a stream conflict here
+    UNIQUE (order_id, sequence)
+);
+
+-- Create index on type and occurred_at for the purge of deleted orders
+CREATE INDEX IF NOT EXISTS idx_order_events_type_occurred_at ON order_events(type, occurred_at);
+
+-- Events are immutable, reject any update or delete
+CREATE TRIGGER IF NOT EXISTS trg_order_events_no_update
+    BEFORE UPDATE ON order_events
+BEGIN
+    SELECT RAISE(ABORT, 'order_events are immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_order_events_no_delete
+    BEFORE DELETE ON order_events
+BEGIN
+    SELECT RAISE(ABORT, 'order_events are immutable');
+END;
+
+CREATE TABLE IF NOT EXISTS order_snapshots (
+    tenant_id TEXT NOT NULL,
+    order_id TEXT NOT NULL,
+    sequence INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (order_id, sequence)
+);
@@ -0,0 +1,41 @@
+-- Create order_summaries, SQLite equivalent of migrations/008_create_order_summaries.sql.
+CREATE TABLE IF NOT EXISTS order_summaries (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    deleted_at TEXT,
+    -- Number of recorded changes of the order
+    version INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT '',
+    last_action TEXT NOT NULL DEFAULT '',
+    last_actor TEXT NOT NULL DEFAULT '',
+    last_changed_at TEXT NOT NULL,
+    status_changed_at TEXT NOT NULL
+);
+
+-- Create index on tenant_id and created_at for listing the orders of a tenant
+CREATE INDEX IF NOT EXISTS idx_order_summaries_tenant_created_at ON order_summaries(tenant_id, created_at);
+
+-- Project the existing orders, same query as OrderSummaryRepository.Rebuild
+INSERT INTO order_summaries (id, tenant_id, description, status, created_at, updated_at, deleted_at,
+                             version, created_by, last_action, last_actor, last_changed_at, status_changed_at)
+SELECT o.id, o.tenant_id, o.description, o.status, o.created_at, o.updated_at, o.deleted_at,
+    (SELECT COUNT(*) FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id),
+    COALESCE((SELECT h.actor FROM order_history h
+              WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id AND h.action = 'created'
+              ORDER BY h.created_at LIMIT 1), ''),
+    COALESCE((SELECT h.action FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id
+              ORDER BY h.created_at DESC, h.id DESC LIMIT 1), ''),
+    COALESCE((SELECT h.actor FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id
+              ORDER BY h.created_at DESC, h.id DESC LIMIT 1), ''),
+    COALESCE((SELECT MAX(h.created_at) FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id),
+             o.updated_at),
+    COALESCE((SELECT MAX(h.created_at) FROM order_history h
+              WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id AND h.action = 'status_changed'),
+             o.created_at)
+FROM orders o
+WHERE true
+ON CONFLICT (id) DO NOTHING;
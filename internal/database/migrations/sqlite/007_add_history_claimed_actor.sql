@@ -0,0 +1,2 @@
+-- Add claimed_actor, SQLite equivalent of migrations/010_add_history_claimed_actor.sql
+ALTER TABLE order_history ADD COLUMN claimed_actor TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Add items, SQLite equivalent of migrations/011_add_order_items.sql. The search
+-- of SQLite scans the summaries in memory, so there is no text index to update.
+ALTER TABLE orders ADD COLUMN items TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE order_summaries ADD COLUMN items TEXT NOT NULL DEFAULT '[]';
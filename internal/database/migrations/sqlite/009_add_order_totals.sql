@@ -0,0 +1,15 @@
+-- Add the currency and the order total, SQLite equivalent of
+-- migrations/012_add_order_totals.sql
+ALTER TABLE orders ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL';
+ALTER TABLE orders ADD COLUMN total_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_summaries ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL';
+ALTER TABLE order_summaries ADD COLUMN total_amount INTEGER NOT NULL DEFAULT 0;
+
+-- Total the orders stored with items before this migration
+UPDATE orders SET total_amount = (
+    SELECT COALESCE(SUM(json_extract(item.value, '$.quantity') * json_extract(item.value, '$.unit_price')), 0)
+    FROM json_each(orders.items) item
+) WHERE items <> '[]';
+UPDATE order_summaries SET total_amount = (
+    SELECT orders.total_amount FROM orders WHERE orders.id = order_summaries.id
+) WHERE id IN (SELECT id FROM orders WHERE total_amount <> 0);
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConnectPool establishes a pgx connection pool, retrying with exponential backoff
+// while PostgreSQL is not yet accepting connections. configure, when not nil, can
+// adjust the pool configuration, e.g. to install a tracer or prepare statements.
+func ConnectPool(config *Config, configure func(*pgxpool.Config)) (*pgxpool.Pool, error) {
+	dsn, err := config.DSN()
+	if err != nil {
+		return nil, err
+	}
+
+	// Unknown parameters such as statement_timeout become run-time parameters of every connection
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing database configuration: %v", err)
+	}
+
+	if config.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(config.MaxOpenConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.ConnMaxLifetime
+	}
+	if config.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.ConnMaxIdleTime
+	}
+
+	if configure != nil {
+		configure(poolConfig)
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+
+	if err := waitForDatabase(config, func() error { return pool.Ping(context.Background()) }); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
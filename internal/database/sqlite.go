@@ -0,0 +1,58 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations holds the SQLite schema, PostgreSQL uses the files in /migrations
+//
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// ConnectSQLite opens the SQLite database file in WAL mode and applies pending migrations.
+// Every pooled connection gets the busy timeout, so writers wait for the lock instead of failing.
+func ConnectSQLite(config *Config) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", config.SQLiteBusyTimeout.Milliseconds()))
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "synchronous(NORMAL)")
+	// Take the write lock when a transaction begins to avoid deadlocks on lock upgrades
+	query.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+config.SQLitePath+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	slog.Info("Successfully connected to database", "database", config.SQLitePath, "driver", config.Driver)
+	return db, nil
+}
+
+// migrateSQLite applies the embedded migrations not yet recorded in schema_migrations
+func migrateSQLite(db *sql.DB) error {
+	files, err := fs.Sub(sqliteMigrations, "migrations/sqlite")
+	if err != nil {
+		return fmt.Errorf("error listing migrations: %w", err)
+	}
+
+	_, err = applyMigrations(db, files, "?", false)
+	return err
+}
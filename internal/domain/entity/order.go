@@ -1,17 +1,98 @@
 package entity
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrInvalidOrder is returned when an order change would leave the order invalid
+var ErrInvalidOrder = errors.New("invalid order")
+
+// MaxDescriptionLength is the length of the orders.description column
+const MaxDescriptionLength = 255
+
+// MaxItemNameLength is the length an item name may have, like a description
+const MaxItemNameLength = 255
+
+// MaxOrderItems is the number of items an order may have
+const MaxOrderItems = 100
+
+// MaxItemQuantity is the number of units an item may have
+const MaxItemQuantity = 10000
+
+// MaxItemUnitPrice is the unit price an item may have in minor units, which
+// keeps the total of an order far from overflowing
+const MaxItemUnitPrice = 100_000_000
+
+// DefaultCurrency is the currency of the orders created without one
+const DefaultCurrency = "BRL"
+
+// OrderStatus represents the lifecycle state of an order
+type OrderStatus string
+
+// Order statuses
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusConfirmed OrderStatus = "confirmed"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// OrderStatuses lists every valid order status
+var OrderStatuses = []OrderStatus{
+	OrderStatusPending,
+	OrderStatusConfirmed,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+}
+
+// Valid reports whether s is a known order status
+func (s OrderStatus) Valid() bool {
+	for _, status := range OrderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderItem is a line of an order: a product, how many units and their price
+type OrderItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	// UnitPrice is the price of one unit in minor units of the currency, e.g. cents
+	UnitPrice int64 `json:"unit_price"`
+}
+
 // Order represents the order entity in the domain
 type Order struct {
-	ID          uuid.UUID `json:"id"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID   `json:"id"`
+	TenantID    string      `json:"tenant_id"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items,omitempty"`
+	// Currency is the ISO 4217 code of the item prices, e.g. BRL
+	Currency string `json:"currency"`
+	// TotalAmount is the sum of the items in minor units of Currency, kept by SetItems
+	TotalAmount    int64       `json:"total_amount"`
+	Status         OrderStatus `json:"status"`
+	IdempotencyKey string      `json:"idempotency_key,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	DeletedAt      *time.Time  `json:"deleted_at,omitempty"`
+}
+
+// ItemsTotal returns the price of items: the unit prices times the quantities
+func ItemsTotal(items []OrderItem) int64 {
+	var total int64
+	for _, item := range items {
+		total += int64(item.Quantity) * item.UnitPrice
+	}
+	return total
 }
 
 // NewOrder creates a new order with the given description
@@ -20,6 +101,8 @@ func NewOrder(description string) *Order {
 	return &Order{
 		ID:          uuid.New(),
 		Description: description,
+		Currency:    DefaultCurrency,
+		Status:      OrderStatusPending,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -30,3 +113,71 @@ func (o *Order) UpdateDescription(description string) {
 	o.Description = description
 	o.UpdatedAt = time.Now()
 }
+
+// SetItems sets the items of an order being built and their total
+func (o *Order) SetItems(items []OrderItem) {
+	o.Items = items
+	o.TotalAmount = ItemsTotal(items)
+}
+
+// ReplaceItems replaces the order items and sets the updated_at timestamp
+func (o *Order) ReplaceItems(items []OrderItem) {
+	o.SetItems(items)
+	o.UpdatedAt = time.Now()
+}
+
+// ChangeStatus moves the order to status and sets the updated_at timestamp
+func (o *Order) ChangeStatus(status OrderStatus) error {
+	if !status.Valid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidOrder, status)
+	}
+	o.Status = status
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// Validate checks the fields a caller may set
+func (o *Order) Validate() error {
+	switch {
+	case o.Description == "":
+		return fmt.Errorf("%w: description must not be empty", ErrInvalidOrder)
+	case len([]rune(o.Description)) > MaxDescriptionLength:
+		return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidOrder, MaxDescriptionLength)
+	case !o.Status.Valid():
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidOrder, o.Status)
+	case !validCurrency(o.Currency):
+		return fmt.Errorf("%w: currency must be an ISO 4217 code such as BRL, got %q", ErrInvalidOrder, o.Currency)
+	case len(o.Items) > MaxOrderItems:
+		return fmt.Errorf("%w: an order must have at most %d items", ErrInvalidOrder, MaxOrderItems)
+	}
+	for i, item := range o.Items {
+		switch {
+		case item.Name == "":
+			return fmt.Errorf("%w: name of item %d must not be empty", ErrInvalidOrder, i+1)
+		case len([]rune(item.Name)) > MaxItemNameLength:
+			return fmt.Errorf("%w: name of item %d must be at most %d characters", ErrInvalidOrder, i+1, MaxItemNameLength)
+		case item.Quantity < 1 || item.Quantity > MaxItemQuantity:
+			return fmt.Errorf("%w: quantity of item %d must be between 1 and %d", ErrInvalidOrder, i+1, MaxItemQuantity)
+		case item.UnitPrice < 0 || item.UnitPrice > MaxItemUnitPrice:
+			return fmt.Errorf("%w: unit price of item %d must be between 0 and %d", ErrInvalidOrder, i+1, MaxItemUnitPrice)
+		}
+	}
+	if o.TotalAmount != ItemsTotal(o.Items) {
+		return fmt.Errorf("%w: total amount %d is not the sum of the items", ErrInvalidOrder, o.TotalAmount)
+	}
+	return nil
+}
+
+// validCurrency reports whether code has the form of an ISO 4217 code: three
+// uppercase letters
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidOrderEvent is returned when an event cannot be applied to an order
+var ErrInvalidOrderEvent = errors.New("invalid order event")
+
+// OrderImportedEventType starts the stream of an order that existed before
+// event sourcing was enabled, carrying its complete state at that time
+const OrderImportedEventType = "order.imported"
+
+// OrderEventData holds the fields of an order set by an event, nil when the
+// event leaves them unchanged
+type OrderEventData struct {
+	Description    *string      `json:"description,omitempty"`
+	Items          *[]OrderItem `json:"items,omitempty"`
+	Currency       string       `json:"currency,omitempty"`
+	Status         *OrderStatus `json:"status,omitempty"`
+	IdempotencyKey string       `json:"idempotency_key,omitempty"`
+	CreatedAt      *time.Time   `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time   `json:"updated_at,omitempty"`
+	DeletedAt      *time.Time   `json:"deleted_at,omitempty"`
+}
+
+// OrderEvent is an immutable fact in the event stream of an order. Types are
+// the order event types, e.g. order.created, and OrderImportedEventType.
+type OrderEvent struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID string    `json:"tenant_id"`
+	OrderID  uuid.UUID `json:"order_id"`
+	// Sequence is the 1-based position of the event in the stream of the order
+	Sequence   int64          `json:"sequence"`
+	Type       string         `json:"type"`
+	Data       OrderEventData `json:"data"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// OrderSnapshot is the state of an order after the event at Sequence, so it can
+// be rehydrated without replaying its whole stream
+type OrderSnapshot struct {
+	TenantID  string    `json:"tenant_id"`
+	OrderID   uuid.UUID `json:"order_id"`
+	Sequence  int64     `json:"sequence"`
+	State     *Order    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderAggregate is an order rehydrated from its event stream
+type OrderAggregate struct {
+	ID uuid.UUID
+	// Order is the current state, nil before the first event and once purged
+	Order *Order
+	// Version is the sequence of the last applied event, 0 for a new stream
+	Version int64
+}
+
+// NewOrderAggregate creates the aggregate of an order without events
+func NewOrderAggregate(id uuid.UUID) *OrderAggregate {
+	return &OrderAggregate{ID: id}
+}
+
+// RehydrateOrder rebuilds an order from its latest snapshot, which may be nil,
+// and the events that followed it
+func RehydrateOrder(id uuid.UUID, snapshot *OrderSnapshot, events []*OrderEvent) (*OrderAggregate, error) {
+	aggregate := NewOrderAggregate(id)
+	if snapshot != nil {
+		state := *snapshot.State
+		// Snapshots taken before orders had a currency and a total lack them
+		if state.Currency == "" {
+			state.Currency = DefaultCurrency
+		}
+		state.SetItems(state.Items)
+		aggregate.Order = &state
+		aggregate.Version = snapshot.Sequence
+	}
+
+	for _, event := range events {
+		if err := aggregate.Apply(event); err != nil {
+			return nil, err
+		}
+	}
+	return aggregate, nil
+}
+
+// Record creates the next event of the stream and applies it
+func (a *OrderAggregate) Record(tenantID, eventType string, data OrderEventData, occurredAt time.Time) (*OrderEvent, error) {
+	event := &OrderEvent{
+		ID:         uuid.New(),
+		TenantID:   tenantID,
+		OrderID:    a.ID,
+		Sequence:   a.Version + 1,
+		Type:       eventType,
+		Data:       data,
+		OccurredAt: occurredAt,
+	}
+	if err := a.Apply(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Apply updates the state with the next event of the stream
+func (a *OrderAggregate) Apply(event *OrderEvent) error {
+	if event.Sequence != a.Version+1 {
+		return fmt.Errorf("%w: expected sequence %d, got %d", ErrInvalidOrderEvent, a.Version+1, event.Sequence)
+	}
+
+	data := event.Data
+	switch event.Type {
+	case OrderEventType(OrderHistoryCreated), OrderImportedEventType:
+		if a.Order != nil {
+			return fmt.Errorf("%w: %s on an existing order", ErrInvalidOrderEvent, event.Type)
+		}
+		if data.Description == nil || data.Status == nil || data.CreatedAt == nil || data.UpdatedAt == nil {
+			return fmt.Errorf("%w: %s without the complete order", ErrInvalidOrderEvent, event.Type)
+		}
+		a.Order = &Order{
+			ID:             a.ID,
+			TenantID:       event.TenantID,
+			Description:    *data.Description,
+			Currency:       data.Currency,
+			Status:         *data.Status,
+			IdempotencyKey: data.IdempotencyKey,
+			CreatedAt:      *data.CreatedAt,
+			UpdatedAt:      *data.UpdatedAt,
+			DeletedAt:      data.DeletedAt,
+		}
+		if data.Items != nil {
+			a.Order.SetItems(*data.Items)
+		}
+		// Events recorded before orders had a currency lack it
+		if a.Order.Currency == "" {
+			a.Order.Currency = DefaultCurrency
+		}
+	case OrderEventType(OrderHistoryUpdated), OrderEventType(OrderHistoryStatusChanged):
+		if err := a.requireOrder(event, data.UpdatedAt); err != nil {
+			return err
+		}
+		if data.Description != nil {
+			a.Order.Description = *data.Description
+		}
+		if data.Items != nil {
+			a.Order.SetItems(*data.Items)
+		}
+		if data.Status != nil {
+			a.Order.Status = *data.Status
+		}
+		a.Order.UpdatedAt = *data.UpdatedAt
+	case OrderEventType(OrderHistoryDeleted):
+		if err := a.requireOrder(event, data.DeletedAt); err != nil {
+			return err
+		}
+		a.Order.DeletedAt = data.DeletedAt
+		a.Order.UpdatedAt = *data.DeletedAt
+	case OrderEventType(OrderHistoryRestored):
+		if err := a.requireOrder(event, data.UpdatedAt); err != nil {
+			return err
+		}
+		a.Order.DeletedAt = nil
+		a.Order.UpdatedAt = *data.UpdatedAt
+	case OrderEventType(OrderHistoryPurged):
+		if err := a.requireOrder(event, &event.OccurredAt); err != nil {
+			return err
+		}
+		a.Order = nil
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidOrderEvent, event.Type)
+	}
+
+	a.Version = event.Sequence
+	return nil
+}
+
+// requireOrder checks that an event changing the order applies to an existing
+// order and carries its timestamp
+func (a *OrderAggregate) requireOrder(event *OrderEvent, timestamp *time.Time) error {
+	if a.Order == nil {
+		return fmt.Errorf("%w: %s before the order was created", ErrInvalidOrderEvent, event.Type)
+	}
+	if timestamp == nil {
+		return fmt.Errorf("%w: %s without a timestamp", ErrInvalidOrderEvent, event.Type)
+	}
+	return nil
+}
+
+// Snapshot returns a snapshot of the current state, nil once the order is purged
+func (a *OrderAggregate) Snapshot() *OrderSnapshot {
+	if a.Order == nil {
+		return nil
+	}
+	state := *a.Order
+	return &OrderSnapshot{
+		TenantID:  state.TenantID,
+		OrderID:   a.ID,
+		Sequence:  a.Version,
+		State:     &state,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderHistoryAction identifies the kind of change recorded in the order history
+type OrderHistoryAction string
+
+// Order history actions
+const (
+	OrderHistoryCreated       OrderHistoryAction = "created"
+	OrderHistoryUpdated       OrderHistoryAction = "updated"
+	OrderHistoryStatusChanged OrderHistoryAction = "status_changed"
+	OrderHistoryDeleted       OrderHistoryAction = "deleted"
+	OrderHistoryRestored      OrderHistoryAction = "restored"
+	OrderHistoryPurged        OrderHistoryAction = "purged"
+)
+
+// OrderEventType returns the type of the events published for an order
+// history action, e.g. order.created
+func OrderEventType(action OrderHistoryAction) string {
+	return "order." + string(action)
+}
+
+// FieldChange is the before and after value of a single order field, nil when absent
+type FieldChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// OrderHistoryEntry is an immutable record of a change to an order
+type OrderHistoryEntry struct {
+	ID           uuid.UUID          `json:"id"`
+	TenantID     string             `json:"tenant_id"`
+	OrderID      uuid.UUID          `json:"order_id"`
+	Action       OrderHistoryAction `json:"action"`
+	Actor        string             `json:"actor"`                   // identity of the credential the change was made with
+	ClaimedActor string             `json:"claimed_actor,omitempty"` // caller named by the actor header, not verified
+	RequestID    string             `json:"request_id,omitempty"`
+	Changes      []FieldChange      `json:"changes"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// NewOrderHistoryEntry creates a history entry for a change made now
+func NewOrderHistoryEntry(orderID uuid.UUID, action OrderHistoryAction, changes []FieldChange) *OrderHistoryEntry {
+	return &OrderHistoryEntry{
+		ID:        uuid.New(),
+		OrderID:   orderID,
+		Action:    action,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+}
+
+// DiffOrders returns the fields that differ between two versions of an order.
+// before is nil for a created order and after is nil for a purged one.
+func DiffOrders(before, after *Order) []FieldChange {
+	var changes []FieldChange
+	for _, field := range auditedOrderFields {
+		var old, new *string
+		if before != nil {
+			old = field.value(before)
+		}
+		if after != nil {
+			new = field.value(after)
+		}
+		if old == nil && new == nil || old != nil && new != nil && *old == *new {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field.name, Before: old, After: new})
+	}
+	return changes
+}
+
+// auditedOrderFields are the order fields compared by DiffOrders
+var auditedOrderFields = []struct {
+	name  string
+	value func(*Order) *string
+}{
+	{"description", func(o *Order) *string { return &o.Description }},
+	{"items", func(o *Order) *string {
+		if len(o.Items) == 0 {
+			return nil
+		}
+		encoded, _ := json.Marshal(o.Items)
+		items := string(encoded)
+		return &items
+	}},
+	{"currency", func(o *Order) *string { return &o.Currency }},
+	{"status", func(o *Order) *string { status := string(o.Status); return &status }},
+	{"deleted_at", func(o *Order) *string {
+		if o.DeletedAt == nil {
+			return nil
+		}
+		deletedAt := o.DeletedAt.UTC().Format(time.RFC3339)
+		return &deletedAt
+	}},
+}
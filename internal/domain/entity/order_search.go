@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidSearchQuery is returned when a search query is empty or malformed
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+// Search query limits
+const (
+	MaxSearchQueryLength = 256
+	MaxSearchTerms       = 16
+)
+
+// SearchTerm is a single term of a search query: one word, or a phrase of
+// consecutive words when quoted. With Prefix the last word also matches the
+// words starting with it.
+type SearchTerm struct {
+	Words  []string
+	Prefix bool
+}
+
+// SearchQuery is a parsed full-text search. An order matches when its
+// description and item names, OrderSummary.SearchText, contain every term.
+type SearchQuery struct {
+	Text  string
+	Terms []SearchTerm
+}
+
+// ParseSearchQuery parses a search such as `bolo "de chocolate" choc*`: words
+// are matched individually, quoted words as a phrase and a trailing * makes the
+// last word a prefix. Words are compared lowercased and punctuation separates them.
+func ParseSearchQuery(text string) (SearchQuery, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return SearchQuery{}, fmt.Errorf("%w: must not be empty", ErrInvalidSearchQuery)
+	}
+	if len(text) > MaxSearchQueryLength {
+		return SearchQuery{}, fmt.Errorf("%w: must be at most %d characters", ErrInvalidSearchQuery, MaxSearchQueryLength)
+	}
+
+	query := SearchQuery{Text: text}
+	rest := text
+	for rest != "" {
+		var token string
+		phrase := rest[0] == '"'
+		if phrase {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return SearchQuery{}, fmt.Errorf("%w: unterminated quote", ErrInvalidSearchQuery)
+			}
+			token, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			if end < 0 {
+				end = len(rest)
+			}
+			token, rest = rest[:end], rest[end:]
+		}
+
+		// A * right after the token, inside or outside the quotes, marks a prefix
+		prefix := strings.HasSuffix(token, "*")
+		if strings.HasPrefix(rest, "*") {
+			prefix, rest = true, rest[1:]
+		}
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+
+		// Punctuated words such as e-mail match as a phrase, like quoted ones
+		words := SearchWords(token)
+		if len(words) == 0 {
+			continue
+		}
+		query.Terms = append(query.Terms, SearchTerm{Words: words, Prefix: prefix})
+	}
+
+	if len(query.Terms) == 0 {
+		return SearchQuery{}, fmt.Errorf("%w: must contain a letter or digit", ErrInvalidSearchQuery)
+	}
+	if len(query.Terms) > MaxSearchTerms {
+		return SearchQuery{}, fmt.Errorf("%w: must have at most %d terms", ErrInvalidSearchQuery, MaxSearchTerms)
+	}
+
+	return query, nil
+}
+
+// SearchWords splits text into lowercase words of letters and digits
+func SearchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// OrderSearchResult is an order matching a search
+type OrderSearchResult struct {
+	Summary *OrderSummary
+	// Rank orders the results by relevance, higher first. Its scale depends on the backend.
+	Rank float64
+	// Snippet is an excerpt of the search text with the matches wrapped in
+	// <mark></mark>, the text itself is not escaped
+	Snippet string
+}
@@ -0,0 +1,102 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidStatsQuery is returned when an order statistics query is malformed
+var ErrInvalidStatsQuery = errors.New("invalid order stats query")
+
+// MaxStatsBuckets bounds the number of periods a statistics query may span
+const MaxStatsBuckets = 1000
+
+// StatsGroupBy is the period the order statistics are bucketed by
+type StatsGroupBy string
+
+// Stats periods
+const (
+	StatsGroupByDay   StatsGroupBy = "day"
+	StatsGroupByWeek  StatsGroupBy = "week"
+	StatsGroupByMonth StatsGroupBy = "month"
+)
+
+// Valid reports whether g is a known period
+func (g StatsGroupBy) Valid() bool {
+	switch g {
+	case StatsGroupByDay, StatsGroupByWeek, StatsGroupByMonth:
+		return true
+	}
+	return false
+}
+
+// Truncate returns the start of the period containing t in loc. Weeks start
+// on Monday, like date_trunc in PostgreSQL.
+func (g StatsGroupBy) Truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	year, month, day := t.Date()
+	switch g {
+	case StatsGroupByWeek:
+		weekday := (int(t.Weekday()) + 6) % 7 // days since Monday
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, loc)
+	case StatsGroupByMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+}
+
+// Next returns the start of the period following the one starting at start
+func (g StatsGroupBy) Next(start time.Time) time.Time {
+	switch g {
+	case StatsGroupByWeek:
+		return start.AddDate(0, 0, 7)
+	case StatsGroupByMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// OrderStatsQuery selects the orders created in [From, To) of the current
+// tenant, bucketed by GroupBy in the time zone Location
+type OrderStatsQuery struct {
+	GroupBy        StatsGroupBy
+	From           time.Time
+	To             time.Time
+	Location       *time.Location
+	IncludeDeleted bool
+}
+
+// Validate checks the query, Location must be an IANA time zone so SQL can use it
+func (q OrderStatsQuery) Validate() error {
+	if !q.GroupBy.Valid() {
+		return fmt.Errorf("%w: group by must be day, week or month, got %q", ErrInvalidStatsQuery, q.GroupBy)
+	}
+	if q.Location == nil || q.Location == time.Local {
+		return fmt.Errorf("%w: time zone must be an IANA name such as America/Sao_Paulo", ErrInvalidStatsQuery)
+	}
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidStatsQuery)
+	}
+
+	buckets := 0
+	for start := q.GroupBy.Truncate(q.From, q.Location); start.Before(q.To); start = q.GroupBy.Next(start) {
+		if buckets++; buckets > MaxStatsBuckets {
+			return fmt.Errorf("%w: spans more than %d periods", ErrInvalidStatsQuery, MaxStatsBuckets)
+		}
+	}
+	return nil
+}
+
+// OrderStatsRow is the number of orders with a status and currency created in
+// the period starting at Bucket, and the sum of their totals
+type OrderStatsRow struct {
+	Bucket   time.Time
+	Status   OrderStatus
+	Currency string
+	Count    int64
+	// Amount is the sum of the order totals in minor units of Currency
+	Amount int64
+}
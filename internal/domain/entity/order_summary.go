@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderSummary is the denormalized view of an order read by the query side. It
+// is projected from the changes recorded in the order history.
+type OrderSummary struct {
+	ID          uuid.UUID   `json:"id"`
+	TenantID    string      `json:"tenant_id"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items,omitempty"`
+	Currency    string      `json:"currency"`
+	TotalAmount int64       `json:"total_amount"`
+	Status      OrderStatus `json:"status"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	DeletedAt   *time.Time  `json:"deleted_at,omitempty"`
+	// Version is the number of recorded changes of the order
+	Version int64 `json:"version"`
+	// CreatedBy is the actor of the creation, empty when it was not recorded
+	CreatedBy     string             `json:"created_by"`
+	LastAction    OrderHistoryAction `json:"last_action"`
+	LastActor     string             `json:"last_actor"`
+	LastChangedAt time.Time          `json:"last_changed_at"`
+	// StatusChangedAt is when the order got its current status
+	StatusChangedAt time.Time `json:"status_changed_at"`
+}
+
+// Apply updates the summary with a recorded change and the order after it.
+// A zero summary is started from the order, so changes of orders created
+// before the projection existed are projected as well.
+func (s *OrderSummary) Apply(entry *OrderHistoryEntry, order *Order) {
+	if s.ID == uuid.Nil {
+		s.StatusChangedAt = order.CreatedAt
+	}
+	if entry.Action == OrderHistoryCreated {
+		s.CreatedBy = entry.Actor
+	}
+	if entry.Action == OrderHistoryStatusChanged {
+		s.StatusChangedAt = entry.CreatedAt
+	}
+
+	s.ID = order.ID
+	s.TenantID = order.TenantID
+	s.Description = order.Description
+	s.Items = order.Items
+	s.Currency = order.Currency
+	s.TotalAmount = order.TotalAmount
+	s.Status = order.Status
+	s.CreatedAt = order.CreatedAt
+	s.UpdatedAt = order.UpdatedAt
+	s.DeletedAt = order.DeletedAt
+	s.Version++
+	s.LastAction = entry.Action
+	s.LastActor = entry.Actor
+	s.LastChangedAt = entry.CreatedAt
+}
+
+// SearchTextSeparator separates the description and the item names in SearchText
+const SearchTextSeparator = " | "
+
+// SearchText is the text matched by the full-text search and excerpted in its
+// snippets: the description followed by the names of the items
+func (s *OrderSummary) SearchText() string {
+	var b strings.Builder
+	b.WriteString(s.Description)
+	for _, item := range s.Items {
+		b.WriteString(SearchTextSeparator)
+		b.WriteString(item.Name)
+	}
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package entity
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidWebhookEndpoint is returned when a webhook endpoint fails validation
+	ErrInvalidWebhookEndpoint = errors.New("invalid webhook endpoint")
+	// ErrInvalidWebhookDeliveryStatus is returned for an unknown webhook delivery status
+	ErrInvalidWebhookDeliveryStatus = errors.New("invalid webhook delivery status")
+)
+
+// WebhookEventTypes lists the order events webhook endpoints can subscribe to,
+// one per order history action
+var WebhookEventTypes = []string{
+	OrderEventType(OrderHistoryCreated),
+	OrderEventType(OrderHistoryUpdated),
+	OrderEventType(OrderHistoryStatusChanged),
+	OrderEventType(OrderHistoryDeleted),
+	OrderEventType(OrderHistoryRestored),
+	OrderEventType(OrderHistoryPurged),
+}
+
+// minWebhookSecretLength is the shortest secret accepted for signing deliveries
+const minWebhookSecretLength = 16
+
+// WebhookEndpoint is a URL of a tenant notified of order events
+type WebhookEndpoint struct {
+	ID         uuid.UUID `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	// Secret signs the deliveries with HMAC-SHA256
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewWebhookEndpoint validates and creates a webhook endpoint, generating a
+// secret when none is given
+func NewWebhookEndpoint(rawURL string, eventTypes []string, secret string) (*WebhookEndpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhookEndpoint)
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhookEndpoint)
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(WebhookEventTypes, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q, expected one of %v", ErrInvalidWebhookEndpoint, eventType, WebhookEventTypes)
+		}
+	}
+
+	switch {
+	case secret == "":
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	case len(secret) < minWebhookSecretLength:
+		return nil, fmt.Errorf("%w: secret must have at least %d characters", ErrInvalidWebhookEndpoint, minWebhookSecretLength)
+	}
+
+	return &WebhookEndpoint{
+		ID:         uuid.New(),
+		URL:        rawURL,
+		EventTypes: slices.Compact(slices.Sorted(slices.Values(eventTypes))),
+		Secret:     secret,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// Subscribes reports whether the endpoint receives events of the given type
+func (e *WebhookEndpoint) Subscribes(eventType string) bool {
+	return slices.Contains(e.EventTypes, eventType)
+}
+
+// generateWebhookSecret returns a random secret
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// WebhookDeliveryStatus represents the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+// Webhook delivery statuses
+const (
+	// WebhookDeliveryPending deliveries are sent at NextAttemptAt
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliverySucceeded deliveries were acknowledged with a 2xx response
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryDead deliveries failed every attempt and are no longer retried
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// Valid reports whether s is a known webhook delivery status
+func (s WebhookDeliveryStatus) Valid() bool {
+	switch s {
+	case WebhookDeliveryPending, WebhookDeliverySucceeded, WebhookDeliveryDead:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is an order event to be sent, or sent, to a webhook endpoint
+type WebhookDelivery struct {
+	ID         uuid.UUID `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	EndpointID uuid.UUID `json:"endpoint_id"`
+	// EventID identifies the event across endpoints and retries, receivers use it for deduplication
+	EventID        uuid.UUID             `json:"event_id"`
+	EventType      string                `json:"event_type"`
+	Payload        []byte                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+// NewWebhookDelivery creates a pending delivery of an event to an endpoint, due now
+func NewWebhookDelivery(endpoint *WebhookEndpoint, eventID uuid.UUID, eventType string, payload []byte) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		ID:            uuid.New(),
+		TenantID:      endpoint.TenantID,
+		EndpointID:    endpoint.ID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// Succeed records a successful attempt
+func (d *WebhookDelivery) Succeed(statusCode int) {
+	now := time.Now()
+	d.Attempts++
+	d.Status = WebhookDeliverySucceeded
+	d.LastStatusCode = statusCode
+	d.LastError = ""
+	d.DeliveredAt = &now
+	d.UpdatedAt = now
+}
+
+// Fail records a failed attempt, retrying at retryAt or moving the delivery to
+// the dead state once maxAttempts attempts have failed
+func (d *WebhookDelivery) Fail(statusCode int, reason string, maxAttempts int, retryAt time.Time) {
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	d.LastError = reason
+	d.UpdatedAt = time.Now()
+	if d.Attempts >= maxAttempts {
+		d.Status = WebhookDeliveryDead
+		return
+	}
+	d.NextAttemptAt = retryAt
+}
+
+// Redeliver makes a dead or succeeded delivery pending again, due now, with a fresh attempt budget
+func (d *WebhookDelivery) Redeliver() {
+	now := time.Now()
+	d.Status = WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"curso-go-clean-arch/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// ErrOrderConcurrentModification is returned when another change to the order
+// was appended to its event stream since it was loaded
+var ErrOrderConcurrentModification = errors.New("order was modified concurrently")
+
+// OrderEventStore is the append-only store of the event streams of orders.
+// Implementations scope every operation to the tenant carried in ctx.
+type OrderEventStore interface {
+	// Append adds an event to the stream of its order, ErrOrderConcurrentModification
+	// means the stream already has an event at that sequence
+	Append(ctx context.Context, event *entity.OrderEvent) error
+	// Load returns the events of an order after the given sequence, oldest first
+	Load(ctx context.Context, orderID uuid.UUID, afterSequence int64) ([]*entity.OrderEvent, error)
+	// SaveSnapshot saves a snapshot, an existing snapshot at the same sequence is kept
+	SaveSnapshot(ctx context.Context, snapshot *entity.OrderSnapshot) error
+	// LatestSnapshot returns the most recent snapshot of an order, nil when there is none
+	LatestSnapshot(ctx context.Context, orderID uuid.UUID) (*entity.OrderSnapshot, error)
+}
+
+// OrderProjection is the orders read model maintained by projectors, written
+// with the complete state of each order
+type OrderProjection interface {
+	// Upsert saves the state of an order of the current tenant, inserting or replacing its row
+	Upsert(ctx context.Context, order *entity.Order) error
+	// Remove deletes an order of the current tenant, a missing order is not an error
+	Remove(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/domain/entity"
+)
+
+// OrderHistoryRepository stores the audit history of orders. Entries are
+// immutable: they are only appended, never updated or removed, and outlive
+// purged orders. Append joins the transaction of ctx so an entry is committed
+// together with the change it records.
+type OrderHistoryRepository interface {
+	Append(ctx context.Context, entry *entity.OrderHistoryEntry) error
+	// ListByOrderID returns the entries of an order of the current tenant, oldest first
+	ListByOrderID(ctx context.Context, orderID string) ([]*entity.OrderHistoryEntry, error)
+	// ListByOrderIDs returns the entries of several orders of the current tenant
+	// in one query, oldest first, for callers showing the history of a list
+	ListByOrderIDs(ctx context.Context, orderIDs []string) ([]*entity.OrderHistoryEntry, error)
+}
@@ -2,15 +2,60 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"curso-go-clean-arch/internal/domain/entity"
 )
 
-// OrderRepository defines the interface for order data access
+// ErrOrderNotFound is returned when an order does not exist for the current tenant
+var ErrOrderNotFound = errors.New("order not found")
+
+// ErrInvalidOrderID is returned when an order ID is not a valid UUID
+var ErrInvalidOrderID = errors.New("invalid order ID")
+
+// ErrDuplicateIdempotencyKey is returned when an order with the same idempotency key already exists
+var ErrDuplicateIdempotencyKey = errors.New("duplicate idempotency key")
+
+// ListOptions filters the orders returned by List
+type ListOptions struct {
+	// IncludeDeleted also returns soft deleted orders
+	IncludeDeleted bool
+}
+
+// DeletedOrder identifies a soft deleted order of any tenant
+type DeletedOrder struct {
+	TenantID string
+	ID       string
+}
+
+// OrderRepository defines the interface for order data access.
+// Implementations scope every operation to the tenant carried in ctx, except ListDeletedBefore.
+//
+// Delete is a soft delete: the order keeps its row with deleted_at set and is
+// hidden from List, GetByID and Update until restored. GetByIdempotencyKey still
+// finds soft deleted orders so a retried request replays the original order.
 type OrderRepository interface {
 	Create(ctx context.Context, order *entity.Order) error
-	List(ctx context.Context) ([]*entity.Order, error)
+	List(ctx context.Context, options ListOptions) ([]*entity.Order, error)
 	GetByID(ctx context.Context, id string) (*entity.Order, error)
+	// GetByIDIncludingDeleted is GetByID that also finds soft deleted orders
+	GetByIDIncludingDeleted(ctx context.Context, id string) (*entity.Order, error)
+	GetByIdempotencyKey(ctx context.Context, key string) (*entity.Order, error)
 	Update(ctx context.Context, order *entity.Order) error
 	Delete(ctx context.Context, id string) error
+	// Restore clears deleted_at, ErrOrderNotFound means there is no deleted order with that ID
+	Restore(ctx context.Context, id string) error
+	// Purge permanently removes a soft deleted order
+	Purge(ctx context.Context, id string) error
+	// ListDeletedBefore returns the orders of every tenant soft deleted before the
+	// given time, oldest deletion first, to be purged one by one with Purge.
+	// ErrRowLevelSecurity means policies hide the other tenants from the connection.
+	ListDeletedBefore(ctx context.Context, before time.Time) ([]DeletedOrder, error)
+}
+
+// OrderBulkCreator is implemented by repositories that can insert many orders at
+// once more efficiently than calling Create for each of them
+type OrderBulkCreator interface {
+	CreateMany(ctx context.Context, orders []*entity.Order) error
 }
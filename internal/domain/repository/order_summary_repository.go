@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"curso-go-clean-arch/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// ErrRowLevelSecurity is returned by Rebuild and OrderRepository.ListDeletedBefore
+// when row level security policies apply to the connection, which would limit
+// them to the tenant they let through
+var ErrRowLevelSecurity = errors.New("row level security applies to the connection, reading every tenant needs a role bypassing it")
+
+// SearchOptions limits the orders returned by Search
+type SearchOptions struct {
+	// IncludeDeleted also returns soft deleted orders
+	IncludeDeleted bool
+	// Limit is the maximum number of results
+	Limit int
+}
+
+// OrderQueryService is the query side of orders. It reads the order summaries
+// instead of the orders written by the commands, scoped to the tenant carried in ctx.
+type OrderQueryService interface {
+	// List returns the summaries of the orders of the current tenant, newest first
+	List(ctx context.Context, options ListOptions) ([]*entity.OrderSummary, error)
+	// Search returns the orders of the current tenant whose description or item
+	// names match query, most relevant first
+	Search(ctx context.Context, query entity.SearchQuery, options SearchOptions) ([]*entity.OrderSearchResult, error)
+	// Stats counts the orders of the current tenant per period and status, the
+	// periods without orders are omitted and rows come ordered by period
+	Stats(ctx context.Context, query entity.OrderStatsQuery) ([]*entity.OrderStatsRow, error)
+}
+
+// OrderSummaryRepository stores the order summaries projected from the order
+// changes. Implementations scope every operation to the tenant carried in ctx, except Rebuild.
+type OrderSummaryRepository interface {
+	OrderQueryService
+	// Find returns the summary of an order, soft deleted or not, nil when there is none
+	Find(ctx context.Context, id uuid.UUID) (*entity.OrderSummary, error)
+	// Save inserts or replaces the summary of an order
+	Save(ctx context.Context, summary *entity.OrderSummary) error
+	// Delete removes the summary of an order, a missing summary is not an error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Rebuild replaces the summaries of every tenant with ones computed from the
+	// orders and their history, returning how many were projected. It returns
+	// ErrRowLevelSecurity instead of rebuilding the tenants policies let through.
+	Rebuild(ctx context.Context) (int64, error)
+}
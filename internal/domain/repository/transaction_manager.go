@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+)
+
+// TransactionManager runs a unit of work atomically. Repositories called with the
+// ctx passed to fn take part in the transaction instead of using their own connection.
+//
+// Calls to WithinTransaction made inside fn are nested in the outer transaction:
+// when the inner fn fails only its changes are rolled back, through a savepoint.
+// The transaction is rolled back when fn returns an error or panics, and committed otherwise.
+type TransactionManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// AfterCommit runs fn once the transaction of ctx commits, and never when it
+	// rolls back, with a ctx that no longer carries the transaction. Without a
+	// transaction in ctx, fn runs immediately.
+	AfterCommit(ctx context.Context, fn func(ctx context.Context))
+}
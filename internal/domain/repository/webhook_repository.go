@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+)
+
+// ErrWebhookEndpointNotFound is returned when a webhook endpoint does not exist for the current tenant
+var ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+
+// ErrWebhookDeliveryNotFound is returned when a webhook delivery does not exist for the current tenant
+var ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+// WebhookRepository stores webhook endpoints and their deliveries. Implementations
+// scope every operation to the tenant carried in ctx, except ClaimDueDeliveries,
+// and join the transaction of ctx, so deliveries created while an order changes
+// are committed together with the change.
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *entity.WebhookEndpoint) error
+	ListEndpoints(ctx context.Context) ([]*entity.WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, id string) (*entity.WebhookEndpoint, error)
+	// DeleteEndpoint removes the endpoint and its deliveries
+	DeleteEndpoint(ctx context.Context, id string) error
+
+	CreateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id string) (*entity.WebhookDelivery, error)
+	// ListDeliveries returns the most recent deliveries of an endpoint, newest first,
+	// optionally only those with the given status
+	ListDeliveries(ctx context.Context, endpointID string, status entity.WebhookDeliveryStatus, limit int) ([]*entity.WebhookDelivery, error)
+	// UpdateDelivery saves the status, attempts and last result of a delivery
+	UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+	// ClaimDueDeliveries returns up to limit pending deliveries of every tenant due at
+	// now and postpones them until now plus lease, so concurrent workers do not send
+	// the same delivery twice. A worker that dies mid-delivery releases it when the lease expires.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error)
+}
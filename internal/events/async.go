@@ -0,0 +1,140 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	order "curso-go-clean-arch/proto"
+)
+
+// ErrQueueFull is returned by AsyncPublisher.Publish when QueueSize events are
+// already waiting, and ErrPublisherClosed once it is closed
+var (
+	ErrQueueFull       = errors.New("order event queue is full")
+	ErrPublisherClosed = errors.New("order event publisher is closed")
+)
+
+// AsyncPublisher queues events and publishes them in the background through
+// another Publisher, so a slow or unavailable broker does not delay the
+// requests whose changes the events report. A single worker publishes the
+// events in order, retrying each with exponential backoff up to MaxAttempts.
+// Events that cannot be queued or published are logged and reported to the
+// Observer as dropped or failed.
+type AsyncPublisher struct {
+	config   *Config
+	next     Publisher
+	observer Observer
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *order.OrderEvent
+	// stop aborts the publishing of the remaining events once Close gives up
+	stop context.Context
+	halt context.CancelFunc
+	done chan struct{}
+}
+
+// NewAsyncPublisher starts publishing the queued events through next, observer may be nil
+func NewAsyncPublisher(config *Config, next Publisher, observer Observer) *AsyncPublisher {
+	stop, halt := context.WithCancel(context.Background())
+	p := &AsyncPublisher{
+		config:   config,
+		next:     next,
+		observer: observer,
+		queue:    make(chan *order.OrderEvent, config.QueueSize),
+		stop:     stop,
+		halt:     halt,
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Publish queues an event without waiting for the broker
+func (p *AsyncPublisher) Publish(ctx context.Context, event *order.OrderEvent) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPublisherClosed
+	}
+	select {
+	case p.queue <- event:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting events, waits up to DrainTimeout for the queued ones
+// to be published and closes the underlying publisher
+func (p *AsyncPublisher) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+	case <-time.After(p.config.DrainTimeout):
+		p.halt()
+		<-p.done
+	}
+	p.halt()
+	return p.next.Close()
+}
+
+// run publishes the queued events until the queue is closed and drained
+func (p *AsyncPublisher) run() {
+	defer close(p.done)
+	for event := range p.queue {
+		if p.stop.Err() != nil {
+			p.report(event, ResultDropped)
+			slog.Error("Dropped order event on shutdown", "event_id", event.Id, "event_type", event.Type)
+			continue
+		}
+		p.publish(event)
+	}
+}
+
+// publish sends an event, retrying failed attempts after a backoff
+func (p *AsyncPublisher) publish(event *order.OrderEvent) {
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(p.stop, p.config.Timeout)
+		err := p.next.Publish(ctx, event)
+		cancel()
+		if err == nil {
+			p.report(event, ResultPublished)
+			return
+		}
+
+		if attempt >= p.config.MaxAttempts || p.stop.Err() != nil {
+			p.report(event, ResultFailed)
+			slog.Error("Failed to publish order event", "event_id", event.Id, "event_type", event.Type,
+				"attempts", attempt, "error", err)
+			return
+		}
+
+		p.report(event, ResultRetrying)
+		backoff := p.config.Backoff(attempt)
+		slog.Warn("Retrying order event", "event_id", event.Id, "event_type", event.Type,
+			"attempts", attempt, "backoff", backoff.String(), "error", err)
+
+		select {
+		case <-p.stop.Done():
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// report notifies the observer of a publish result
+func (p *AsyncPublisher) report(event *order.OrderEvent, result string) {
+	if p.observer != nil {
+		p.observer.ObserveEventPublish(event.Type, result)
+	}
+}
@@ -0,0 +1,265 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+
+	"github.com/google/uuid"
+
+	order "curso-go-clean-arch/proto"
+)
+
+// errBroker fails a publish attempt on purpose
+var errBroker = errors.New("broker unavailable")
+
+// fakeBroker is a Publisher whose attempts block until release is closed, when
+// set, and fail while failures remain
+type fakeBroker struct {
+	release chan struct{}
+
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	published []string
+	closed    bool
+}
+
+func (b *fakeBroker) Publish(ctx context.Context, event *order.OrderEvent) error {
+	if b.release != nil {
+		select {
+		case <-b.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts++
+	if b.failures > 0 {
+		b.failures--
+		return errBroker
+	}
+	b.published = append(b.published, event.Id)
+	return nil
+}
+
+func (b *fakeBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+// state returns the attempts made and the IDs of the published events
+func (b *fakeBroker) state() (int, []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.attempts, slices.Clone(b.published)
+}
+
+// results records the results reported to the Observer
+type results struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (r *results) ObserveEventPublish(eventType, result string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, result)
+}
+
+func (r *results) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.got)
+}
+
+// testConfig is a configuration with short timeouts and backoffs
+func testConfig() *Config {
+	config := DefaultConfig()
+	config.Timeout = time.Second
+	config.QueueSize = 10
+	config.MaxAttempts = 3
+	config.InitialBackoff = time.Millisecond
+	config.MaxBackoff = 4 * time.Millisecond
+	config.DrainTimeout = 5 * time.Second
+	return &config
+}
+
+// newEvent creates the event of an order change
+func newEvent() *order.OrderEvent {
+	o := entity.NewOrder("order")
+	entry := &entity.OrderHistoryEntry{ID: uuid.New(), OrderID: o.ID, Action: entity.OrderHistoryCreated, CreatedAt: time.Now()}
+	return NewOrderEvent(entry, o)
+}
+
+// TestAsyncPublisher checks that events are published in order, retried after
+// failures, and reported when given up
+func TestAsyncPublisher(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		wantPublished bool
+		wantResults   []string
+	}{
+		{name: "published", wantPublished: true, wantResults: []string{ResultPublished}},
+		{name: "retried", failures: 2, wantPublished: true, wantResults: []string{ResultRetrying, ResultRetrying, ResultPublished}},
+		{name: "given up", failures: 3, wantResults: []string{ResultRetrying, ResultRetrying, ResultFailed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := &fakeBroker{failures: tt.failures}
+			observed := &results{}
+			publisher := NewAsyncPublisher(testConfig(), broker, observed)
+
+			event := newEvent()
+			if err := publisher.Publish(context.Background(), event); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			if err := publisher.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			attempts, published := broker.state()
+			if want := min(tt.failures+1, 3); attempts != want {
+				t.Errorf("attempts = %d, want %d", attempts, want)
+			}
+			if got := slices.Contains(published, event.Id); got != tt.wantPublished {
+				t.Errorf("published = %t, want %t", got, tt.wantPublished)
+			}
+			if got := observed.list(); !slices.Equal(got, tt.wantResults) {
+				t.Errorf("results = %q, want %q", got, tt.wantResults)
+			}
+			if !broker.closed {
+				t.Error("underlying publisher not closed")
+			}
+		})
+	}
+}
+
+// TestAsyncPublisherSlowBroker checks that a slow broker neither blocks Publish
+// nor loses the queued events, which are published in order once it recovers
+func TestAsyncPublisherSlowBroker(t *testing.T) {
+	broker := &fakeBroker{release: make(chan struct{}), failures: 1}
+	publisher := NewAsyncPublisher(testConfig(), broker, nil)
+
+	var want []string
+	start := time.Now()
+	for range 5 {
+		event := newEvent()
+		if err := publisher.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		want = append(want, event.Id)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Publish took %s with a stuck broker, want it not to wait", elapsed)
+	}
+
+	close(broker.release)
+	if err := publisher.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, published := broker.state(); !slices.Equal(published, want) {
+		t.Errorf("published %q, want %q in order", published, want)
+	}
+}
+
+// TestAsyncPublisherFullQueue checks that events beyond the queue are refused
+// rather than blocking, and that Publish fails once closed
+func TestAsyncPublisherFullQueue(t *testing.T) {
+	broker := &fakeBroker{release: make(chan struct{})}
+	config := testConfig()
+	config.QueueSize = 2
+	config.DrainTimeout = 10 * time.Millisecond
+	observed := &results{}
+	publisher := NewAsyncPublisher(config, broker, observed)
+
+	// One event taken by the worker, stuck in the broker, and two queued
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = publisher.Publish(context.Background(), newEvent())
+	}
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Publish error = %v with a full queue, want %v", err, ErrQueueFull)
+	}
+
+	// The broker never recovers: shutdown gives up on the stuck and queued events
+	if err := publisher.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := publisher.Publish(context.Background(), newEvent()); !errors.Is(err, ErrPublisherClosed) {
+		t.Errorf("Publish error = %v once closed, want %v", err, ErrPublisherClosed)
+	}
+
+	got := observed.list()
+	if !slices.Contains(got, ResultDropped) || !slices.Contains(got, ResultFailed) {
+		t.Errorf("results = %q, want the stuck event failed and the queued ones dropped", got)
+	}
+}
+
+// passthroughTransactions runs AfterCommit callbacks immediately, as without a transaction
+type passthroughTransactions struct{}
+
+func (passthroughTransactions) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (passthroughTransactions) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	fn(ctx)
+}
+
+// TestOrderEventHookDoesNotWait checks that a change is not held up by a stuck
+// broker and that an event that cannot be queued is reported as dropped
+func TestOrderEventHookDoesNotWait(t *testing.T) {
+	broker := &fakeBroker{release: make(chan struct{})}
+	config := testConfig()
+	config.QueueSize = 1
+	observed := &results{}
+	publisher := NewAsyncPublisher(config, broker, observed)
+	hook := NewOrderEventHook(publisher, passthroughTransactions{}, observed)
+
+	o := entity.NewOrder("order")
+	start := time.Now()
+	for range 3 {
+		entry := &entity.OrderHistoryEntry{ID: uuid.New(), OrderID: o.ID, Action: entity.OrderHistoryUpdated, CreatedAt: time.Now()}
+		if err := hook.OrderChanged(context.Background(), entry, o); err != nil {
+			t.Fatalf("OrderChanged: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("OrderChanged took %s with a stuck broker, want it not to wait", elapsed)
+	}
+
+	close(broker.release)
+	if err := publisher.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The worker holds one event and the queue another, the third is dropped
+	_, published := broker.state()
+	got := observed.list()
+	if len(published)+countOf(got, ResultDropped) != 3 || countOf(got, ResultDropped) == 0 {
+		t.Errorf("published %d events with results %q, want every event published or reported dropped", len(published), got)
+	}
+}
+
+// countOf counts the occurrences of value in values
+func countOf(values []string, value string) int {
+	var n int
+	for _, v := range values {
+		if v == value {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"fmt"
+
+	"curso-go-clean-arch/internal/domain/entity"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	order "curso-go-clean-arch/proto"
+)
+
+// EnvelopeVersion is the version of the order.OrderEvent envelope published
+const EnvelopeVersion = 1
+
+// aggregateType is the aggregate type of order events
+const aggregateType = "order"
+
+// jsonOptions keeps JSON field names identical to the proto field names
+var jsonOptions = protojson.MarshalOptions{UseProtoNames: true}
+
+// NewOrderEvent creates the envelope of an order change from its history
+// entry, the event sharing the ID of the entry
+func NewOrderEvent(entry *entity.OrderHistoryEntry, o *entity.Order) *order.OrderEvent {
+	payload := &order.Order{
+		Id:          o.ID.String(),
+		Description: o.Description,
+		Currency:    o.Currency,
+		TotalAmount: o.TotalAmount,
+		Status:      string(o.Status),
+		CreatedAt:   timestamppb.New(o.CreatedAt),
+		UpdatedAt:   timestamppb.New(o.UpdatedAt),
+	}
+	if o.DeletedAt != nil {
+		payload.DeletedAt = timestamppb.New(*o.DeletedAt)
+	}
+	for _, item := range o.Items {
+		payload.Items = append(payload.Items, &order.OrderItem{
+			Name:      item.Name,
+			Quantity:  int32(item.Quantity),
+			UnitPrice: item.UnitPrice,
+		})
+	}
+
+	event := &order.OrderEvent{
+		Version:       EnvelopeVersion,
+		Id:            entry.ID.String(),
+		Type:          entity.OrderEventType(entry.Action),
+		OccurredAt:    timestamppb.New(entry.CreatedAt),
+		AggregateType: aggregateType,
+		AggregateId:   o.ID.String(),
+		TenantId:      entry.TenantID,
+		Payload:       payload,
+		Actor:         entry.Actor,
+		ClaimedActor:  entry.ClaimedActor,
+		RequestId:     entry.RequestID,
+	}
+	for _, change := range entry.Changes {
+		event.Changes = append(event.Changes, &order.FieldChange{
+			Field:  change.Field,
+			Before: change.Before,
+			After:  change.After,
+		})
+	}
+	return event
+}
+
+// Encode encodes an event in format, returning its content type
+func Encode(event *order.OrderEvent, format string) ([]byte, string, error) {
+	var (
+		data        []byte
+		contentType string
+		err         error
+	)
+	if format == FormatProtobuf {
+		data, err = proto.Marshal(event)
+		contentType = "application/x-protobuf"
+	} else {
+		data, err = jsonOptions.Marshal(event)
+		contentType = "application/json"
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error encoding order event: %w", err)
+	}
+	return data, contentType, nil
+}
@@ -0,0 +1,160 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	order "curso-go-clean-arch/proto"
+)
+
+// Supported brokers
+const (
+	// BrokerNone disables publishing
+	BrokerNone = "none"
+	// BrokerStdout writes events to the standard output
+	BrokerStdout = "stdout"
+	// BrokerFile appends events to FilePath
+	BrokerFile = "file"
+	// BrokerNATS publishes events to NATS, one subject per event type
+	BrokerNATS = "nats"
+	// BrokerKafka publishes events to a Kafka-compatible broker, keyed by order ID
+	BrokerKafka = "kafka"
+)
+
+// Supported event encodings
+const (
+	// FormatJSON encodes the envelope with the protobuf JSON mapping and snake_case field names
+	FormatJSON = "json"
+	// FormatProtobuf encodes the envelope as a binary order.OrderEvent message
+	FormatProtobuf = "protobuf"
+)
+
+// Headers sent with every message by brokers supporting them
+const (
+	HeaderContentType = "Content-Type"
+	HeaderEventID     = "Event-ID"
+	HeaderEventType   = "Event-Type"
+)
+
+// Config holds order event publishing configuration
+type Config struct {
+	Broker string `yaml:"broker" toml:"broker" env:"EVENTS_BROKER"`
+	Format string `yaml:"format" toml:"format" env:"EVENTS_FORMAT"`
+	// Topic is the Kafka topic, and the NATS subject prefix followed by the event type
+	Topic        string   `yaml:"topic" toml:"topic" env:"EVENTS_TOPIC"`
+	FilePath     string   `yaml:"file_path" toml:"file_path" env:"EVENTS_FILE_PATH"`
+	NATSURL      string   `yaml:"nats_url" toml:"nats_url" env:"EVENTS_NATS_URL"`
+	KafkaBrokers []string `yaml:"kafka_brokers" toml:"kafka_brokers" env:"EVENTS_KAFKA_BROKERS"`
+	// Timeout bounds each publish attempt
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"EVENTS_TIMEOUT"`
+	// QueueSize is the number of events waiting to be published beyond which new ones are dropped
+	QueueSize int `yaml:"queue_size" toml:"queue_size" env:"EVENTS_QUEUE_SIZE"`
+	// MaxAttempts is the number of failed attempts after which an event is given up
+	MaxAttempts    int           `yaml:"max_attempts" toml:"max_attempts" env:"EVENTS_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff" env:"EVENTS_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"EVENTS_MAX_BACKOFF"`
+	// DrainTimeout is how long shutdown waits for the queued events to be published
+	DrainTimeout time.Duration `yaml:"drain_timeout" toml:"drain_timeout" env:"EVENTS_DRAIN_TIMEOUT"`
+}
+
+// DefaultConfig returns the default publishing configuration
+func DefaultConfig() Config {
+	return Config{
+		Broker:         BrokerNone,
+		Format:         FormatJSON,
+		Topic:          "orders.events",
+		FilePath:       "events.log",
+		NATSURL:        "nats://localhost:4222",
+		KafkaBrokers:   []string{"localhost:9092"},
+		Timeout:        5 * time.Second,
+		QueueSize:      10000,
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		DrainTimeout:   10 * time.Second,
+	}
+}
+
+// Validate checks the publishing configuration
+func (c *Config) Validate() error {
+	var errs []error
+	if !slices.Contains([]string{BrokerNone, BrokerStdout, BrokerFile, BrokerNATS, BrokerKafka}, c.Broker) {
+		errs = append(errs, fmt.Errorf("broker: must be none, stdout, file, nats or kafka, got %q", c.Broker))
+	}
+	if c.Format != FormatJSON && c.Format != FormatProtobuf {
+		errs = append(errs, fmt.Errorf("format: must be json or protobuf, got %q", c.Format))
+	}
+	if c.Topic == "" {
+		errs = append(errs, errors.New("topic: must not be empty"))
+	}
+	if c.Broker == BrokerFile && c.FilePath == "" {
+		errs = append(errs, errors.New("file_path: required by the file broker"))
+	}
+	if c.Broker == BrokerNATS && c.NATSURL == "" {
+		errs = append(errs, errors.New("nats_url: required by the nats broker"))
+	}
+	if c.Broker == BrokerKafka && len(c.KafkaBrokers) == 0 {
+		errs = append(errs, errors.New("kafka_brokers: required by the kafka broker"))
+	}
+	for name, value := range map[string]time.Duration{
+		"timeout":         c.Timeout,
+		"initial_backoff": c.InitialBackoff,
+		"max_backoff":     c.MaxBackoff,
+		"drain_timeout":   c.DrainTimeout,
+	} {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive, got %s", name, value))
+		}
+	}
+	for name, value := range map[string]int{
+		"queue_size":   c.QueueSize,
+		"max_attempts": c.MaxAttempts,
+	} {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive, got %d", name, value))
+		}
+	}
+	if c.MaxBackoff > 0 && c.InitialBackoff > c.MaxBackoff {
+		errs = append(errs, fmt.Errorf("initial_backoff: must not exceed max_backoff %s, got %s", c.MaxBackoff, c.InitialBackoff))
+	}
+	return errors.Join(errs...)
+}
+
+// Backoff returns the delay before retrying an event after the given number of
+// failed attempts, doubling from InitialBackoff up to MaxBackoff
+func (c *Config) Backoff(attempts int) time.Duration {
+	backoff := c.InitialBackoff
+	for i := 1; i < attempts && backoff < c.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, c.MaxBackoff)
+}
+
+// Publisher is the port through which order events leave the service. Adapters
+// encode the envelope in the configured format.
+type Publisher interface {
+	// Publish sends a single event
+	Publish(ctx context.Context, event *order.OrderEvent) error
+	// Close flushes pending events and releases the connection
+	Close() error
+}
+
+// NewPublisher creates the Publisher of the configured broker, nil for
+// BrokerNone. Wrap it in an AsyncPublisher to publish in the background.
+func NewPublisher(config *Config) (Publisher, error) {
+	switch config.Broker {
+	case BrokerStdout:
+		return NewStdoutPublisher(config), nil
+	case BrokerFile:
+		return NewFilePublisher(config)
+	case BrokerNATS:
+		return NewNATSPublisher(config)
+	case BrokerKafka:
+		return NewKafkaPublisher(config), nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// Publish results reported to the Observer
+const (
+	ResultPublished = "published"
+	// ResultRetrying is a failed attempt that will be retried
+	ResultRetrying = "retrying"
+	// ResultFailed is an event given up after its last attempt
+	ResultFailed = "failed"
+	// ResultDropped is an event that could not be queued, or was still queued on shutdown
+	ResultDropped = "dropped"
+)
+
+// Observer is notified of every published event, metrics.Metrics implements it
+type Observer interface {
+	ObserveEventPublish(eventType, result string)
+}
+
+// OrderEventHook publishes an event for every recorded order change once its
+// transaction commits, so rolled back changes are never published. With an
+// AsyncPublisher the event is only queued, the request does not wait for the
+// broker, and failed attempts are retried in the background. Events given up
+// or dropped are logged and counted; consumers needing every change can
+// replay the order history or use webhooks.
+type OrderEventHook struct {
+	publisher          Publisher
+	transactionManager repository.TransactionManager
+	observer           Observer
+}
+
+// NewOrderEventHook creates a new instance of OrderEventHook, observer may be nil
+func NewOrderEventHook(publisher Publisher, transactionManager repository.TransactionManager, observer Observer) *OrderEventHook {
+	return &OrderEventHook{
+		publisher:          publisher,
+		transactionManager: transactionManager,
+		observer:           observer,
+	}
+}
+
+// OrderChanged schedules the event of the change to be published after commit
+func (h *OrderEventHook) OrderChanged(ctx context.Context, entry *entity.OrderHistoryEntry, order *entity.Order) error {
+	event := NewOrderEvent(entry, order)
+
+	h.transactionManager.AfterCommit(ctx, func(ctx context.Context) {
+		// The change is committed, publish it even if the request is cancelled
+		if err := h.publisher.Publish(context.WithoutCancel(ctx), event); err != nil {
+			slog.ErrorContext(ctx, "Failed to queue order event", "event_id", event.Id, "event_type", event.Type, "error", err)
+			if h.observer != nil {
+				h.observer.ObserveEventPublish(event.Type, ResultDropped)
+			}
+		}
+	})
+	return nil
+}
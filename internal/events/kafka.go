@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	order "curso-go-clean-arch/proto"
+)
+
+// KafkaPublisher publishes events to Topic on a Kafka-compatible broker
+// (Kafka, Redpanda, ...). Messages are keyed by order ID, so the events of an
+// order land on the same partition and are consumed in order.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+	format string
+}
+
+// NewKafkaPublisher creates a publisher for config.KafkaBrokers, connections are opened on first use
+func NewKafkaPublisher(config *Config) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(config.KafkaBrokers...),
+			Topic:        config.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// Events are published one at a time, do not wait for a batch to fill
+			BatchTimeout: 10 * time.Millisecond,
+			WriteTimeout: config.Timeout,
+		},
+		format: config.Format,
+	}
+}
+
+// Publish sends a single event, waiting for it to be acknowledged by every in-sync replica
+func (p *KafkaPublisher) Publish(ctx context.Context, event *order.OrderEvent) error {
+	data, contentType, err := Encode(event, p.format)
+	if err != nil {
+		return err
+	}
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.AggregateId),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(contentType)},
+			{Key: HeaderEventID, Value: []byte(event.Id)},
+			{Key: HeaderEventType, Value: []byte(event.Type)},
+		},
+		Time: event.OccurredAt.AsTime(),
+	})
+	if err != nil {
+		return fmt.Errorf("error publishing order event to Kafka: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending events and closes the connections
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
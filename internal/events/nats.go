@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+
+	order "curso-go-clean-arch/proto"
+)
+
+// NATSPublisher publishes events to NATS on the subject Topic.<event type>,
+// e.g. orders.events.order.created, so consumers can subscribe to
+// orders.events.> or to a single type. Nats-Msg-Id is set to the event ID for
+// JetStream deduplication.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	topic  string
+	format string
+}
+
+// NewNATSPublisher connects to config.NATSURL. The connection is retried in
+// the background, so the service starts while NATS is unavailable.
+func NewNATSPublisher(config *Config) (*NATSPublisher, error) {
+	conn, err := nats.Connect(config.NATSURL,
+		nats.Name("curso-go-clean-arch"),
+		nats.Timeout(config.Timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to NATS: %w", err)
+	}
+	return &NATSPublisher{conn: conn, topic: config.Topic, format: config.Format}, nil
+}
+
+// Publish sends a single event, buffered by the client while reconnecting
+func (p *NATSPublisher) Publish(ctx context.Context, event *order.OrderEvent) error {
+	data, contentType, err := Encode(event, p.format)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.topic + "." + event.Type)
+	msg.Data = data
+	msg.Header.Set(HeaderContentType, contentType)
+	msg.Header.Set(HeaderEventID, event.Id)
+	msg.Header.Set(HeaderEventType, event.Type)
+	msg.Header.Set(nats.MsgIdHdr, event.Id)
+
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("error publishing order event to NATS: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending events and closes the connection
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
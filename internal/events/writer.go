@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	order "curso-go-clean-arch/proto"
+)
+
+// WriterPublisher writes events to an io.Writer, one JSON document per line or,
+// in the protobuf format, as size-delimited messages readable with protodelim
+type WriterPublisher struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	format string
+}
+
+// NewStdoutPublisher creates a publisher writing events to the standard output
+func NewStdoutPublisher(config *Config) *WriterPublisher {
+	return &WriterPublisher{w: os.Stdout, format: config.Format}
+}
+
+// NewFilePublisher creates a publisher appending events to config.FilePath
+func NewFilePublisher(config *Config) (*WriterPublisher, error) {
+	f, err := os.OpenFile(config.FilePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening events file: %w", err)
+	}
+	return &WriterPublisher{w: f, closer: f, format: config.Format}, nil
+}
+
+// Publish writes a single event
+func (p *WriterPublisher) Publish(ctx context.Context, event *order.OrderEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.format == FormatProtobuf {
+		if _, err := protodelim.MarshalTo(p.w, event); err != nil {
+			return fmt.Errorf("error writing order event: %w", err)
+		}
+		return nil
+	}
+
+	data, _, err := Encode(event, p.format)
+	if err != nil {
+		return err
+	}
+	if _, err := p.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing order event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file, the standard output is left open
+func (p *WriterPublisher) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestValidator checks a request message of the given full method before
+// it reaches the service. An error without a gRPC status is returned as
+// InvalidArgument.
+type RequestValidator func(ctx context.Context, fullMethod string, req any) error
+
+// validatable is implemented by messages that validate themselves, such as
+// those generated by protoc-gen-validate
+type validatable interface {
+	Validate() error
+}
+
+// RecoveryUnaryServerInterceptor turns a panic of a unary handler into an
+// Internal status, so it does not crash the process and the other servers
+func RecoveryUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recoveredError(ctx, info.FullMethod, p)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamServerInterceptor turns a panic of a stream handler into an Internal status
+func RecoveryStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recoveredError(ss.Context(), info.FullMethod, p)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recoveredError logs a recovered panic with its stack and returns an Internal
+// status that does not expose it
+func recoveredError(ctx context.Context, method string, p any) error {
+	slog.ErrorContext(ctx, "gRPC handler panicked", "method", method, "panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+	return status.Error(codes.Internal, "internal error")
+}
+
+// DeadlineUnaryServerInterceptor gives unary calls without a deadline the
+// default one and shortens deadlines beyond max. A zero duration disables
+// either rule. Calls failing once their deadline expired get DeadlineExceeded.
+func DeadlineUnaryServerInterceptor(defaultDeadline, maxDeadline time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, cancel := withDeadline(ctx, defaultDeadline, maxDeadline)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		return resp, deadlineError(ctx, err)
+	}
+}
+
+// DeadlineStreamServerInterceptor applies the deadline rules of
+// DeadlineUnaryServerInterceptor to streams
+func DeadlineStreamServerInterceptor(defaultDeadline, maxDeadline time.Duration) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := withDeadline(ss.Context(), defaultDeadline, maxDeadline)
+		defer cancel()
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		return deadlineError(ctx, err)
+	}
+}
+
+// withDeadline bounds ctx by the default and max deadlines
+func withDeadline(ctx context.Context, defaultDeadline, maxDeadline time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	switch {
+	case !ok && defaultDeadline > 0:
+		return context.WithTimeout(ctx, defaultDeadline)
+	case maxDeadline > 0 && (!ok || time.Until(deadline) > maxDeadline):
+		return context.WithTimeout(ctx, maxDeadline)
+	default:
+		return ctx, func() {}
+	}
+}
+
+// deadlineError reports err as DeadlineExceeded when ctx expired, as the
+// handlers map the context error of a cancelled query to Internal
+func deadlineError(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if code := status.Code(err); code != codes.Unknown && code != codes.Internal {
+		return err
+	}
+	return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+}
+
+// ValidationUnaryServerInterceptor runs the validators, after Validate of
+// messages implementing it, on every unary request
+func ValidationUnaryServerInterceptor(validators ...RequestValidator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := validateRequest(ctx, info.FullMethod, req, validators); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ValidationStreamServerInterceptor runs the validators on every message received on a stream
+func ValidationStreamServerInterceptor(validators ...RequestValidator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss, method: info.FullMethod, validators: validators})
+	}
+}
+
+// validateRequest runs the validation hooks on req, converting their errors to a status
+func validateRequest(ctx context.Context, method string, req any, validators []RequestValidator) error {
+	if v, ok := req.(validatable); ok {
+		if err := v.Validate(); err != nil {
+			return validationStatus(err)
+		}
+	}
+	for _, validate := range validators {
+		if err := validate(ctx, method, req); err != nil {
+			return validationStatus(err)
+		}
+	}
+	return nil
+}
+
+// validationStatus keeps the status of err, InvalidArgument when it has none
+func validationStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the overridden context
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// validatingStream validates the messages received on a server stream
+type validatingStream struct {
+	grpc.ServerStream
+	method     string
+	validators []RequestValidator
+}
+
+// RecvMsg receives a message and validates it
+func (s *validatingStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateRequest(s.Context(), s.method, m, s.validators)
+}
@@ -0,0 +1,268 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testMethod = "/order.OrderService/CreateOrder"
+
+var (
+	unaryInfo  = &grpc.UnaryServerInfo{FullMethod: testMethod}
+	streamInfo = &grpc.StreamServerInfo{FullMethod: testMethod}
+)
+
+// fakeStream is a server stream with a context, receiving the messages given
+type fakeStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	messages []string
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) RecvMsg(m any) error {
+	if len(s.messages) == 0 {
+		return errors.New("no message")
+	}
+	*m.(*testMessage) = testMessage{value: s.messages[0]}
+	s.messages = s.messages[1:]
+	return nil
+}
+
+// testMessage validates itself, rejecting the value "invalid"
+type testMessage struct {
+	value string
+}
+
+func (m *testMessage) Validate() error {
+	if m.value == "invalid" {
+		return errors.New("value: invalid")
+	}
+	return nil
+}
+
+// TestRecoveryInterceptors checks that a panicking handler returns an Internal
+// status that does not expose the panic, and that other results pass through
+func TestRecoveryInterceptors(t *testing.T) {
+	handlerErr := status.Error(codes.NotFound, "order not found")
+
+	tests := []struct {
+		name     string
+		handle   func() error
+		wantCode codes.Code
+	}{
+		{name: "success", handle: func() error { return nil }, wantCode: codes.OK},
+		{name: "error", handle: func() error { return handlerErr }, wantCode: codes.NotFound},
+		{name: "panic", handle: func() error { panic("secret state") }, wantCode: codes.Internal},
+		{name: "panic with an error", handle: func() error { panic(fmt.Errorf("secret state")) }, wantCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unary := RecoveryUnaryServerInterceptor()
+			_, unaryErr := unary(context.Background(), nil, unaryInfo, func(context.Context, any) (any, error) {
+				return nil, tt.handle()
+			})
+
+			stream := RecoveryStreamServerInterceptor()
+			streamErr := stream(nil, &fakeStream{ctx: context.Background()}, streamInfo, func(any, grpc.ServerStream) error {
+				return tt.handle()
+			})
+
+			for kind, err := range map[string]error{"unary": unaryErr, "stream": streamErr} {
+				if code := status.Code(err); code != tt.wantCode {
+					t.Errorf("%s: code = %s, want %s", kind, code, tt.wantCode)
+				}
+				if status.Convert(err).Message() == "secret state" {
+					t.Errorf("%s: status %v exposes the panic", kind, err)
+				}
+			}
+		})
+	}
+}
+
+// TestDeadlineInterceptors checks the deadline a handler gets and the
+// conversion of errors caused by its expiry
+func TestDeadlineInterceptors(t *testing.T) {
+	const (
+		defaultDeadline = time.Minute
+		maxDeadline     = 2 * time.Minute
+	)
+
+	tests := []struct {
+		name            string
+		clientDeadline  time.Duration
+		defaultDeadline time.Duration
+		maxDeadline     time.Duration
+		wantDeadline    time.Duration
+	}{
+		{name: "default without a client deadline", defaultDeadline: defaultDeadline, maxDeadline: maxDeadline, wantDeadline: defaultDeadline},
+		{name: "client deadline within max", clientDeadline: 30 * time.Second, defaultDeadline: defaultDeadline, maxDeadline: maxDeadline, wantDeadline: 30 * time.Second},
+		{name: "client deadline beyond max", clientDeadline: time.Hour, defaultDeadline: defaultDeadline, maxDeadline: maxDeadline, wantDeadline: maxDeadline},
+		{name: "max without a default", maxDeadline: maxDeadline, wantDeadline: maxDeadline},
+		{name: "client deadline without a max", clientDeadline: time.Hour, defaultDeadline: defaultDeadline, wantDeadline: time.Hour},
+		{name: "disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.clientDeadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.clientDeadline)
+				defer cancel()
+			}
+
+			assertDeadline := func(kind string, ctx context.Context) {
+				deadline, ok := ctx.Deadline()
+				if tt.wantDeadline == 0 {
+					if ok {
+						t.Errorf("%s: deadline in %s, want none", kind, time.Until(deadline))
+					}
+					return
+				}
+				if !ok {
+					t.Fatalf("%s: no deadline, want %s", kind, tt.wantDeadline)
+				}
+				if remaining := time.Until(deadline); remaining > tt.wantDeadline || remaining < tt.wantDeadline-5*time.Second {
+					t.Errorf("%s: deadline in %s, want %s", kind, remaining, tt.wantDeadline)
+				}
+			}
+
+			unary := DeadlineUnaryServerInterceptor(tt.defaultDeadline, tt.maxDeadline)
+			if _, err := unary(ctx, nil, unaryInfo, func(ctx context.Context, _ any) (any, error) {
+				assertDeadline("unary", ctx)
+				return nil, nil
+			}); err != nil {
+				t.Fatalf("unary: %v", err)
+			}
+
+			stream := DeadlineStreamServerInterceptor(tt.defaultDeadline, tt.maxDeadline)
+			if err := stream(nil, &fakeStream{ctx: ctx}, streamInfo, func(_ any, ss grpc.ServerStream) error {
+				assertDeadline("stream", ss.Context())
+				return nil
+			}); err != nil {
+				t.Fatalf("stream: %v", err)
+			}
+		})
+	}
+}
+
+// TestDeadlineInterceptorsExpiry checks that errors of handlers that ran out
+// of time become DeadlineExceeded, unless they carry a meaningful status
+func TestDeadlineInterceptorsExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+	}{
+		{name: "context error", err: context.DeadlineExceeded, wantCode: codes.DeadlineExceeded},
+		{name: "internal status", err: status.Error(codes.Internal, "error listing orders: context deadline exceeded"), wantCode: codes.DeadlineExceeded},
+		{name: "meaningful status", err: status.Error(codes.NotFound, "order not found"), wantCode: codes.NotFound},
+		{name: "success", wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The handler waits for the deadline, as a slow query would
+			unary := DeadlineUnaryServerInterceptor(10*time.Millisecond, 0)
+			_, unaryErr := unary(context.Background(), nil, unaryInfo, func(ctx context.Context, _ any) (any, error) {
+				<-ctx.Done()
+				return nil, tt.err
+			})
+
+			stream := DeadlineStreamServerInterceptor(10*time.Millisecond, 0)
+			streamErr := stream(nil, &fakeStream{ctx: context.Background()}, streamInfo, func(_ any, ss grpc.ServerStream) error {
+				<-ss.Context().Done()
+				return tt.err
+			})
+
+			for kind, err := range map[string]error{"unary": unaryErr, "stream": streamErr} {
+				if code := status.Code(err); code != tt.wantCode {
+					t.Errorf("%s: code = %s, want %s", kind, code, tt.wantCode)
+				}
+			}
+		})
+	}
+
+	// Errors before the deadline are left alone
+	unary := DeadlineUnaryServerInterceptor(time.Minute, 0)
+	_, err := unary(context.Background(), nil, unaryInfo, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.Internal, "internal error")
+	})
+	if code := status.Code(err); code != codes.Internal {
+		t.Errorf("code = %s before the deadline, want %s", code, codes.Internal)
+	}
+}
+
+// TestValidationInterceptors checks that invalid requests are rejected before
+// the handler with the status of the validator, InvalidArgument by default
+func TestValidationInterceptors(t *testing.T) {
+	rejectEmpty := func(_ context.Context, method string, req any) error {
+		if method != testMethod {
+			t.Errorf("validator got method %q, want %q", method, testMethod)
+		}
+		if req.(*testMessage).value == "" {
+			return errors.New("value: required")
+		}
+		return nil
+	}
+	denyForbidden := func(_ context.Context, _ string, req any) error {
+		if req.(*testMessage).value == "forbidden" {
+			return status.Error(codes.PermissionDenied, "forbidden")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		wantCode codes.Code
+	}{
+		{name: "valid", value: "ok", wantCode: codes.OK},
+		{name: "Validate method", value: "invalid", wantCode: codes.InvalidArgument},
+		{name: "validator error", value: "", wantCode: codes.InvalidArgument},
+		{name: "validator status", value: "forbidden", wantCode: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled int
+
+			unary := ValidationUnaryServerInterceptor(rejectEmpty, denyForbidden)
+			_, unaryErr := unary(context.Background(), &testMessage{value: tt.value}, unaryInfo, func(context.Context, any) (any, error) {
+				handled++
+				return nil, nil
+			})
+
+			stream := ValidationStreamServerInterceptor(rejectEmpty, denyForbidden)
+			streamErr := stream(nil, &fakeStream{ctx: context.Background(), messages: []string{tt.value}}, streamInfo, func(_ any, ss grpc.ServerStream) error {
+				var m testMessage
+				if err := ss.RecvMsg(&m); err != nil {
+					return err
+				}
+				handled++
+				return nil
+			})
+
+			for kind, err := range map[string]error{"unary": unaryErr, "stream": streamErr} {
+				if code := status.Code(err); code != tt.wantCode {
+					t.Errorf("%s: code = %s, want %s", kind, code, tt.wantCode)
+				}
+			}
+			if wantHandled := map[bool]int{true: 2, false: 0}[tt.wantCode == codes.OK]; handled != wantHandled {
+				t.Errorf("handled %d requests, want %d", handled, wantHandled)
+			}
+		})
+	}
+}
@@ -2,15 +2,23 @@ package grpc
 
 import (
 	"context"
+	"curso-go-clean-arch/internal/auth"
 	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/locale"
+	"curso-go-clean-arch/internal/logger"
+	"curso-go-clean-arch/internal/tracing"
 	"curso-go-clean-arch/internal/usecase"
-	"log"
+	"errors"
+	"log/slog"
 	"net"
-	"os"
 	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -32,20 +40,28 @@ func NewOrderServer(container *container.Container) *OrderServer {
 
 // CreateOrder implements the CreateOrder RPC method
 func (s *OrderServer) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (*order.CreateOrderResponse, error) {
-	if req.Description == "" {
-		return nil, status.Error(codes.InvalidArgument, "description is required")
-	}
-
 	// Convert to use case input
 	input := usecase.CreateOrderInput{
 		Description: req.Description,
+		Currency:    req.Currency,
+	}
+	for _, item := range req.Items {
+		input.Items = append(input.Items, usecase.OrderItem{
+			Name:      item.Name,
+			Quantity:  int(item.Quantity),
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get("idempotency-key"); len(keys) > 0 {
+			input.IdempotencyKey = keys[0]
+		}
 	}
 
 	// Execute use case
 	output, err := s.container.CreateOrderUseCase.Execute(ctx, input)
 	if err != nil {
-		log.Printf("Failed to create order: %v", err)
-		return nil, status.Error(codes.Internal, "failed to create order")
+		return nil, orderStatusError(ctx, "create", err)
 	}
 
 	// Convert to protobuf response
@@ -55,6 +71,10 @@ func (s *OrderServer) CreateOrder(ctx context.Context, req *order.CreateOrderReq
 	protoOrder := &order.Order{
 		Id:          output.ID,
 		Description: output.Description,
+		Items:       protoOrderItems(output.Items),
+		Currency:    output.Currency,
+		TotalAmount: output.TotalAmount,
+		Status:      output.Status,
 		CreatedAt:   timestamppb.New(createdAt),
 		UpdatedAt:   timestamppb.New(updatedAt),
 	}
@@ -67,9 +87,11 @@ func (s *OrderServer) CreateOrder(ctx context.Context, req *order.CreateOrderReq
 // ListOrders implements the ListOrders RPC method
 func (s *OrderServer) ListOrders(ctx context.Context, req *order.ListOrdersRequest) (*order.ListOrdersResponse, error) {
 	// Execute use case
-	output, err := s.container.ListOrdersUseCase.Execute(ctx)
+	output, err := s.container.ListOrdersUseCase.Execute(ctx, usecase.ListOrdersInput{
+		IncludeDeleted: req.IncludeDeleted,
+	})
 	if err != nil {
-		log.Printf("Failed to list orders: %v", err)
+		slog.ErrorContext(ctx, "Failed to list orders", "error", err)
 		return nil, status.Error(codes.Internal, "failed to list orders")
 	}
 
@@ -82,9 +104,17 @@ func (s *OrderServer) ListOrders(ctx context.Context, req *order.ListOrdersReque
 		protoOrder := &order.Order{
 			Id:          orderOutput.ID,
 			Description: orderOutput.Description,
+			Items:       protoOrderItems(orderOutput.Items),
+			Currency:    orderOutput.Currency,
+			TotalAmount: orderOutput.TotalAmount,
+			Status:      orderOutput.Status,
 			CreatedAt:   timestamppb.New(createdAt),
 			UpdatedAt:   timestamppb.New(updatedAt),
 		}
+		if orderOutput.DeletedAt != "" {
+			deletedAt, _ := time.Parse(time.RFC3339, orderOutput.DeletedAt)
+			protoOrder.DeletedAt = timestamppb.New(deletedAt)
+		}
 		protoOrders = append(protoOrders, protoOrder)
 	}
 
@@ -94,6 +124,234 @@ func (s *OrderServer) ListOrders(ctx context.Context, req *order.ListOrdersReque
 	}, nil
 }
 
+// SearchOrders implements the SearchOrders RPC method
+func (s *OrderServer) SearchOrders(ctx context.Context, req *order.SearchOrdersRequest) (*order.SearchOrdersResponse, error) {
+	// Execute use case
+	output, err := s.container.SearchOrdersUseCase.Execute(ctx, usecase.SearchOrdersInput{
+		Query:          req.Query,
+		Limit:          int(req.Limit),
+		IncludeDeleted: req.IncludeDeleted,
+	})
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidSearchQuery) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		slog.ErrorContext(ctx, "Failed to search orders", "error", err)
+		return nil, status.Error(codes.Internal, "failed to search orders")
+	}
+
+	// Convert to protobuf response
+	results := make([]*order.OrderSearchResult, 0, len(output))
+	for _, result := range output {
+		createdAt, _ := time.Parse(time.RFC3339, result.CreatedAt)
+		updatedAt, _ := time.Parse(time.RFC3339, result.UpdatedAt)
+
+		protoOrder := &order.Order{
+			Id:          result.ID,
+			Description: result.Description,
+			Items:       protoOrderItems(result.Items),
+			Currency:    result.Currency,
+			TotalAmount: result.TotalAmount,
+			Status:      result.Status,
+			CreatedAt:   timestamppb.New(createdAt),
+			UpdatedAt:   timestamppb.New(updatedAt),
+		}
+		if result.DeletedAt != "" {
+			deletedAt, _ := time.Parse(time.RFC3339, result.DeletedAt)
+			protoOrder.DeletedAt = timestamppb.New(deletedAt)
+		}
+		results = append(results, &order.OrderSearchResult{
+			Order:   protoOrder,
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		})
+	}
+
+	return &order.SearchOrdersResponse{
+		Results: results,
+		Total:   int32(len(results)),
+	}, nil
+}
+
+// GetOrderStats implements the GetOrderStats RPC method
+func (s *OrderServer) GetOrderStats(ctx context.Context, req *order.GetOrderStatsRequest) (*order.GetOrderStatsResponse, error) {
+	// Execute use case
+	output, err := s.container.GetOrderStatsUseCase.Execute(ctx, usecase.GetOrderStatsInput{
+		GroupBy:        req.GroupBy,
+		From:           req.From,
+		To:             req.To,
+		TimeZone:       req.TimeZone,
+		IncludeDeleted: req.IncludeDeleted,
+	})
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidStatsQuery) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		slog.ErrorContext(ctx, "Failed to get order stats", "error", err)
+		return nil, status.Error(codes.Internal, "failed to get order stats")
+	}
+
+	// Convert to protobuf response
+	from, _ := time.Parse(time.RFC3339, output.From)
+	to, _ := time.Parse(time.RFC3339, output.To)
+	response := &order.GetOrderStatsResponse{
+		GroupBy:  output.GroupBy,
+		TimeZone: output.TimeZone,
+		From:     timestamppb.New(from),
+		To:       timestamppb.New(to),
+		Total:    output.Total,
+		ByStatus: output.ByStatus,
+		Revenue:  output.Revenue,
+		Buckets:  make([]*order.OrderStatsBucket, 0, len(output.Buckets)),
+	}
+	for _, bucket := range output.Buckets {
+		start, _ := time.Parse(time.RFC3339, bucket.Start)
+		end, _ := time.Parse(time.RFC3339, bucket.End)
+		response.Buckets = append(response.Buckets, &order.OrderStatsBucket{
+			Start:    timestamppb.New(start),
+			End:      timestamppb.New(end),
+			Total:    bucket.Total,
+			ByStatus: bucket.ByStatus,
+			Revenue:  bucket.Revenue,
+		})
+	}
+
+	return response, nil
+}
+
+// DeleteOrder implements the DeleteOrder RPC method
+func (s *OrderServer) DeleteOrder(ctx context.Context, req *order.DeleteOrderRequest) (*order.DeleteOrderResponse, error) {
+	if err := s.container.DeleteOrderUseCase.Execute(ctx, req.Id); err != nil {
+		return nil, orderStatusError(ctx, "delete", err)
+	}
+
+	return &order.DeleteOrderResponse{Success: true}, nil
+}
+
+// RestoreOrder implements the RestoreOrder RPC method
+func (s *OrderServer) RestoreOrder(ctx context.Context, req *order.RestoreOrderRequest) (*order.RestoreOrderResponse, error) {
+	output, err := s.container.RestoreOrderUseCase.Execute(ctx, req.Id)
+	if err != nil {
+		return nil, orderStatusError(ctx, "restore", err)
+	}
+
+	// Convert to protobuf response
+	createdAt, _ := time.Parse(time.RFC3339, output.CreatedAt)
+	updatedAt, _ := time.Parse(time.RFC3339, output.UpdatedAt)
+
+	return &order.RestoreOrderResponse{
+		Order: &order.Order{
+			Id:          output.ID,
+			Description: output.Description,
+			Items:       protoOrderItems(output.Items),
+			Currency:    output.Currency,
+			TotalAmount: output.TotalAmount,
+			Status:      output.Status,
+			CreatedAt:   timestamppb.New(createdAt),
+			UpdatedAt:   timestamppb.New(updatedAt),
+		},
+	}, nil
+}
+
+// PurgeOrder implements the PurgeOrder RPC method
+func (s *OrderServer) PurgeOrder(ctx context.Context, req *order.PurgeOrderRequest) (*order.PurgeOrderResponse, error) {
+	if err := s.container.PurgeOrderUseCase.Execute(ctx, req.Id); err != nil {
+		return nil, orderStatusError(ctx, "purge", err)
+	}
+
+	return &order.PurgeOrderResponse{Success: true}, nil
+}
+
+// GetOrderHistory implements the GetOrderHistory RPC method
+func (s *OrderServer) GetOrderHistory(ctx context.Context, req *order.GetOrderHistoryRequest) (*order.GetOrderHistoryResponse, error) {
+	output, err := s.container.GetOrderHistoryUseCase.Execute(ctx, req.Id)
+	if err != nil {
+		return nil, orderStatusError(ctx, "get history of", err)
+	}
+
+	// Convert to protobuf response
+	var entries []*order.OrderHistoryEntry
+	for _, entry := range output {
+		createdAt, _ := time.Parse(time.RFC3339, entry.CreatedAt)
+		protoEntry := &order.OrderHistoryEntry{
+			Id:           entry.ID,
+			OrderId:      entry.OrderID,
+			Action:       entry.Action,
+			Actor:        entry.Actor,
+			ClaimedActor: entry.ClaimedActor,
+			RequestId:    entry.RequestID,
+			CreatedAt:    timestamppb.New(createdAt),
+		}
+		for _, change := range entry.Changes {
+			protoEntry.Changes = append(protoEntry.Changes, &order.FieldChange{
+				Field:  change.Field,
+				Before: change.Before,
+				After:  change.After,
+			})
+		}
+		entries = append(entries, protoEntry)
+	}
+
+	return &order.GetOrderHistoryResponse{
+		Entries: entries,
+		Total:   int32(len(entries)),
+	}, nil
+}
+
+// orderStatusError maps errors of single order operations to gRPC status codes
+func orderStatusError(ctx context.Context, action string, err error) error {
+	var validationErr *usecase.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationStatusError(validationErr)
+	case errors.Is(err, repository.ErrOrderNotFound):
+		return status.Error(codes.NotFound, "order not found")
+	case errors.Is(err, repository.ErrInvalidOrderID):
+		return status.Error(codes.InvalidArgument, "invalid order ID")
+	case errors.Is(err, entity.ErrInvalidOrder):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrOrderConcurrentModification):
+		return status.Error(codes.Aborted, "order was modified concurrently")
+	case errors.Is(err, auth.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		slog.ErrorContext(ctx, "Failed to "+action+" order", "error", err)
+		return status.Error(codes.Internal, "failed to "+action+" order")
+	}
+}
+
+// protoOrderItems converts the items of a use case output to protobuf
+func protoOrderItems(items []usecase.OrderItem) []*order.OrderItem {
+	converted := make([]*order.OrderItem, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, &order.OrderItem{
+			Name:      item.Name,
+			Quantity:  int32(item.Quantity),
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return converted
+}
+
+// validationStatusError maps the invalid fields of a use case input to an
+// InvalidArgument status carrying a BadRequest detail per field
+func validationStatusError(err *usecase.ValidationError) error {
+	badRequest := &errdetails.BadRequest{}
+	for _, field := range err.Fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field.Field,
+			Description: field.Message,
+			Reason:      field.Code,
+		})
+	}
+
+	st, detailsErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(badRequest)
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
+
 // GRPCServer represents the gRPC server
 type GRPCServer struct {
 	server    *grpc.Server
@@ -101,25 +359,101 @@ type GRPCServer struct {
 	port      string
 }
 
-// NewGRPCServer creates a new gRPC server
-func NewGRPCServer(container *container.Container) *GRPCServer {
-	port := os.Getenv("GRPC_PORT")
-	if port == "" {
-		port = "8082"
+// ServerOption configures the interceptor chain of a GRPCServer
+type ServerOption func(*serverOptions)
+
+// serverOptions holds the settings of the interceptor chain
+type serverOptions struct {
+	defaultDeadline time.Duration
+	maxDeadline     time.Duration
+	validators      []RequestValidator
+	unary           []grpc.UnaryServerInterceptor
+	stream          []grpc.StreamServerInterceptor
+}
+
+// WithDeadlines overrides the default and max deadlines of the server config,
+// a zero duration disables the rule
+func WithDeadlines(defaultDeadline, maxDeadline time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.defaultDeadline = defaultDeadline
+		o.maxDeadline = maxDeadline
+	}
+}
+
+// WithRequestValidators adds hooks validating every request once the caller is authenticated
+func WithRequestValidators(validators ...RequestValidator) ServerOption {
+	return func(o *serverOptions) {
+		o.validators = append(o.validators, validators...)
+	}
+}
+
+// WithUnaryInterceptors appends interceptors to the end of the unary chain, next to the handlers
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) ServerOption {
+	return func(o *serverOptions) {
+		o.unary = append(o.unary, interceptors...)
 	}
+}
+
+// WithStreamInterceptors appends interceptors to the end of the stream chain, next to the handlers
+func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) ServerOption {
+	return func(o *serverOptions) {
+		o.stream = append(o.stream, interceptors...)
+	}
+}
+
+// NewGRPCServer creates a new gRPC server. Panics are recovered inside the
+// tracing, access log and metrics interceptors, so they record the Internal
+// status, and deadlines are bounded before any other work is done.
+func NewGRPCServer(container *container.Container, opts ...ServerOption) *GRPCServer {
+	options := serverOptions{
+		defaultDeadline: container.Config.Server.GRPCDefaultDeadline,
+		maxDeadline:     container.Config.Server.GRPCMaxDeadline,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	unary := append([]grpc.UnaryServerInterceptor{
+		tracing.UnaryServerInterceptor(),
+		logger.UnaryServerInterceptor(),
+		container.Metrics.UnaryServerInterceptor(),
+		RecoveryUnaryServerInterceptor(),
+		DeadlineUnaryServerInterceptor(options.defaultDeadline, options.maxDeadline),
+		locale.UnaryServerInterceptor(),
+		container.RateLimiter.UnaryServerInterceptor(),
+		container.TenantResolver.UnaryServerInterceptor(),
+		container.Authenticator.UnaryServerInterceptor(),
+		ValidationUnaryServerInterceptor(options.validators...),
+	}, options.unary...)
+	stream := append([]grpc.StreamServerInterceptor{
+		tracing.StreamServerInterceptor(),
+		logger.StreamServerInterceptor(),
+		container.Metrics.StreamServerInterceptor(),
+		RecoveryStreamServerInterceptor(),
+		DeadlineStreamServerInterceptor(options.defaultDeadline, options.maxDeadline),
+		locale.StreamServerInterceptor(),
+		container.RateLimiter.StreamServerInterceptor(),
+		container.TenantResolver.StreamServerInterceptor(),
+		container.Authenticator.StreamServerInterceptor(),
+		ValidationStreamServerInterceptor(options.validators...),
+	}, options.stream...)
 
 	return &GRPCServer{
-		server:    grpc.NewServer(),
+		server: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(unary...),
+			grpc.ChainStreamInterceptor(stream...),
+		),
 		container: container,
-		port:      port,
+		port:      container.Config.Server.GRPCPort,
 	}
 }
 
 // Start starts the gRPC server
 func (s *GRPCServer) Start() error {
-	// Register the service
+	// Register the services
 	orderServer := NewOrderServer(s.container)
 	order.RegisterOrderServiceServer(s.server, orderServer)
+	order.RegisterWebhookServiceServer(s.server, NewWebhookServer(s.container))
 
 	// Start listening
 	lis, err := net.Listen("tcp", ":"+s.port)
@@ -127,8 +461,7 @@ func (s *GRPCServer) Start() error {
 		return err
 	}
 
-	log.Printf("gRPC server starting on port %s", s.port)
-	log.Printf("gRPC server: localhost:%s", s.port)
+	slog.Info("gRPC server starting", "port", s.port, "address", "localhost:"+s.port)
 
 	return s.server.Serve(lis)
 }
@@ -139,3 +472,18 @@ func (s *GRPCServer) Stop() {
 		s.server.GracefulStop()
 	}
 }
+
+// Shutdown stops the server gracefully, forcing it to stop when ctx expires
+func (s *GRPCServer) Shutdown(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
@@ -0,0 +1,161 @@
+package grpc
+
+import (
+	"context"
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/usecase"
+	"errors"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	order "curso-go-clean-arch/proto"
+)
+
+// WebhookServer implements the gRPC WebhookService
+type WebhookServer struct {
+	order.UnimplementedWebhookServiceServer
+	container *container.Container
+}
+
+// NewWebhookServer creates a new gRPC webhook server
+func NewWebhookServer(container *container.Container) *WebhookServer {
+	return &WebhookServer{
+		container: container,
+	}
+}
+
+// RegisterWebhook implements the RegisterWebhook RPC method
+func (s *WebhookServer) RegisterWebhook(ctx context.Context, req *order.RegisterWebhookRequest) (*order.RegisterWebhookResponse, error) {
+	output, err := s.container.RegisterWebhookUseCase.Execute(ctx, usecase.RegisterWebhookInput{
+		URL:        req.Url,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+	})
+	if err != nil {
+		return nil, webhookStatusError(ctx, "register webhook endpoint", err)
+	}
+
+	return &order.RegisterWebhookResponse{Endpoint: toProtoWebhookEndpoint(output)}, nil
+}
+
+// ListWebhooks implements the ListWebhooks RPC method
+func (s *WebhookServer) ListWebhooks(ctx context.Context, req *order.ListWebhooksRequest) (*order.ListWebhooksResponse, error) {
+	output, err := s.container.ListWebhooksUseCase.Execute(ctx)
+	if err != nil {
+		return nil, webhookStatusError(ctx, "list webhook endpoints", err)
+	}
+
+	// Convert to protobuf response
+	var endpoints []*order.WebhookEndpoint
+	for _, endpoint := range output {
+		endpoints = append(endpoints, toProtoWebhookEndpoint(endpoint))
+	}
+
+	return &order.ListWebhooksResponse{
+		Endpoints: endpoints,
+		Total:     int32(len(endpoints)),
+	}, nil
+}
+
+// DeleteWebhook implements the DeleteWebhook RPC method
+func (s *WebhookServer) DeleteWebhook(ctx context.Context, req *order.DeleteWebhookRequest) (*order.DeleteWebhookResponse, error) {
+	if err := s.container.DeleteWebhookUseCase.Execute(ctx, req.Id); err != nil {
+		return nil, webhookStatusError(ctx, "delete webhook endpoint", err)
+	}
+
+	return &order.DeleteWebhookResponse{Success: true}, nil
+}
+
+// ListWebhookDeliveries implements the ListWebhookDeliveries RPC method
+func (s *WebhookServer) ListWebhookDeliveries(ctx context.Context, req *order.ListWebhookDeliveriesRequest) (*order.ListWebhookDeliveriesResponse, error) {
+	output, err := s.container.ListWebhookDeliveriesUseCase.Execute(ctx, req.EndpointId, req.Status)
+	if err != nil {
+		return nil, webhookStatusError(ctx, "list webhook deliveries", err)
+	}
+
+	// Convert to protobuf response
+	var deliveries []*order.WebhookDelivery
+	for _, delivery := range output {
+		deliveries = append(deliveries, toProtoWebhookDelivery(delivery))
+	}
+
+	return &order.ListWebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		Total:      int32(len(deliveries)),
+	}, nil
+}
+
+// RetryWebhookDelivery implements the RetryWebhookDelivery RPC method
+func (s *WebhookServer) RetryWebhookDelivery(ctx context.Context, req *order.RetryWebhookDeliveryRequest) (*order.RetryWebhookDeliveryResponse, error) {
+	output, err := s.container.RetryWebhookDeliveryUseCase.Execute(ctx, req.EndpointId, req.DeliveryId)
+	if err != nil {
+		return nil, webhookStatusError(ctx, "retry webhook delivery", err)
+	}
+
+	return &order.RetryWebhookDeliveryResponse{Delivery: toProtoWebhookDelivery(output)}, nil
+}
+
+// toProtoWebhookEndpoint converts a webhook endpoint output to protobuf
+func toProtoWebhookEndpoint(endpoint *usecase.WebhookEndpointOutput) *order.WebhookEndpoint {
+	return &order.WebhookEndpoint{
+		Id:         endpoint.ID,
+		Url:        endpoint.URL,
+		EventTypes: endpoint.EventTypes,
+		Secret:     endpoint.Secret,
+		CreatedAt:  protoTimestamp(endpoint.CreatedAt),
+	}
+}
+
+// toProtoWebhookDelivery converts a webhook delivery output to protobuf
+func toProtoWebhookDelivery(delivery *usecase.WebhookDeliveryOutput) *order.WebhookDelivery {
+	protoDelivery := &order.WebhookDelivery{
+		Id:             delivery.ID,
+		EndpointId:     delivery.EndpointID,
+		EventId:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       int32(delivery.Attempts),
+		NextAttemptAt:  protoTimestamp(delivery.NextAttemptAt),
+		LastStatusCode: int32(delivery.LastStatusCode),
+		LastError:      delivery.LastError,
+		CreatedAt:      protoTimestamp(delivery.CreatedAt),
+		UpdatedAt:      protoTimestamp(delivery.UpdatedAt),
+	}
+	if delivery.DeliveredAt != nil {
+		protoDelivery.DeliveredAt = protoTimestamp(*delivery.DeliveredAt)
+	}
+	return protoDelivery
+}
+
+// protoTimestamp converts an RFC 3339 use case timestamp to protobuf
+func protoTimestamp(value string) *timestamppb.Timestamp {
+	t, _ := time.Parse(time.RFC3339, value)
+	return timestamppb.New(t)
+}
+
+// webhookStatusError maps errors of webhook operations to gRPC status codes
+func webhookStatusError(ctx context.Context, action string, err error) error {
+	var validationErr *usecase.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationStatusError(validationErr)
+	case errors.Is(err, entity.ErrInvalidWebhookEndpoint), errors.Is(err, entity.ErrInvalidWebhookDeliveryStatus):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrWebhookEndpointNotFound):
+		return status.Error(codes.NotFound, "webhook endpoint not found")
+	case errors.Is(err, repository.ErrWebhookDeliveryNotFound):
+		return status.Error(codes.NotFound, "webhook delivery not found")
+	case errors.Is(err, auth.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		slog.ErrorContext(ctx, "Failed to "+action, "error", err)
+		return status.Error(codes.Internal, "failed to "+action)
+	}
+}
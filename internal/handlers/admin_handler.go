@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/handlers/dto"
+	"curso-go-clean-arch/internal/problem"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// AdminHandler handles HTTP requests for maintenance operations
+type AdminHandler struct {
+	container *container.Container
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(container *container.Container) *AdminHandler {
+	return &AdminHandler{
+		container: container,
+	}
+}
+
+// RebuildProjections handles POST /admin/projections/rebuild
+func (h *AdminHandler) RebuildProjections(w http.ResponseWriter, r *http.Request) {
+	output, err := h.container.RebuildOrderProjectionsUseCase.Execute(r.Context())
+	if err != nil {
+		if errors.Is(err, auth.ErrForbidden) {
+			problem.Forbidden(w, r, "Admin privileges required")
+			return
+		}
+		if errors.Is(err, repository.ErrRowLevelSecurity) {
+			problem.Internal(w, r, "The database user must bypass row level security to rebuild projections", err)
+			return
+		}
+		problem.Internal(w, r, "Failed to rebuild projections", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode((*dto.RebuildProjectionsResponse)(output))
+}
@@ -0,0 +1,7 @@
+package dto
+
+// RebuildProjectionsResponse represents the response body for rebuilding a projection
+type RebuildProjectionsResponse struct {
+	Projection string `json:"projection"`
+	Orders     int64  `json:"orders"`
+}
@@ -5,17 +5,33 @@ import (
 	"time"
 )
 
+// OrderItem represents an item of an order in requests and responses, the unit
+// price in minor units of the currency
+type OrderItem struct {
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice int64  `json:"unit_price"`
+}
+
 // CreateOrderRequest represents the request body for creating an order
 type CreateOrderRequest struct {
-	Description string `json:"description" validate:"required"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items,omitempty"`
+	Currency    string      `json:"currency,omitempty"`
 }
 
-// OrderResponse represents the response body for order operations
+// OrderResponse represents the response body for order operations, the total
+// amount in minor units of the currency
 type OrderResponse struct {
-	ID          string `json:"id"`
-	Description string `json:"description"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items"`
+	Currency    string      `json:"currency"`
+	TotalAmount int64       `json:"total_amount"`
+	Status      string      `json:"status"`
+	CreatedAt   string      `json:"created_at"`
+	UpdatedAt   string      `json:"updated_at"`
+	DeletedAt   string      `json:"deleted_at,omitempty"`
 }
 
 // ListOrdersResponse represents the response body for listing orders
@@ -24,19 +40,79 @@ type ListOrdersResponse struct {
 	Total  int              `json:"total"`
 }
 
+// OrderSearchResultResponse represents an order matching a search, the snippet
+// marks the matches with <mark></mark> and is not HTML escaped
+type OrderSearchResultResponse struct {
+	Order   *OrderResponse `json:"order"`
+	Rank    float64        `json:"rank"`
+	Snippet string         `json:"snippet"`
+}
+
+// SearchOrdersResponse represents the response body for searching orders
+type SearchOrdersResponse struct {
+	Query   string                       `json:"query"`
+	Results []*OrderSearchResultResponse `json:"results"`
+	Total   int                          `json:"total"`
+}
+
+// FieldChangeResponse represents the before and after value of a changed field
+type FieldChangeResponse struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// OrderHistoryEntryResponse represents a single entry of the history of an order
+type OrderHistoryEntryResponse struct {
+	ID           string                `json:"id"`
+	Action       string                `json:"action"`
+	Actor        string                `json:"actor"`
+	ClaimedActor string                `json:"claimed_actor,omitempty"`
+	RequestID    string                `json:"request_id,omitempty"`
+	Changes      []FieldChangeResponse `json:"changes"`
+	CreatedAt    string                `json:"created_at"`
+}
+
+// OrderHistoryResponse represents the response body for the history of an order
+type OrderHistoryResponse struct {
+	OrderID string                       `json:"order_id"`
+	Entries []*OrderHistoryEntryResponse `json:"entries"`
+	Total   int                          `json:"total"`
+}
+
 // ToEntity converts CreateOrderRequest to domain entity
 func (r *CreateOrderRequest) ToEntity() *entity.Order {
-	return entity.NewOrder(r.Description)
+	order := entity.NewOrder(r.Description)
+	items := make([]entity.OrderItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		items = append(items, entity.OrderItem(item))
+	}
+	order.SetItems(items)
+	if r.Currency != "" {
+		order.Currency = r.Currency
+	}
+	return order
 }
 
 // FromEntity converts domain entity to OrderResponse
 func FromEntity(order *entity.Order) *OrderResponse {
-	return &OrderResponse{
+	response := &OrderResponse{
 		ID:          order.ID.String(),
 		Description: order.Description,
+		Items:       make([]OrderItem, 0, len(order.Items)),
+		Currency:    order.Currency,
+		TotalAmount: order.TotalAmount,
+		Status:      string(order.Status),
 		CreatedAt:   order.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   order.UpdatedAt.Format(time.RFC3339),
 	}
+	for _, item := range order.Items {
+		response.Items = append(response.Items, OrderItem(item))
+	}
+	if order.DeletedAt != nil {
+		response.DeletedAt = order.DeletedAt.Format(time.RFC3339)
+	}
+	return response
 }
 
 // FromEntities converts slice of domain entities to ListOrdersResponse
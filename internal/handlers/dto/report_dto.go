@@ -0,0 +1,23 @@
+package dto
+
+// OrderStatsBucketResponse represents the orders created in one period
+type OrderStatsBucketResponse struct {
+	Start    string           `json:"start"`
+	End      string           `json:"end"`
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"`
+	Revenue  map[string]int64 `json:"revenue"`
+}
+
+// OrderStatsResponse represents the response body for the order report. Revenue
+// maps currencies to the totals of the orders not cancelled, in minor units.
+type OrderStatsResponse struct {
+	GroupBy  string                      `json:"group_by"`
+	TimeZone string                      `json:"time_zone"`
+	From     string                      `json:"from"`
+	To       string                      `json:"to"`
+	Total    int64                       `json:"total"`
+	ByStatus map[string]int64            `json:"by_status"`
+	Revenue  map[string]int64            `json:"revenue"`
+	Buckets  []*OrderStatsBucketResponse `json:"buckets"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+// CreateWebhookEndpointRequest represents the request body for registering a webhook endpoint
+type CreateWebhookEndpointRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+// WebhookEndpointResponse represents a webhook endpoint, the secret is only
+// returned when the endpoint is registered
+type WebhookEndpointResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// ListWebhookEndpointsResponse represents the response body for listing webhook endpoints
+type ListWebhookEndpointsResponse struct {
+	Endpoints []*WebhookEndpointResponse `json:"endpoints"`
+	Total     int                        `json:"total"`
+}
+
+// WebhookDeliveryResponse represents a delivery of an event to a webhook endpoint
+type WebhookDeliveryResponse struct {
+	ID             string  `json:"id"`
+	EndpointID     string  `json:"endpoint_id"`
+	EventID        string  `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	NextAttemptAt  string  `json:"next_attempt_at"`
+	LastStatusCode int     `json:"last_status_code,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+	DeliveredAt    *string `json:"delivered_at,omitempty"`
+}
+
+// ListWebhookDeliveriesResponse represents the response body for the delivery log of a webhook endpoint
+type ListWebhookDeliveriesResponse struct {
+	EndpointID string                     `json:"endpoint_id"`
+	Deliveries []*WebhookDeliveryResponse `json:"deliveries"`
+	Total      int                        `json:"total"`
+}
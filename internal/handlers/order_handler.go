@@ -1,26 +1,30 @@
 package handlers
 
 import (
+	"curso-go-clean-arch/internal/auth"
 	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
 	"curso-go-clean-arch/internal/handlers/dto"
+	"curso-go-clean-arch/internal/problem"
 	"curso-go-clean-arch/internal/usecase"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
 )
 
 // OrderHandler handles HTTP requests for orders
 type OrderHandler struct {
 	container *container.Container
-	validate  *validator.Validate
 }
 
 // NewOrderHandler creates a new order handler
 func NewOrderHandler(container *container.Container) *OrderHandler {
 	return &OrderHandler{
 		container: container,
-		validate:  validator.New(),
 	}
 }
 
@@ -28,25 +32,24 @@ func NewOrderHandler(container *container.Container) *OrderHandler {
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateOrderRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	if err := h.validate.Struct(req); err != nil {
-		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Convert to use case input
 	input := usecase.CreateOrderInput{
-		Description: req.Description,
+		Description:    req.Description,
+		Currency:       req.Currency,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	}
+	for _, item := range req.Items {
+		input.Items = append(input.Items, usecase.OrderItem(item))
 	}
 
 	// Execute use case
 	output, err := h.container.CreateOrderUseCase.Execute(r.Context(), input)
 	if err != nil {
-		http.Error(w, "Failed to create order: "+err.Error(), http.StatusInternalServerError)
+		writeOrderError(w, r, "create", err)
 		return
 	}
 
@@ -54,6 +57,10 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	response := &dto.OrderResponse{
 		ID:          output.ID,
 		Description: output.Description,
+		Items:       orderItemsResponse(output.Items),
+		Currency:    output.Currency,
+		TotalAmount: output.TotalAmount,
+		Status:      output.Status,
 		CreatedAt:   output.CreatedAt,
 		UpdatedAt:   output.UpdatedAt,
 	}
@@ -65,10 +72,20 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 // ListOrders handles GET /orders
 func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	var input usecase.ListOrdersInput
+	if value := r.URL.Query().Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			problem.BadRequest(w, r, "Invalid include_deleted, expected true or false")
+			return
+		}
+		input.IncludeDeleted = includeDeleted
+	}
+
 	// Execute use case
-	output, err := h.container.ListOrdersUseCase.Execute(r.Context())
+	output, err := h.container.ListOrdersUseCase.Execute(r.Context(), input)
 	if err != nil {
-		http.Error(w, "Failed to list orders: "+err.Error(), http.StatusInternalServerError)
+		problem.Internal(w, r, "Failed to list orders", err)
 		return
 	}
 
@@ -78,8 +95,13 @@ func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
 		orders = append(orders, &dto.OrderResponse{
 			ID:          order.ID,
 			Description: order.Description,
+			Items:       orderItemsResponse(order.Items),
+			Currency:    order.Currency,
+			TotalAmount: order.TotalAmount,
+			Status:      order.Status,
 			CreatedAt:   order.CreatedAt,
 			UpdatedAt:   order.UpdatedAt,
+			DeletedAt:   order.DeletedAt,
 		})
 	}
 
@@ -91,3 +113,178 @@ func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// SearchOrders handles GET /orders/search
+func (h *OrderHandler) SearchOrders(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	input := usecase.SearchOrdersInput{Query: params.Get("q")}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			problem.BadRequest(w, r, "Invalid limit, expected an integer")
+			return
+		}
+		input.Limit = limit
+	}
+	if value := params.Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			problem.BadRequest(w, r, "Invalid include_deleted, expected true or false")
+			return
+		}
+		input.IncludeDeleted = includeDeleted
+	}
+
+	// Execute use case
+	output, err := h.container.SearchOrdersUseCase.Execute(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidSearchQuery) {
+			problem.BadRequest(w, r, err.Error())
+			return
+		}
+		problem.Internal(w, r, "Failed to search orders", err)
+		return
+	}
+
+	// Convert to response
+	results := make([]*dto.OrderSearchResultResponse, 0, len(output))
+	for _, result := range output {
+		results = append(results, &dto.OrderSearchResultResponse{
+			Order: &dto.OrderResponse{
+				ID:          result.ID,
+				Description: result.Description,
+				Items:       orderItemsResponse(result.Items),
+				Currency:    result.Currency,
+				TotalAmount: result.TotalAmount,
+				Status:      result.Status,
+				CreatedAt:   result.CreatedAt,
+				UpdatedAt:   result.UpdatedAt,
+				DeletedAt:   result.DeletedAt,
+			},
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		})
+	}
+
+	response := &dto.SearchOrdersResponse{
+		Query:   input.Query,
+		Results: results,
+		Total:   len(results),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteOrder handles DELETE /orders/{id}
+func (h *OrderHandler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
+	if err := h.container.DeleteOrderUseCase.Execute(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeOrderError(w, r, "delete", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreOrder handles POST /orders/{id}/restore
+func (h *OrderHandler) RestoreOrder(w http.ResponseWriter, r *http.Request) {
+	output, err := h.container.RestoreOrderUseCase.Execute(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeOrderError(w, r, "restore", err)
+		return
+	}
+
+	// Convert to response
+	response := &dto.OrderResponse{
+		ID:          output.ID,
+		Description: output.Description,
+		Items:       orderItemsResponse(output.Items),
+		Currency:    output.Currency,
+		TotalAmount: output.TotalAmount,
+		Status:      output.Status,
+		CreatedAt:   output.CreatedAt,
+		UpdatedAt:   output.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// PurgeOrder handles DELETE /orders/{id}/purge
+func (h *OrderHandler) PurgeOrder(w http.ResponseWriter, r *http.Request) {
+	if err := h.container.PurgeOrderUseCase.Execute(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeOrderError(w, r, "purge", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetOrderHistory handles GET /orders/{id}/history
+func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["id"]
+
+	// Execute use case
+	output, err := h.container.GetOrderHistoryUseCase.Execute(r.Context(), orderID)
+	if err != nil {
+		writeOrderError(w, r, "get history of", err)
+		return
+	}
+
+	// Convert to response
+	entries := make([]*dto.OrderHistoryEntryResponse, 0, len(output))
+	for _, entry := range output {
+		item := &dto.OrderHistoryEntryResponse{
+			ID:           entry.ID,
+			Action:       entry.Action,
+			Actor:        entry.Actor,
+			ClaimedActor: entry.ClaimedActor,
+			RequestID:    entry.RequestID,
+			Changes:      make([]dto.FieldChangeResponse, 0, len(entry.Changes)),
+			CreatedAt:    entry.CreatedAt,
+		}
+		for _, change := range entry.Changes {
+			item.Changes = append(item.Changes, dto.FieldChangeResponse(change))
+		}
+		entries = append(entries, item)
+	}
+
+	response := &dto.OrderHistoryResponse{
+		OrderID: orderID,
+		Entries: entries,
+		Total:   len(entries),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeOrderError maps errors of single order operations to HTTP status codes
+func writeOrderError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	var validationErr *usecase.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		problem.Validation(w, r, fieldErrors(validationErr))
+	case errors.Is(err, repository.ErrOrderNotFound):
+		problem.NotFound(w, r, "Order not found")
+	case errors.Is(err, repository.ErrInvalidOrderID):
+		problem.BadRequest(w, r, "Invalid order ID, expected a UUID")
+	case errors.Is(err, entity.ErrInvalidOrder):
+		problem.BadRequest(w, r, err.Error())
+	case errors.Is(err, repository.ErrOrderConcurrentModification):
+		problem.Conflict(w, r, "Order was modified concurrently, retry the request")
+	case errors.Is(err, auth.ErrForbidden):
+		problem.Forbidden(w, r, "Admin privileges required")
+	default:
+		problem.Internal(w, r, "Failed to "+action+" order", err)
+	}
+}
+
+// orderItemsResponse converts the items of a use case output to the response
+func orderItemsResponse(items []usecase.OrderItem) []dto.OrderItem {
+	response := make([]dto.OrderItem, 0, len(items))
+	for _, item := range items {
+		response = append(response, dto.OrderItem(item))
+	}
+	return response
+}
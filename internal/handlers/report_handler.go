@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/handlers/dto"
+	"curso-go-clean-arch/internal/problem"
+	"curso-go-clean-arch/internal/usecase"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ReportHandler handles HTTP requests for reports
+type ReportHandler struct {
+	container *container.Container
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(container *container.Container) *ReportHandler {
+	return &ReportHandler{
+		container: container,
+	}
+}
+
+// OrderStats handles GET /reports/orders
+func (h *ReportHandler) OrderStats(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	input := usecase.GetOrderStatsInput{
+		GroupBy:  params.Get("group_by"),
+		From:     params.Get("from"),
+		To:       params.Get("to"),
+		TimeZone: params.Get("tz"),
+	}
+	if value := params.Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			problem.BadRequest(w, r, "Invalid include_deleted, expected true or false")
+			return
+		}
+		input.IncludeDeleted = includeDeleted
+	}
+
+	// Execute use case
+	output, err := h.container.GetOrderStatsUseCase.Execute(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidStatsQuery) {
+			problem.BadRequest(w, r, err.Error())
+			return
+		}
+		problem.Internal(w, r, "Failed to get order stats", err)
+		return
+	}
+
+	// Convert to response
+	response := &dto.OrderStatsResponse{
+		GroupBy:  output.GroupBy,
+		TimeZone: output.TimeZone,
+		From:     output.From,
+		To:       output.To,
+		Total:    output.Total,
+		ByStatus: output.ByStatus,
+		Revenue:  output.Revenue,
+		Buckets:  make([]*dto.OrderStatsBucketResponse, 0, len(output.Buckets)),
+	}
+	for _, bucket := range output.Buckets {
+		response.Buckets = append(response.Buckets, (*dto.OrderStatsBucketResponse)(bucket))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"curso-go-clean-arch/internal/locale"
+	"curso-go-clean-arch/internal/problem"
+	"curso-go-clean-arch/internal/usecase"
+)
+
+// typeMessages describe a JSON value of the wrong type in every supported locale
+var typeMessages = map[string]string{
+	locale.English:             "must be a JSON %s",
+	locale.BrazilianPortuguese: "deve ser do tipo JSON %s",
+}
+
+// decodeJSON decodes the JSON body of r into req, writing a problem and
+// returning false when it fails. The fields are validated by the use cases.
+func decodeJSON(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			problem.Validation(w, r, []problem.FieldError{{
+				Field:   typeErr.Field,
+				Code:    "type",
+				Message: fmt.Sprintf(typeMessages[locale.FromContext(r.Context())], jsonType(typeErr.Type)),
+			}})
+			return false
+		}
+		problem.BadRequest(w, r, "The request body is not valid JSON")
+		return false
+	}
+	return true
+}
+
+// fieldErrors converts the invalid fields of a use case input into problem field errors
+func fieldErrors(err *usecase.ValidationError) []problem.FieldError {
+	fields := make([]problem.FieldError, 0, len(err.Fields))
+	for _, field := range err.Fields {
+		fields = append(fields, problem.FieldError{
+			Field:   field.Field,
+			Code:    field.Code,
+			Message: field.Message,
+		})
+	}
+	return fields
+}
+
+// jsonType names the JSON type decoded into t
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "number"
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/handlers/dto"
+	"curso-go-clean-arch/internal/problem"
+	"curso-go-clean-arch/internal/usecase"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// WebhookHandler handles HTTP requests for webhook endpoints
+type WebhookHandler struct {
+	container *container.Container
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(container *container.Container) *WebhookHandler {
+	return &WebhookHandler{
+		container: container,
+	}
+}
+
+// CreateWebhookEndpoint handles POST /webhooks
+func (h *WebhookHandler) CreateWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateWebhookEndpointRequest
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// Execute use case
+	output, err := h.container.RegisterWebhookUseCase.Execute(r.Context(), usecase.RegisterWebhookInput{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+	})
+	if err != nil {
+		writeWebhookError(w, r, "register webhook endpoint", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode((*dto.WebhookEndpointResponse)(output))
+}
+
+// ListWebhookEndpoints handles GET /webhooks
+func (h *WebhookHandler) ListWebhookEndpoints(w http.ResponseWriter, r *http.Request) {
+	output, err := h.container.ListWebhooksUseCase.Execute(r.Context())
+	if err != nil {
+		writeWebhookError(w, r, "list webhook endpoints", err)
+		return
+	}
+
+	// Convert to response
+	endpoints := make([]*dto.WebhookEndpointResponse, 0, len(output))
+	for _, endpoint := range output {
+		endpoints = append(endpoints, (*dto.WebhookEndpointResponse)(endpoint))
+	}
+
+	response := &dto.ListWebhookEndpointsResponse{
+		Endpoints: endpoints,
+		Total:     len(endpoints),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteWebhookEndpoint handles DELETE /webhooks/{id}
+func (h *WebhookHandler) DeleteWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	if err := h.container.DeleteWebhookUseCase.Execute(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeWebhookError(w, r, "delete webhook endpoint", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET /webhooks/{id}/deliveries
+func (h *WebhookHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	endpointID := mux.Vars(r)["id"]
+
+	// Execute use case
+	output, err := h.container.ListWebhookDeliveriesUseCase.Execute(r.Context(), endpointID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeWebhookError(w, r, "list webhook deliveries", err)
+		return
+	}
+
+	// Convert to response
+	deliveries := make([]*dto.WebhookDeliveryResponse, 0, len(output))
+	for _, delivery := range output {
+		deliveries = append(deliveries, (*dto.WebhookDeliveryResponse)(delivery))
+	}
+
+	response := &dto.ListWebhookDeliveriesResponse{
+		EndpointID: endpointID,
+		Deliveries: deliveries,
+		Total:      len(deliveries),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RetryWebhookDelivery handles POST /webhooks/{id}/deliveries/{deliveryId}/retry
+func (h *WebhookHandler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	output, err := h.container.RetryWebhookDeliveryUseCase.Execute(r.Context(), vars["id"], vars["deliveryId"])
+	if err != nil {
+		writeWebhookError(w, r, "retry webhook delivery", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode((*dto.WebhookDeliveryResponse)(output))
+}
+
+// writeWebhookError maps errors of webhook operations to HTTP status codes
+func writeWebhookError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	var validationErr *usecase.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		problem.Validation(w, r, fieldErrors(validationErr))
+	case errors.Is(err, entity.ErrInvalidWebhookEndpoint), errors.Is(err, entity.ErrInvalidWebhookDeliveryStatus):
+		problem.BadRequest(w, r, err.Error())
+	case errors.Is(err, repository.ErrWebhookEndpointNotFound):
+		problem.NotFound(w, r, "Webhook endpoint not found")
+	case errors.Is(err, repository.ErrWebhookDeliveryNotFound):
+		problem.NotFound(w, r, "Webhook delivery not found")
+	case errors.Is(err, auth.ErrForbidden):
+		problem.Forbidden(w, r, "Admin privileges required")
+	default:
+		problem.Internal(w, r, "Failed to "+action, err)
+	}
+}
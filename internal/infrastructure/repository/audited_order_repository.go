@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/requestid"
+)
+
+// OrderChangeHook is called with every recorded order change, inside the
+// transaction of the change, so work it queues commits or rolls back with it
+type OrderChangeHook interface {
+	// OrderChanged is called with the history entry of a change and the order
+	// after it, or before it when it was purged
+	OrderChanged(ctx context.Context, entry *entity.OrderHistoryEntry, order *entity.Order) error
+}
+
+// anonymousActor is recorded when the caller presented no credential
+const anonymousActor = "anonymous"
+
+// AuditedOrderRepository records an order history entry for every change made
+// through another OrderRepository. Each change and its entry run in the same
+// transaction, so an entry exists if and only if the change was committed.
+//
+// The actor is the principal of ctx and the request ID the one of ctx.
+type AuditedOrderRepository struct {
+	repository.OrderRepository
+	history            repository.OrderHistoryRepository
+	transactionManager repository.TransactionManager
+	hooks              []OrderChangeHook
+}
+
+// NewAuditedOrderRepository wraps next so its changes are recorded in history
+// and passed to hooks
+func NewAuditedOrderRepository(next repository.OrderRepository, history repository.OrderHistoryRepository, transactionManager repository.TransactionManager, hooks ...OrderChangeHook) *AuditedOrderRepository {
+	return &AuditedOrderRepository{
+		OrderRepository:    next,
+		history:            history,
+		transactionManager: transactionManager,
+		hooks:              hooks,
+	}
+}
+
+// Create saves the order and records its creation
+func (r *AuditedOrderRepository) Create(ctx context.Context, order *entity.Order) error {
+	return r.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := r.OrderRepository.Create(ctx, order); err != nil {
+			return err
+		}
+		return r.record(ctx, nil, order, entity.OrderHistoryCreated)
+	})
+}
+
+// CreateMany saves the orders in bulk when supported and records the creation of each
+func (r *AuditedOrderRepository) CreateMany(ctx context.Context, orders []*entity.Order) error {
+	return r.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if bulk, ok := r.OrderRepository.(repository.OrderBulkCreator); ok {
+			if err := bulk.CreateMany(ctx, orders); err != nil {
+				return err
+			}
+		} else {
+			for _, order := range orders {
+				if err := r.OrderRepository.Create(ctx, order); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, order := range orders {
+			if err := r.record(ctx, nil, order, entity.OrderHistoryCreated); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Update saves the order and records the changed fields, as a status change
+// when the status changed
+func (r *AuditedOrderRepository) Update(ctx context.Context, order *entity.Order) error {
+	return r.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.OrderRepository.GetByID(ctx, order.ID.String())
+		if err != nil {
+			return err
+		}
+
+		if err := r.OrderRepository.Update(ctx, order); err != nil {
+			return err
+		}
+
+		action := entity.OrderHistoryUpdated
+		if before.Status != order.Status {
+			action = entity.OrderHistoryStatusChanged
+		}
+		return r.record(ctx, before, order, action)
+	})
+}
+
+// Delete soft deletes the order and records the deletion
+func (r *AuditedOrderRepository) Delete(ctx context.Context, id string) error {
+	return r.change(ctx, id, entity.OrderHistoryDeleted, r.OrderRepository.Delete)
+}
+
+// Restore restores the order and records the restoration
+func (r *AuditedOrderRepository) Restore(ctx context.Context, id string) error {
+	return r.change(ctx, id, entity.OrderHistoryRestored, r.OrderRepository.Restore)
+}
+
+// Purge permanently removes the order and records the purge, the history itself is kept
+func (r *AuditedOrderRepository) Purge(ctx context.Context, id string) error {
+	return r.change(ctx, id, entity.OrderHistoryPurged, r.OrderRepository.Purge)
+}
+
+// change applies fn to a single order and records the difference between the
+// order before and after it, a purged order having no after state
+func (r *AuditedOrderRepository) change(ctx context.Context, id string, action entity.OrderHistoryAction, fn func(ctx context.Context, id string) error) error {
+	return r.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.OrderRepository.GetByIDIncludingDeleted(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(ctx, id); err != nil {
+			return err
+		}
+
+		var after *entity.Order
+		if action != entity.OrderHistoryPurged {
+			if after, err = r.OrderRepository.GetByIDIncludingDeleted(ctx, id); err != nil {
+				return err
+			}
+		}
+		return r.record(ctx, before, after, action)
+	})
+}
+
+// record appends the history entry of a change from before to after and calls the hooks
+func (r *AuditedOrderRepository) record(ctx context.Context, before, after *entity.Order, action entity.OrderHistoryAction) error {
+	order := after
+	if order == nil {
+		order = before
+	}
+
+	entry := entity.NewOrderHistoryEntry(order.ID, action, entity.DiffOrders(before, after))
+	principal := auth.FromContext(ctx)
+	entry.Actor = principal.Actor
+	entry.ClaimedActor = principal.ClaimedActor
+	if entry.Actor == "" {
+		entry.Actor = anonymousActor
+	}
+	entry.RequestID = requestid.FromContext(ctx)
+
+	if err := r.history.Append(ctx, entry); err != nil {
+		return err
+	}
+
+	for _, hook := range r.hooks {
+		if err := hook.OrderChanged(ctx, entry, order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"curso-go-clean-arch/internal/cache"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// CachedOrderRepository is a read-through cache in front of another OrderRepository.
+// GetByID and List are served from the store, whose entries an OrderCacheInvalidator
+// drops as orders change. Reads inside a transaction bypass the cache so
+// uncommitted rows are never cached.
+type CachedOrderRepository struct {
+	repository.OrderRepository
+	store    cache.Store
+	ttl      time.Duration
+	observer cache.Observer
+}
+
+// NewCachedOrderRepository wraps next with a cache kept in store for ttl
+func NewCachedOrderRepository(next repository.OrderRepository, store cache.Store, ttl time.Duration, observer cache.Observer) *CachedOrderRepository {
+	return &CachedOrderRepository{
+		OrderRepository: next,
+		store:           store,
+		ttl:             ttl,
+		observer:        observer,
+	}
+}
+
+// GetByID returns the cached order or loads and caches it
+func (r *CachedOrderRepository) GetByID(ctx context.Context, id string) (*entity.Order, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	orderID, err := uuid.Parse(id)
+	if !ok || err != nil || inTransaction(ctx) {
+		return r.OrderRepository.GetByID(ctx, id)
+	}
+
+	key := orderCacheKey(tenantID, orderID.String())
+	var order *entity.Order
+	if r.get(ctx, "GetByID", key, &order) {
+		return order, nil
+	}
+
+	order, err = r.OrderRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.set(ctx, key, order)
+	return order, nil
+}
+
+// List returns the cached orders of the tenant or loads and caches them.
+// Listings including soft deleted orders are not cached.
+func (r *CachedOrderRepository) List(ctx context.Context, options repository.ListOptions) ([]*entity.Order, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok || options.IncludeDeleted || inTransaction(ctx) {
+		return r.OrderRepository.List(ctx, options)
+	}
+
+	key := listCacheKey(tenantID)
+	var orders []*entity.Order
+	if r.get(ctx, "List", key, &orders) {
+		return orders, nil
+	}
+
+	orders, err := r.OrderRepository.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	r.set(ctx, key, orders)
+	return orders, nil
+}
+
+// CreateMany saves the orders in bulk when supported
+func (r *CachedOrderRepository) CreateMany(ctx context.Context, orders []*entity.Order) error {
+	if bulk, ok := r.OrderRepository.(repository.OrderBulkCreator); ok {
+		return bulk.CreateMany(ctx, orders)
+	}
+	for _, order := range orders {
+		if err := r.OrderRepository.Create(ctx, order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get loads key into v, reporting a hit. Store errors count as misses.
+func (r *CachedOrderRepository) get(ctx context.Context, operation, key string, v any) bool {
+	data, found, err := r.store.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "Cache lookup failed", "key", key, "error", err)
+	}
+
+	hit := found && err == nil && json.Unmarshal(data, v) == nil
+	if r.observer != nil {
+		r.observer.ObserveCache(operation, hit)
+	}
+	return hit
+}
+
+// set stores v under key, logging store errors
+func (r *CachedOrderRepository) set(ctx context.Context, key string, v any) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = r.store.Set(ctx, key, data, r.ttl)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Cache store failed", "key", key, "error", err)
+	}
+}
+
+// OrderCacheInvalidator drops the cached entries of every recorded order
+// change once its transaction commits. Registered as an OrderChangeHook of the
+// AuditedOrderRepository a CachedOrderRepository wraps, it sees the changes of
+// every path, and invalidating after commit keeps a concurrent read from caching
+// the rows the change replaces.
+type OrderCacheInvalidator struct {
+	store              cache.Store
+	transactionManager repository.TransactionManager
+}
+
+// NewOrderCacheInvalidator creates an invalidator of the entries kept in store
+func NewOrderCacheInvalidator(store cache.Store, transactionManager repository.TransactionManager) *OrderCacheInvalidator {
+	return &OrderCacheInvalidator{
+		store:              store,
+		transactionManager: transactionManager,
+	}
+}
+
+// OrderChanged schedules the invalidation of the changed order after commit
+func (i *OrderCacheInvalidator) OrderChanged(ctx context.Context, entry *entity.OrderHistoryEntry, order *entity.Order) error {
+	i.transactionManager.AfterCommit(ctx, func(ctx context.Context) {
+		i.Invalidate(context.WithoutCancel(ctx), entry.TenantID, entry.OrderID.String())
+	})
+	return nil
+}
+
+// Invalidate drops the cached order and list of a tenant. An empty orderID only
+// drops the list.
+func (i *OrderCacheInvalidator) Invalidate(ctx context.Context, tenantID, orderID string) {
+	keys := []string{listCacheKey(tenantID)}
+	if orderID != "" {
+		keys = append(keys, orderCacheKey(tenantID, orderID))
+	}
+
+	if err := i.store.Delete(ctx, keys...); err != nil {
+		slog.WarnContext(ctx, "Cache invalidation failed", "keys", keys, "error", err)
+	}
+}
+
+// orderCacheKey is the key of a single order of a tenant
+func orderCacheKey(tenantID, orderID string) string {
+	return "orders:" + tenantID + ":id:" + orderID
+}
+
+// listCacheKey is the key of the order list of a tenant
+func listCacheKey(tenantID string) string {
+	return "orders:" + tenantID + ":list"
+}
+
+// inTransaction reports whether ctx carries a transaction started by a TransactionManager
+func inTransaction(ctx context.Context) bool {
+	if _, ok := sqlTxFromContext(ctx); ok {
+		return true
+	}
+	_, ok := pgxTxFromContext(ctx)
+	return ok
+}
@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"curso-go-clean-arch/internal/cache"
+	"curso-go-clean-arch/internal/database"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+)
+
+// recordingStore is a cache store recording the keys deleted from it
+type recordingStore struct {
+	cache.Store
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (s *recordingStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	s.deleted = append(s.deleted, keys...)
+	s.mu.Unlock()
+	return s.Store.Delete(ctx, keys...)
+}
+
+// takeDeleted returns and forgets the keys deleted so far
+func (s *recordingStore) takeDeleted() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := s.deleted
+	s.deleted = nil
+	return deleted
+}
+
+// cachedFixture is the decorator chain of the container with the cache enabled,
+// over SQLite
+type cachedFixture struct {
+	repo  *CachedOrderRepository
+	tm    repository.TransactionManager
+	store *recordingStore
+}
+
+// newCachedFixture builds base, optionally event-sourced, then audited with an
+// OrderCacheInvalidator hook, then cached
+func newCachedFixture(t *testing.T, eventSourced bool) *cachedFixture {
+	t.Helper()
+
+	config := database.DefaultConfig()
+	config.Driver = database.DriverSQLite
+	config.SQLitePath = filepath.Join(t.TempDir(), "orders.db")
+
+	db, err := database.ConnectSQLite(&config)
+	if err != nil {
+		t.Fatalf("connecting to SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	tm := NewSQLTransactionManager(db, false)
+	var base repository.OrderRepository = NewSQLiteOrderRepository(db)
+	if eventSourced {
+		if base, err = NewEventSourcedOrderRepository(base, NewSQLiteOrderEventStore(db), tm, 3); err != nil {
+			t.Fatalf("creating event-sourced repository: %v", err)
+		}
+	}
+
+	store := &recordingStore{Store: cache.NewMemoryStore(100)}
+	audited := NewAuditedOrderRepository(base, NewSQLiteOrderHistoryRepository(db), tm, NewOrderCacheInvalidator(store, tm))
+
+	return &cachedFixture{
+		repo:  NewCachedOrderRepository(audited, store, time.Hour, nil),
+		tm:    tm,
+		store: store,
+	}
+}
+
+// warm caches the order, when given, and the order list of the tenant of ctx
+func (f *cachedFixture) warm(t *testing.T, ctx context.Context, order *entity.Order) {
+	t.Helper()
+
+	if order != nil {
+		if _, err := f.repo.GetByID(ctx, order.ID.String()); err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+	}
+	if _, err := f.repo.List(ctx, repository.ListOptions{}); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	f.store.takeDeleted()
+}
+
+// listed returns the descriptions of the orders List returns
+func (f *cachedFixture) listed(t *testing.T, ctx context.Context) []string {
+	t.Helper()
+
+	orders, err := f.repo.List(ctx, repository.ListOptions{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	descriptions := make([]string, 0, len(orders))
+	for _, order := range orders {
+		descriptions = append(descriptions, order.Description)
+	}
+	slices.Sort(descriptions)
+	return descriptions
+}
+
+// assertInvalidated fails unless the cached list and the given orders of the
+// tenant of ctx were dropped
+func (f *cachedFixture) assertInvalidated(t *testing.T, ctx context.Context, orders ...*entity.Order) {
+	t.Helper()
+
+	tenantID, _ := tenant.FromContext(ctx)
+	deleted := f.store.takeDeleted()
+	want := []string{listCacheKey(tenantID)}
+	for _, order := range orders {
+		want = append(want, orderCacheKey(tenantID, order.ID.String()))
+	}
+	for _, key := range want {
+		if !slices.Contains(deleted, key) {
+			t.Errorf("cache key %s was not invalidated, deleted %q", key, deleted)
+		}
+	}
+}
+
+// TestCachedOrderRepositoryInvalidation checks that every write path drops the
+// cached entries it makes stale, with and without event sourcing
+func TestCachedOrderRepositoryInvalidation(t *testing.T) {
+	for _, eventSourced := range []bool{false, true} {
+		name := "projection"
+		if eventSourced {
+			name = "event sourced"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			t.Run("Create", func(t *testing.T) {
+				f := newCachedFixture(t, eventSourced)
+				ctx := newTenant()
+				f.warm(t, ctx, nil)
+
+				order := entity.NewOrder("created")
+				if err := f.repo.Create(ctx, order); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+
+				f.assertInvalidated(t, ctx, order)
+				if got := f.listed(t, ctx); !slices.Equal(got, []string{"created"}) {
+					t.Errorf("List = %q after Create, want the new order", got)
+				}
+			})
+
+			t.Run("CreateMany", func(t *testing.T) {
+				f := newCachedFixture(t, eventSourced)
+				ctx := newTenant()
+				f.warm(t, ctx, nil)
+
+				orders := []*entity.Order{entity.NewOrder("bulk a"), entity.NewOrder("bulk b")}
+				if err := f.repo.CreateMany(ctx, orders); err != nil {
+					t.Fatalf("CreateMany: %v", err)
+				}
+
+				f.assertInvalidated(t, ctx, orders...)
+				if got := f.listed(t, ctx); !slices.Equal(got, []string{"bulk a", "bulk b"}) {
+					t.Errorf("List = %q after CreateMany, want the new orders", got)
+				}
+			})
+
+			t.Run("Update", func(t *testing.T) {
+				f := newCachedFixture(t, eventSourced)
+				ctx := newTenant()
+				order := createOrder(t, ctx, f.repo, "before")
+				f.warm(t, ctx, order)
+
+				updated := *order
+				updated.UpdateDescription("after")
+				if err := f.repo.Update(ctx, &updated); err != nil {
+					t.Fatalf("Update: %v", err)
+				}
+
+				f.assertInvalidated(t, ctx, order)
+				got, err := f.repo.GetByID(ctx, order.ID.String())
+				if err != nil {
+					t.Fatalf("GetByID: %v", err)
+				}
+				if got.Description != "after" {
+					t.Errorf("GetByID description = %q after Update, want after", got.Description)
+				}
+				if listed := f.listed(t, ctx); !slices.Equal(listed, []string{"after"}) {
+					t.Errorf("List = %q after Update, want the new description", listed)
+				}
+			})
+
+			t.Run("Delete", func(t *testing.T) {
+				f := newCachedFixture(t, eventSourced)
+				ctx := newTenant()
+				order := createOrder(t, ctx, f.repo, "deleted")
+				f.warm(t, ctx, order)
+
+				if err := f.repo.Delete(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+
+				f.assertInvalidated(t, ctx, order)
+				if _, err := f.repo.GetByID(ctx, order.ID.String()); !errors.Is(err, repository.ErrOrderNotFound) {
+					t.Errorf("GetByID error = %v after Delete, want %v", err, repository.ErrOrderNotFound)
+				}
+				if got := f.listed(t, ctx); len(got) != 0 {
+					t.Errorf("List = %q after Delete, want none", got)
+				}
+			})
+
+			t.Run("Restore", func(t *testing.T) {
+				f := newCachedFixture(t, eventSourced)
+				ctx := newTenant()
+				order := createOrder(t, ctx, f.repo, "restored")
+				if err := f.repo.Delete(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				f.warm(t, ctx, nil)
+
+				if err := f.repo.Restore(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Restore: %v", err)
+				}
+
+				f.assertInvalidated(t, ctx, order)
+				if got := f.listed(t, ctx); !slices.Equal(got, []string{"restored"}) {
+					t.Errorf("List = %q after Restore, want the restored order", got)
+				}
+			})
+
+			t.Run("Purge", func(t *testing.T) {
+				f := newCachedFixture(t, eventSourced)
+				ctx := newTenant()
+				order := createOrder(t, ctx, f.repo, "purged")
+				if err := f.repo.Delete(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				f.warm(t, ctx, nil)
+
+				if err := f.repo.Purge(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Purge: %v", err)
+				}
+
+				f.assertInvalidated(t, ctx, order)
+			})
+		})
+	}
+}
+
+// TestCachedOrderRepositoryTransactions checks that changes made in a
+// transaction invalidate the cache only once it commits
+func TestCachedOrderRepositoryTransactions(t *testing.T) {
+	t.Run("Commit", func(t *testing.T) {
+		f := newCachedFixture(t, false)
+		ctx := newTenant()
+		order := createOrder(t, ctx, f.repo, "before")
+		f.warm(t, ctx, order)
+
+		err := f.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+			updated := *order
+			updated.UpdateDescription("after")
+			if err := f.repo.Update(txCtx, &updated); err != nil {
+				return err
+			}
+
+			// Reads in the transaction see its changes, bypassing the cache
+			got, err := f.repo.GetByID(txCtx, order.ID.String())
+			if err != nil {
+				return err
+			}
+			if got.Description != "after" {
+				t.Errorf("GetByID in the transaction = %q, want after", got.Description)
+			}
+			if deleted := f.store.takeDeleted(); len(deleted) > 0 {
+				t.Errorf("cache keys %q invalidated before the commit", deleted)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithinTransaction: %v", err)
+		}
+
+		f.assertInvalidated(t, ctx, order)
+		got, err := f.repo.GetByID(ctx, order.ID.String())
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.Description != "after" {
+			t.Errorf("GetByID description = %q after the commit, want after", got.Description)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		f := newCachedFixture(t, false)
+		ctx := newTenant()
+		order := createOrder(t, ctx, f.repo, "kept")
+		f.warm(t, ctx, order)
+
+		err := f.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+			if err := f.repo.Delete(txCtx, order.ID.String()); err != nil {
+				return err
+			}
+			return errUnitOfWork
+		})
+		if !errors.Is(err, errUnitOfWork) {
+			t.Fatalf("WithinTransaction error = %v, want %v", err, errUnitOfWork)
+		}
+
+		if deleted := f.store.takeDeleted(); len(deleted) > 0 {
+			t.Errorf("cache keys %q invalidated by a rolled back change", deleted)
+		}
+		if got := f.listed(t, ctx); !slices.Equal(got, []string{"kept"}) {
+			t.Errorf("List = %q after the rollback, want the order kept", got)
+		}
+	})
+}
+
+// createOrder creates an order through repo
+func createOrder(t *testing.T, ctx context.Context, repo repository.OrderRepository, description string) *entity.Order {
+	t.Helper()
+
+	order := entity.NewOrder(description)
+	if err := repo.Create(ctx, order); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return order
+}
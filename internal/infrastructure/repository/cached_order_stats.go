@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"curso-go-clean-arch/internal/cache"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+)
+
+// CachedOrderStats caches the Stats of another OrderQueryService for a short
+// TTL. Writes do not invalidate it, reports may lag the orders by up to ttl.
+type CachedOrderStats struct {
+	repository.OrderQueryService
+	store    cache.Store
+	ttl      time.Duration
+	observer cache.Observer
+}
+
+// NewCachedOrderStats wraps next with a cache of its Stats kept in store for ttl
+func NewCachedOrderStats(next repository.OrderQueryService, store cache.Store, ttl time.Duration, observer cache.Observer) *CachedOrderStats {
+	return &CachedOrderStats{
+		OrderQueryService: next,
+		store:             store,
+		ttl:               ttl,
+		observer:          observer,
+	}
+}
+
+// Stats returns the cached statistics of the tenant or computes and caches them
+func (r *CachedOrderStats) Stats(ctx context.Context, query entity.OrderStatsQuery) ([]*entity.OrderStatsRow, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok || inTransaction(ctx) {
+		return r.OrderQueryService.Stats(ctx, query)
+	}
+
+	key := statsCacheKey(tenantID, query)
+	if data, found, err := r.store.Get(ctx, key); err != nil {
+		slog.WarnContext(ctx, "Cache lookup failed", "key", key, "error", err)
+	} else if found {
+		var rows []*entity.OrderStatsRow
+		if err := json.Unmarshal(data, &rows); err == nil {
+			r.observe(true)
+			// JSON keeps the offset of the buckets but not their zone
+			for _, row := range rows {
+				row.Bucket = row.Bucket.In(query.Location)
+			}
+			return rows, nil
+		}
+	}
+	r.observe(false)
+
+	rows, err := r.OrderQueryService.Stats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(rows)
+	if err == nil {
+		err = r.store.Set(ctx, key, data, r.ttl)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Cache store failed", "key", key, "error", err)
+	}
+	return rows, nil
+}
+
+// observe reports a lookup to the observer, when there is one
+func (r *CachedOrderStats) observe(hit bool) {
+	if r.observer != nil {
+		r.observer.ObserveCache("Stats", hit)
+	}
+}
+
+// statsCacheKey is the key of the statistics of a tenant for query
+func statsCacheKey(tenantID string, query entity.OrderStatsQuery) string {
+	return fmt.Sprintf("orders:%s:stats:%s:%s:%d:%d:%t", tenantID, query.GroupBy, query.Location,
+		query.From.UnixNano(), query.To.UnixNano(), query.IncludeDeleted)
+}
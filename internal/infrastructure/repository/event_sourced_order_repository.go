@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// EventSourcedOrderRepository stores every order change as an event appended to
+// the stream of the order, and keeps the orders table as a projection of the
+// streams that serves every read. The state of an order is rehydrated from its
+// latest snapshot and the events after it; each change is validated against
+// it, appended and projected in the same transaction.
+//
+// Orders created before event sourcing was enabled have no stream, their first
+// change imports them with an order.imported event holding their stored state.
+type EventSourcedOrderRepository struct {
+	// OrderRepository is the read model, also the source of imported orders
+	repository.OrderRepository
+	store              repository.OrderEventStore
+	projection         repository.OrderProjection
+	transactionManager repository.TransactionManager
+	snapshotInterval   int64
+}
+
+// NewEventSourcedOrderRepository creates an event-sourced repository projecting
+// into readModel, which must also implement OrderProjection. A snapshot is saved
+// every snapshotInterval events of an order.
+func NewEventSourcedOrderRepository(readModel repository.OrderRepository, store repository.OrderEventStore, transactionManager repository.TransactionManager, snapshotInterval int) (*EventSourcedOrderRepository, error) {
+	projection, ok := readModel.(repository.OrderProjection)
+	if !ok {
+		return nil, fmt.Errorf("order repository %T cannot be used as a projection", readModel)
+	}
+	if snapshotInterval < 1 {
+		return nil, fmt.Errorf("snapshot interval must be at least 1, got %d", snapshotInterval)
+	}
+
+	return &EventSourcedOrderRepository{
+		OrderRepository:    readModel,
+		store:              store,
+		projection:         projection,
+		transactionManager: transactionManager,
+		snapshotInterval:   int64(snapshotInterval),
+	}, nil
+}
+
+// Create starts the stream of a new order with an order.created event
+func (r *EventSourcedOrderRepository) Create(ctx context.Context, order *entity.Order) error {
+	return r.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if order.IdempotencyKey != "" {
+			_, err := r.OrderRepository.GetByIdempotencyKey(ctx, order.IdempotencyKey)
+			if err == nil {
+				return repository.ErrDuplicateIdempotencyKey
+			}
+			if !errors.Is(err, repository.ErrOrderNotFound) {
+				return err
+			}
+		}
+
+		aggregate, err := r.load(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if aggregate.Order != nil || aggregate.Version > 0 {
+			return repository.ErrOrderConcurrentModification
+		}
+
+		createdAt, updatedAt := order.CreatedAt, order.UpdatedAt
+		data := entity.OrderEventData{
+			Description:    &order.Description,
+			Items:          eventItems(order.Items),
+			Currency:       order.Currency,
+			Status:         &order.Status,
+			IdempotencyKey: order.IdempotencyKey,
+			CreatedAt:      &createdAt,
+			UpdatedAt:      &updatedAt,
+		}
+		if err := r.record(ctx, aggregate, entity.OrderEventType(entity.OrderHistoryCreated), data, createdAt); err != nil {
+			return err
+		}
+
+		order.TenantID = aggregate.Order.TenantID
+		return nil
+	})
+}
+
+// Update appends an order.updated event, or order.status_changed when the status changed
+func (r *EventSourcedOrderRepository) Update(ctx context.Context, order *entity.Order) error {
+	return r.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		aggregate, err := r.load(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if aggregate.Order == nil || aggregate.Order.DeletedAt != nil {
+			return repository.ErrOrderNotFound
+		}
+
+		eventType := entity.OrderEventType(entity.OrderHistoryUpdated)
+		if aggregate.Order.Status != order.Status {
+			eventType = entity.OrderEventType(entity.OrderHistoryStatusChanged)
+		}
+
+		updatedAt := order.UpdatedAt
+		data := entity.OrderEventData{
+			Description: &order.Description,
+			Items:       eventItems(order.Items),
+			Status:      &order.Status,
+			UpdatedAt:   &updatedAt,
+		}
+		return r.record(ctx, aggregate, eventType, data, updatedAt)
+	})
+}
+
+// Delete appends an order.deleted event
+func (r *EventSourcedOrderRepository) Delete(ctx context.Context, id string) error {
+	return r.change(ctx, id, func(ctx context.Context, aggregate *entity.OrderAggregate) error {
+		if aggregate.Order.DeletedAt != nil {
+			return repository.ErrOrderNotFound
+		}
+
+		now := time.Now()
+		data := entity.OrderEventData{DeletedAt: &now}
+		return r.record(ctx, aggregate, entity.OrderEventType(entity.OrderHistoryDeleted), data, now)
+	})
+}
+
+// Restore appends an order.restored event
+func (r *EventSourcedOrderRepository) Restore(ctx context.Context, id string) error {
+	return r.change(ctx, id, func(ctx context.Context, aggregate *entity.OrderAggregate) error {
+		if aggregate.Order.DeletedAt == nil {
+			return repository.ErrOrderNotFound
+		}
+
+		now := time.Now()
+		data := entity.OrderEventData{UpdatedAt: &now}
+		return r.record(ctx, aggregate, entity.OrderEventType(entity.OrderHistoryRestored), data, now)
+	})
+}
+
+// Purge appends an order.purged event, removing the order from the projection
+// while its stream is kept
+func (r *EventSourcedOrderRepository) Purge(ctx context.Context, id string) error {
+	return r.change(ctx, id, func(ctx context.Context, aggregate *entity.OrderAggregate) error {
+		if aggregate.Order.DeletedAt == nil {
+			return repository.ErrOrderNotFound
+		}
+		return r.record(ctx, aggregate, entity.OrderEventType(entity.OrderHistoryPurged), entity.OrderEventData{}, time.Now())
+	})
+}
+
+// change loads an existing order, importing it when it has no stream, and
+// applies fn to it within a transaction
+func (r *EventSourcedOrderRepository) change(ctx context.Context, id string, fn func(ctx context.Context, aggregate *entity.OrderAggregate) error) error {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+	}
+
+	return r.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		aggregate, err := r.load(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if aggregate.Order == nil {
+			return repository.ErrOrderNotFound
+		}
+		return fn(ctx, aggregate)
+	})
+}
+
+// load rehydrates an order from its latest snapshot and the events after it.
+// An order of the read model without a stream is imported first.
+func (r *EventSourcedOrderRepository) load(ctx context.Context, id uuid.UUID) (*entity.OrderAggregate, error) {
+	snapshot, err := r.store.LatestSnapshot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var afterSequence int64
+	if snapshot != nil {
+		afterSequence = snapshot.Sequence
+	}
+
+	events, err := r.store.Load(ctx, id, afterSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregate, err := entity.RehydrateOrder(id, snapshot, events)
+	if err != nil {
+		return nil, fmt.Errorf("error rehydrating order %s: %w", id, err)
+	}
+	if aggregate.Version > 0 {
+		return aggregate, nil
+	}
+
+	return r.importOrder(ctx, aggregate)
+}
+
+// importOrder starts the stream of an order stored before event sourcing was
+// enabled, leaving the aggregate empty when there is no such order
+func (r *EventSourcedOrderRepository) importOrder(ctx context.Context, aggregate *entity.OrderAggregate) (*entity.OrderAggregate, error) {
+	order, err := r.OrderRepository.GetByIDIncludingDeleted(ctx, aggregate.ID.String())
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return aggregate, nil
+		}
+		return nil, err
+	}
+
+	data := entity.OrderEventData{
+		Description:    &order.Description,
+		Items:          eventItems(order.Items),
+		Currency:       order.Currency,
+		Status:         &order.Status,
+		IdempotencyKey: order.IdempotencyKey,
+		CreatedAt:      &order.CreatedAt,
+		UpdatedAt:      &order.UpdatedAt,
+		DeletedAt:      order.DeletedAt,
+	}
+	event, err := aggregate.Record(order.TenantID, entity.OrderImportedEventType, data, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.Append(ctx, event); err != nil {
+		return nil, err
+	}
+
+	return aggregate, nil
+}
+
+// record appends the next event of the aggregate, updates the projection and
+// saves a snapshot every snapshotInterval events
+func (r *EventSourcedOrderRepository) record(ctx context.Context, aggregate *entity.OrderAggregate, eventType string, data entity.OrderEventData, occurredAt time.Time) error {
+	tenantID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	event, err := aggregate.Record(tenantID, eventType, data, occurredAt)
+	if err != nil {
+		return err
+	}
+	if err := r.store.Append(ctx, event); err != nil {
+		return err
+	}
+
+	if aggregate.Order == nil {
+		if err := r.projection.Remove(ctx, aggregate.ID); err != nil {
+			return err
+		}
+	} else if err := r.projection.Upsert(ctx, aggregate.Order); err != nil {
+		return err
+	}
+
+	if snapshot := aggregate.Snapshot(); snapshot != nil && aggregate.Version%r.snapshotInterval == 0 {
+		return r.store.SaveSnapshot(ctx, snapshot)
+	}
+	return nil
+}
+
+// eventItems returns the items an event sets, an empty list rather than nil
+// so that clearing the items is recorded
+func eventItems(items []entity.OrderItem) *[]entity.OrderItem {
+	if items == nil {
+		items = []entity.OrderItem{}
+	}
+	return &items
+}
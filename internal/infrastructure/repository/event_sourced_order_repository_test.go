@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"curso-go-clean-arch/internal/database"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// recordingEventStore is an event store recording the sequences events are
+// loaded after
+type recordingEventStore struct {
+	repository.OrderEventStore
+	loadedAfter []int64
+}
+
+func (s *recordingEventStore) Load(ctx context.Context, orderID uuid.UUID, afterSequence int64) ([]*entity.OrderEvent, error) {
+	s.loadedAfter = append(s.loadedAfter, afterSequence)
+	return s.OrderEventStore.Load(ctx, orderID, afterSequence)
+}
+
+// eventSourcedFixture is an event-sourced repository over SQLite, with its
+// read model and event store
+type eventSourcedFixture struct {
+	repo      *EventSourcedOrderRepository
+	readModel repository.OrderRepository
+	store     *recordingEventStore
+}
+
+func newEventSourcedFixture(t *testing.T, snapshotInterval int) *eventSourcedFixture {
+	t.Helper()
+
+	config := database.DefaultConfig()
+	config.Driver = database.DriverSQLite
+	config.SQLitePath = filepath.Join(t.TempDir(), "orders.db")
+
+	db, err := database.ConnectSQLite(&config)
+	if err != nil {
+		t.Fatalf("connecting to SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	readModel := NewSQLiteOrderRepository(db)
+	store := &recordingEventStore{OrderEventStore: NewSQLiteOrderEventStore(db)}
+	repo, err := NewEventSourcedOrderRepository(readModel, store, NewSQLTransactionManager(db, false), snapshotInterval)
+	if err != nil {
+		t.Fatalf("creating event-sourced repository: %v", err)
+	}
+
+	return &eventSourcedFixture{repo: repo, readModel: readModel, store: store}
+}
+
+// update changes the description of order n times through the repository
+func (f *eventSourcedFixture) update(t *testing.T, ctx context.Context, order *entity.Order, n int) {
+	t.Helper()
+
+	for i := range n {
+		order.UpdateDescription(fmt.Sprintf("update %d", i+1))
+		if err := f.repo.Update(ctx, order); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+}
+
+// eventTypes returns the types of the stream of the order, oldest first
+func (f *eventSourcedFixture) eventTypes(t *testing.T, ctx context.Context, id uuid.UUID) []string {
+	t.Helper()
+
+	events, err := f.store.OrderEventStore.Load(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("loading events: %v", err)
+	}
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// snapshotSequence returns the sequence of the latest snapshot of the order, 0 without one
+func (f *eventSourcedFixture) snapshotSequence(t *testing.T, ctx context.Context, id uuid.UUID) int64 {
+	t.Helper()
+
+	snapshot, err := f.store.LatestSnapshot(ctx, id)
+	if err != nil {
+		t.Fatalf("LatestSnapshot: %v", err)
+	}
+	if snapshot == nil {
+		return 0
+	}
+	return snapshot.Sequence
+}
+
+// TestEventSourcedSnapshotInterval checks that record saves a snapshot exactly
+// when the version of an order reaches a multiple of the interval
+func TestEventSourcedSnapshotInterval(t *testing.T) {
+	tests := []struct {
+		name         string
+		interval     int
+		updates      int
+		wantSnapshot int64
+	}{
+		{name: "every event", interval: 1, updates: 2, wantSnapshot: 3},
+		{name: "below the interval", interval: 3, updates: 1, wantSnapshot: 0},
+		{name: "at the interval", interval: 3, updates: 2, wantSnapshot: 3},
+		{name: "past the interval", interval: 3, updates: 3, wantSnapshot: 3},
+		{name: "before the second interval", interval: 3, updates: 4, wantSnapshot: 3},
+		{name: "at the second interval", interval: 3, updates: 5, wantSnapshot: 6},
+		{name: "interval beyond the stream", interval: 100, updates: 5, wantSnapshot: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEventSourcedFixture(t, tt.interval)
+			ctx := newTenant()
+
+			order := entity.NewOrder("created")
+			if err := f.repo.Create(ctx, order); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			f.update(t, ctx, order, tt.updates)
+
+			if got := f.snapshotSequence(t, ctx, order.ID); got != tt.wantSnapshot {
+				t.Errorf("snapshot at sequence %d after %d events, want %d", got, tt.updates+1, tt.wantSnapshot)
+			}
+		})
+	}
+}
+
+// TestEventSourcedRehydration checks that load replays only the events after
+// the latest snapshot and ends up with the state of the projection
+func TestEventSourcedRehydration(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  int
+		updates   int
+		wantAfter int64
+	}{
+		{name: "without a snapshot", interval: 100, updates: 2, wantAfter: 0},
+		{name: "snapshot without trailing events", interval: 3, updates: 2, wantAfter: 3},
+		{name: "snapshot and trailing events", interval: 3, updates: 4, wantAfter: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEventSourcedFixture(t, tt.interval)
+			ctx := newTenant()
+
+			order := entity.NewOrder("created")
+			if err := f.repo.Create(ctx, order); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			f.update(t, ctx, order, tt.updates)
+
+			f.store.loadedAfter = nil
+			aggregate, err := f.repo.load(ctx, order.ID)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			if !slices.Equal(f.store.loadedAfter, []int64{tt.wantAfter}) {
+				t.Errorf("events loaded after %v, want after %d", f.store.loadedAfter, tt.wantAfter)
+			}
+			if want := int64(tt.updates + 1); aggregate.Version != want {
+				t.Errorf("version = %d, want %d", aggregate.Version, want)
+			}
+
+			projected, err := f.readModel.GetByID(ctx, order.ID.String())
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if aggregate.Order.Description != projected.Description || aggregate.Order.Status != projected.Status {
+				t.Errorf("rehydrated %q %s, projection has %q %s",
+					aggregate.Order.Description, aggregate.Order.Status, projected.Description, projected.Status)
+			}
+		})
+	}
+
+	// The state comes from the snapshot: a field no later event sets keeps
+	// the value of the snapshot, not of the order.created event
+	t.Run("state of the snapshot", func(t *testing.T) {
+		f := newEventSourcedFixture(t, 100)
+		ctx := newTenant()
+
+		order := entity.NewOrder("created")
+		if err := f.repo.Create(ctx, order); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		f.update(t, ctx, order, 3)
+
+		aggregate, err := f.repo.load(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		state := *aggregate.Order
+		state.Description = "from the snapshot"
+		state.IdempotencyKey = "from-the-snapshot"
+		if err := f.store.SaveSnapshot(ctx, &entity.OrderSnapshot{OrderID: order.ID, Sequence: 2, State: &state}); err != nil {
+			t.Fatalf("SaveSnapshot: %v", err)
+		}
+
+		if aggregate, err = f.repo.load(ctx, order.ID); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if aggregate.Order.IdempotencyKey != "from-the-snapshot" {
+			t.Errorf("idempotency key = %q, want the one of the snapshot", aggregate.Order.IdempotencyKey)
+		}
+		if aggregate.Order.Description != "update 3" || aggregate.Version != 4 {
+			t.Errorf("rehydrated %q at version %d, want the trailing events applied up to update 3 at version 4",
+				aggregate.Order.Description, aggregate.Version)
+		}
+	})
+}
+
+// TestEventSourcedImport checks that orders stored before event sourcing get
+// an order.imported event with their state on their first change
+func TestEventSourcedImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		deleted    bool
+		change     func(ctx context.Context, repo *EventSourcedOrderRepository, order *entity.Order) error
+		wantEvents []string
+		wantErr    error
+	}{
+		{
+			name: "update",
+			change: func(ctx context.Context, repo *EventSourcedOrderRepository, order *entity.Order) error {
+				order.UpdateDescription("updated")
+				return repo.Update(ctx, order)
+			},
+			wantEvents: []string{entity.OrderImportedEventType, entity.OrderEventType(entity.OrderHistoryUpdated)},
+		},
+		{
+			name: "status change",
+			change: func(ctx context.Context, repo *EventSourcedOrderRepository, order *entity.Order) error {
+				if err := order.ChangeStatus(entity.OrderStatusShipped); err != nil {
+					return err
+				}
+				return repo.Update(ctx, order)
+			},
+			wantEvents: []string{entity.OrderImportedEventType, entity.OrderEventType(entity.OrderHistoryStatusChanged)},
+		},
+		{
+			name: "delete",
+			change: func(ctx context.Context, repo *EventSourcedOrderRepository, order *entity.Order) error {
+				return repo.Delete(ctx, order.ID.String())
+			},
+			wantEvents: []string{entity.OrderImportedEventType, entity.OrderEventType(entity.OrderHistoryDeleted)},
+		},
+		{
+			name:    "restore of a deleted order",
+			deleted: true,
+			change: func(ctx context.Context, repo *EventSourcedOrderRepository, order *entity.Order) error {
+				return repo.Restore(ctx, order.ID.String())
+			},
+			wantEvents: []string{entity.OrderImportedEventType, entity.OrderEventType(entity.OrderHistoryRestored)},
+		},
+		{
+			name:    "update of a deleted order",
+			deleted: true,
+			change: func(ctx context.Context, repo *EventSourcedOrderRepository, order *entity.Order) error {
+				order.UpdateDescription("updated")
+				return repo.Update(ctx, order)
+			},
+			wantEvents: nil,
+			wantErr:    repository.ErrOrderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEventSourcedFixture(t, 100)
+			ctx := newTenant()
+
+			// Stored by the read model alone, as before event sourcing
+			order := entity.NewOrder("stored before")
+			order.IdempotencyKey = "key-" + order.ID.String()
+			if err := f.readModel.Create(ctx, order); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if tt.deleted {
+				if err := f.readModel.Delete(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+			}
+
+			err := tt.change(ctx, f.repo, order)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("change error = %v, want %v", err, tt.wantErr)
+			}
+
+			// A failed change rolls back the import with it
+			if got := f.eventTypes(t, ctx, order.ID); !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("events = %q, want %q", got, tt.wantEvents)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			events, err := f.store.OrderEventStore.Load(ctx, order.ID, 0)
+			if err != nil {
+				t.Fatalf("loading events: %v", err)
+			}
+			imported := events[0].Data
+			if *imported.Description != "stored before" || imported.IdempotencyKey != order.IdempotencyKey {
+				t.Errorf("imported %q with key %q, want the stored order", *imported.Description, imported.IdempotencyKey)
+			}
+			if (imported.DeletedAt != nil) != tt.deleted {
+				t.Errorf("imported deleted_at = %v, want it set: %t", imported.DeletedAt, tt.deleted)
+			}
+		})
+	}
+}
+
+// TestEventSourcedConcurrentCreate checks that Create refuses an order whose
+// stream already exists, even once purged, or that the read model already has
+func TestEventSourcedConcurrentCreate(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, ctx context.Context, f *eventSourcedFixture, order *entity.Order)
+	}{
+		{
+			name: "existing stream",
+			setup: func(t *testing.T, ctx context.Context, f *eventSourcedFixture, order *entity.Order) {
+				if err := f.repo.Create(ctx, order); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+			},
+		},
+		{
+			name: "purged stream",
+			setup: func(t *testing.T, ctx context.Context, f *eventSourcedFixture, order *entity.Order) {
+				if err := f.repo.Create(ctx, order); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if err := f.repo.Delete(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				if err := f.repo.Purge(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Purge: %v", err)
+				}
+			},
+		},
+		{
+			name: "order stored before event sourcing",
+			setup: func(t *testing.T, ctx context.Context, f *eventSourcedFixture, order *entity.Order) {
+				if err := f.readModel.Create(ctx, order); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEventSourcedFixture(t, 100)
+			ctx := newTenant()
+			order := entity.NewOrder("first")
+			tt.setup(t, ctx, f, order)
+			before := f.eventTypes(t, ctx, order.ID)
+
+			again := *order
+			again.Description = "second"
+			if err := f.repo.Create(ctx, &again); !errors.Is(err, repository.ErrOrderConcurrentModification) {
+				t.Errorf("Create error = %v, want %v", err, repository.ErrOrderConcurrentModification)
+			}
+			if got := f.eventTypes(t, ctx, order.ID); !slices.Equal(got, before) {
+				t.Errorf("events = %q after the refused create, want %q", got, before)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"curso-go-clean-arch/internal/domain/entity"
+)
+
+// snippetWords is how many words of a long search text a snippet keeps
+const snippetWords = 35
+
+// searchInMemory is the full-text search of the backends without a text index.
+// It scans summaries, keeping those matching every term of query, ranks them by
+// the share of their words matched and highlights the matches like ts_headline.
+func searchInMemory(summaries []*entity.OrderSummary, query entity.SearchQuery, limit int) []*entity.OrderSearchResult {
+	var results []*entity.OrderSearchResult
+	for _, summary := range summaries {
+		text := summary.SearchText()
+		words := tokenize(text)
+
+		var matches []wordSpan
+		matchesAll := true
+		for _, term := range query.Terms {
+			found := matchTerm(words, term)
+			if len(found) == 0 {
+				matchesAll = false
+				break
+			}
+			matches = append(matches, found...)
+		}
+		if !matchesAll {
+			continue
+		}
+
+		results = append(results, &entity.OrderSearchResult{
+			Summary: summary,
+			Rank:    float64(len(matches)) / float64(len(words)),
+			Snippet: highlight(text, words, matches),
+		})
+	}
+
+	// Summaries come newest first, which breaks ties like the SQL search
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// wordSpan is a word of a text, or a run of words, with its byte offsets
+type wordSpan struct {
+	word       string
+	start, end int
+	// first and last are the indexes of the words a match spans
+	first, last int
+}
+
+// tokenize returns the lowercase words of text with their offsets, split like entity.SearchWords
+func tokenize(text string) []wordSpan {
+	var words []wordSpan
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			words = append(words, wordSpan{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, wordSpan{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	for i := range words {
+		words[i].first, words[i].last = i, i
+	}
+	return words
+}
+
+// matchTerm returns the occurrences of term in words
+func matchTerm(words []wordSpan, term entity.SearchTerm) []wordSpan {
+	var found []wordSpan
+	n := len(term.Words)
+	for i := 0; i+n <= len(words); i++ {
+		matches := true
+		for k, want := range term.Words {
+			got := words[i+k].word
+			if got != want && !(term.Prefix && k == n-1 && strings.HasPrefix(got, want)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			found = append(found, wordSpan{start: words[i].start, end: words[i+n-1].end, first: i, last: i + n - 1})
+		}
+	}
+	return found
+}
+
+// highlight wraps the matches of text in <mark></mark>, keeping snippetWords
+// words around the first match when text is longer than that
+func highlight(text string, words []wordSpan, matches []wordSpan) string {
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	from, to := 0, len(text)
+	prefix, suffix := "", ""
+	if len(words) > snippetWords {
+		first := max(matches[0].first-snippetWords/4, 0)
+		last := min(first+snippetWords, len(words)) - 1
+		from, to = words[first].start, words[last].end
+		if first > 0 {
+			prefix = "... "
+		}
+		if last < len(words)-1 {
+			suffix = " ..."
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	pos := from
+	for _, match := range matches {
+		if match.start < pos || match.end > to {
+			// Overlaps the previous match or lies outside the snippet
+			continue
+		}
+		b.WriteString(text[pos:match.start])
+		b.WriteString("<mark>")
+		b.WriteString(text[match.start:match.end])
+		b.WriteString("</mark>")
+		pos = match.end
+	}
+	b.WriteString(text[pos:to])
+	b.WriteString(suffix)
+	return b.String()
+}
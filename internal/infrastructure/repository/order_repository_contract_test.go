@@ -0,0 +1,764 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"curso-go-clean-arch/internal/database"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// postgresDSNEnv names the PostgreSQL database the contract also runs against,
+// its tables must be migrated or migratable from /migrations
+const postgresDSNEnv = "TEST_DATABASE_URL"
+
+// TestOrderRepositoryContract runs the same checks against every OrderRepository
+// implementation, PostgreSQL ones only when postgresDSNEnv is set
+func TestOrderRepositoryContract(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) repository.OrderRepository
+	}{
+		{"sqlite", openSQLiteOrderRepository},
+		{"postgres", openPostgresOrderRepository},
+		{"pgx", openPgxOrderRepository},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			repo := backend.open(t)
+
+			t.Run("CreateListGet", func(t *testing.T) { testCreateListGet(t, repo) })
+			t.Run("TenantIsolation", func(t *testing.T) { testTenantIsolation(t, repo) })
+			t.Run("IdempotencyKey", func(t *testing.T) { testIdempotencyKey(t, repo) })
+			t.Run("SoftDeleteRestorePurge", func(t *testing.T) { testSoftDeleteRestorePurge(t, repo) })
+			t.Run("CreateMany", func(t *testing.T) { testCreateMany(t, repo) })
+		})
+	}
+}
+
+func openSQLiteOrderRepository(t *testing.T) repository.OrderRepository {
+	config := database.DefaultConfig()
+	config.Driver = database.DriverSQLite
+	config.SQLitePath = filepath.Join(t.TempDir(), "orders.db")
+
+	db, err := database.ConnectSQLite(&config)
+	if err != nil {
+		t.Fatalf("connecting to SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewSQLiteOrderRepository(db)
+}
+
+func openPostgresOrderRepository(t *testing.T) repository.OrderRepository {
+	config := postgresConfig(t)
+
+	db, err := database.Connect(&config)
+	if err != nil {
+		t.Fatalf("connecting to PostgreSQL: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := database.MigratePostgres(db, os.DirFS(filepath.Join("..", "..", "..", "migrations")), false); err != nil {
+		t.Fatalf("migrating PostgreSQL: %v", err)
+	}
+
+	return NewPostgresOrderRepository(db, false)
+}
+
+func openPgxOrderRepository(t *testing.T) repository.OrderRepository {
+	// Migrate through database/sql first, the pool prepares statements on the tables
+	openPostgresOrderRepository(t)
+	config := postgresConfig(t)
+
+	pool, err := database.ConnectPool(&config, ConfigurePgxPool)
+	if err != nil {
+		t.Fatalf("connecting to PostgreSQL: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return NewPgxOrderRepository(pool, false)
+}
+
+// postgresConfig returns the configuration of the test database, skipping the
+// test when none is set
+func postgresConfig(t *testing.T) database.Config {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skip(postgresDSNEnv + " is not set")
+	}
+
+	config := database.DefaultConfig()
+	config.URL = dsn
+	config.ConnectAttempts = 1
+	return config
+}
+
+// newTenant returns a context of a tenant no other test uses, so the tests can
+// share a database across runs
+func newTenant() context.Context {
+	return tenant.WithTenant(context.Background(), "contract-"+uuid.NewString()[:8])
+}
+
+// orderIDs returns the IDs of orders
+func orderIDs(orders []*entity.Order) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	return ids
+}
+
+func testCreateListGet(t *testing.T, repo repository.OrderRepository) {
+	ctx := newTenant()
+	tenantID, _ := tenant.FromContext(ctx)
+
+	first := entity.NewOrder("first")
+	first.SetItems([]entity.OrderItem{{Name: "Mouse sem fio", Quantity: 2, UnitPrice: 8990}})
+	first.Currency = "USD"
+	second := entity.NewOrder("second")
+	for _, order := range []*entity.Order{first, second} {
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if first.TenantID != tenantID {
+		t.Errorf("Create set tenant %q, want %q", first.TenantID, tenantID)
+	}
+
+	orders, err := repo.List(ctx, repository.ListOptions{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	ids := orderIDs(orders)
+	if len(ids) != 2 || !slices.Contains(ids, first.ID) || !slices.Contains(ids, second.ID) {
+		t.Errorf("List returned %v, want %s and %s", ids, first.ID, second.ID)
+	}
+
+	got, err := repo.GetByID(ctx, first.ID.String())
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ID != first.ID || got.TenantID != tenantID || got.Description != "first" || got.Status != entity.OrderStatusPending {
+		t.Errorf("GetByID returned %+v, want %+v", got, first)
+	}
+	if !slices.Equal(got.Items, first.Items) {
+		t.Errorf("GetByID returned items %+v, want %+v", got.Items, first.Items)
+	}
+	if got.Currency != "USD" || got.TotalAmount != 17980 {
+		t.Errorf("GetByID returned total %d %s, want 17980 USD", got.TotalAmount, got.Currency)
+	}
+	// The databases store created_at with less precision than time.Now
+	if got.CreatedAt.Sub(first.CreatedAt).Abs() > time.Millisecond {
+		t.Errorf("GetByID created_at %s, want %s", got.CreatedAt, first.CreatedAt)
+	}
+
+	got.UpdateDescription("updated")
+	got.ReplaceItems(nil)
+	if err := got.ChangeStatus(entity.OrderStatusConfirmed); err != nil {
+		t.Fatalf("ChangeStatus: %v", err)
+	}
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = repo.GetByID(ctx, first.ID.String())
+	if err != nil {
+		t.Fatalf("GetByID after Update: %v", err)
+	}
+	if got.Description != "updated" || got.Status != entity.OrderStatusConfirmed {
+		t.Errorf("GetByID after Update returned %q %q, want updated confirmed", got.Description, got.Status)
+	}
+	if len(got.Items) != 0 || got.TotalAmount != 0 {
+		t.Errorf("GetByID after Update returned items %+v totaling %d, want none", got.Items, got.TotalAmount)
+	}
+
+	if _, err := repo.GetByID(ctx, uuid.NewString()); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("GetByID of an unknown order returned %v, want ErrOrderNotFound", err)
+	}
+	if _, err := repo.GetByID(ctx, "not-a-uuid"); !errors.Is(err, repository.ErrInvalidOrderID) {
+		t.Errorf("GetByID of an invalid ID returned %v, want ErrInvalidOrderID", err)
+	}
+	if _, err := repo.List(context.Background(), repository.ListOptions{}); !errors.Is(err, tenant.ErrMissingTenant) {
+		t.Errorf("List without a tenant returned %v, want ErrMissingTenant", err)
+	}
+}
+
+func testTenantIsolation(t *testing.T, repo repository.OrderRepository) {
+	acme, other := newTenant(), newTenant()
+
+	order := entity.NewOrder("acme only")
+	order.IdempotencyKey = "isolation"
+	if err := repo.Create(acme, order); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	orders, err := repo.List(other, repository.ListOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(orders) != 0 {
+		t.Errorf("List of another tenant returned %v", orderIDs(orders))
+	}
+
+	id := order.ID.String()
+	if _, err := repo.GetByID(other, id); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("GetByID from another tenant returned %v, want ErrOrderNotFound", err)
+	}
+	if _, err := repo.GetByIDIncludingDeleted(other, id); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("GetByIDIncludingDeleted from another tenant returned %v, want ErrOrderNotFound", err)
+	}
+	if _, err := repo.GetByIdempotencyKey(other, "isolation"); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("GetByIdempotencyKey from another tenant returned %v, want ErrOrderNotFound", err)
+	}
+
+	stolen := *order
+	stolen.Description = "stolen"
+	if err := repo.Update(other, &stolen); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("Update from another tenant returned %v, want ErrOrderNotFound", err)
+	}
+	if err := repo.Delete(other, id); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("Delete from another tenant returned %v, want ErrOrderNotFound", err)
+	}
+
+	got, err := repo.GetByID(acme, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Description != "acme only" || got.DeletedAt != nil {
+		t.Errorf("another tenant changed the order: %+v", got)
+	}
+}
+
+func testIdempotencyKey(t *testing.T, repo repository.OrderRepository) {
+	ctx := newTenant()
+
+	original := entity.NewOrder("original")
+	original.IdempotencyKey = "key-1"
+	if err := repo.Create(ctx, original); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	retry := entity.NewOrder("retry")
+	retry.IdempotencyKey = "key-1"
+	if err := repo.Create(ctx, retry); !errors.Is(err, repository.ErrDuplicateIdempotencyKey) {
+		t.Fatalf("Create with a used key returned %v, want ErrDuplicateIdempotencyKey", err)
+	}
+
+	got, err := repo.GetByIdempotencyKey(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("GetByIdempotencyKey: %v", err)
+	}
+	if got.ID != original.ID {
+		t.Errorf("GetByIdempotencyKey returned %s, want the original %s", got.ID, original.ID)
+	}
+
+	orders, err := repo.List(ctx, repository.ListOptions{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(orders) != 1 {
+		t.Errorf("List returned %d orders after the duplicate, want 1", len(orders))
+	}
+
+	// Keys are unique per tenant
+	elsewhere := entity.NewOrder("other tenant")
+	elsewhere.IdempotencyKey = "key-1"
+	if err := repo.Create(newTenant(), elsewhere); err != nil {
+		t.Errorf("Create with the key of another tenant: %v", err)
+	}
+
+	// A soft deleted order still holds its key, so a late retry replays it
+	if err := repo.Delete(ctx, original.ID.String()); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	got, err = repo.GetByIdempotencyKey(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("GetByIdempotencyKey of a deleted order: %v", err)
+	}
+	if got.ID != original.ID || got.DeletedAt == nil {
+		t.Errorf("GetByIdempotencyKey of a deleted order returned %+v", got)
+	}
+}
+
+func testSoftDeleteRestorePurge(t *testing.T, repo repository.OrderRepository) {
+	ctx := newTenant()
+	tenantID, _ := tenant.FromContext(ctx)
+
+	order := entity.NewOrder("to delete")
+	kept := entity.NewOrder("kept")
+	for _, o := range []*entity.Order{order, kept} {
+		if err := repo.Create(ctx, o); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	id := order.ID.String()
+
+	if err := repo.Purge(ctx, kept.ID.String()); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("Purge of an order not deleted returned %v, want ErrOrderNotFound", err)
+	}
+	if err := repo.Restore(ctx, kept.ID.String()); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("Restore of an order not deleted returned %v, want ErrOrderNotFound", err)
+	}
+
+	if err := repo.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, id); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("second Delete returned %v, want ErrOrderNotFound", err)
+	}
+	if _, err := repo.GetByID(ctx, id); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("GetByID of a deleted order returned %v, want ErrOrderNotFound", err)
+	}
+	deleted, err := repo.GetByIDIncludingDeleted(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByIDIncludingDeleted: %v", err)
+	}
+	if deleted.DeletedAt == nil {
+		t.Error("GetByIDIncludingDeleted returned an order without deleted_at")
+	}
+
+	orders, err := repo.List(ctx, repository.ListOptions{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if ids := orderIDs(orders); slices.Contains(ids, order.ID) || !slices.Contains(ids, kept.ID) {
+		t.Errorf("List returned %v, want only %s", ids, kept.ID)
+	}
+	orders, err = repo.List(ctx, repository.ListOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("List including deleted: %v", err)
+	}
+	if ids := orderIDs(orders); !slices.Contains(ids, order.ID) || !slices.Contains(ids, kept.ID) {
+		t.Errorf("List including deleted returned %v, want %s and %s", ids, order.ID, kept.ID)
+	}
+
+	if err := repo.Restore(ctx, id); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	restored, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID after Restore: %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Error("Restore left deleted_at set")
+	}
+
+	if err := repo.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete after Restore: %v", err)
+	}
+	expired, err := repo.ListDeletedBefore(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ListDeletedBefore: %v", err)
+	}
+	if !slices.Contains(expired, repository.DeletedOrder{TenantID: tenantID, ID: id}) {
+		t.Errorf("ListDeletedBefore did not return %s of %s", id, tenantID)
+	}
+	notExpired, err := repo.ListDeletedBefore(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ListDeletedBefore: %v", err)
+	}
+	if slices.Contains(notExpired, repository.DeletedOrder{TenantID: tenantID, ID: id}) {
+		t.Errorf("ListDeletedBefore returned %s, deleted after the cutoff", id)
+	}
+
+	if err := repo.Purge(ctx, id); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if _, err := repo.GetByIDIncludingDeleted(ctx, id); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("GetByIDIncludingDeleted of a purged order returned %v, want ErrOrderNotFound", err)
+	}
+	if err := repo.Purge(ctx, id); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("second Purge returned %v, want ErrOrderNotFound", err)
+	}
+}
+
+func testCreateMany(t *testing.T, repo repository.OrderRepository) {
+	ctx := newTenant()
+	tenantID, _ := tenant.FromContext(ctx)
+
+	orders := []*entity.Order{entity.NewOrder("bulk 1"), entity.NewOrder("bulk 2"), entity.NewOrder("bulk 3")}
+	orders[1].IdempotencyKey = "bulk"
+
+	// Repositories without bulk support are filled one order at a time, as the decorators do
+	if bulk, ok := repo.(repository.OrderBulkCreator); ok {
+		if err := bulk.CreateMany(ctx, orders); err != nil {
+			t.Fatalf("CreateMany: %v", err)
+		}
+	} else {
+		for _, order := range orders {
+			if err := repo.Create(ctx, order); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+	}
+
+	listed, err := repo.List(ctx, repository.ListOptions{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	ids := orderIDs(listed)
+	for _, order := range orders {
+		if order.TenantID != tenantID {
+			t.Errorf("order %s has tenant %q, want %q", order.ID, order.TenantID, tenantID)
+		}
+		if !slices.Contains(ids, order.ID) {
+			t.Errorf("List is missing %s", order.ID)
+		}
+	}
+
+	got, err := repo.GetByIdempotencyKey(ctx, "bulk")
+	if err != nil {
+		t.Fatalf("GetByIdempotencyKey: %v", err)
+	}
+	if got.ID != orders[1].ID {
+		t.Errorf("GetByIdempotencyKey returned %s, want %s", got.ID, orders[1].ID)
+	}
+}
+
+// TestListDeletedBefore checks that ListDeletedBefore lists the deleted orders
+// of every tenant, and on PostgreSQL fails with ErrRowLevelSecurity rather than
+// listing nothing when policies apply to the connection
+func TestListDeletedBefore(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) (repository.OrderRepository, repository.TransactionManager)
+		rls  bool
+	}{
+		{"sqlite", openSQLiteTransactionManager, false},
+		{"postgres", openPostgresTransactionManager, true},
+		{"pgx", openPgxTransactionManager, true},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			repo, tm := backend.open(t)
+
+			var want []string
+			for _, ctx := range []context.Context{newTenant(), newTenant()} {
+				order := entity.NewOrder("deleted")
+				if err := repo.Create(ctx, order); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if err := repo.Delete(ctx, order.ID.String()); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				want = append(want, order.ID.String())
+			}
+
+			deleted, err := repo.ListDeletedBefore(context.Background(), time.Now().Add(time.Minute))
+			if err != nil {
+				t.Fatalf("ListDeletedBefore: %v", err)
+			}
+			var got []string
+			for _, order := range deleted {
+				if slices.Contains(want, order.ID) {
+					got = append(got, order.ID)
+				}
+			}
+			if len(got) != len(want) {
+				t.Errorf("listed %q of the orders deleted in two tenants %q", got, want)
+			}
+
+			if !backend.rls {
+				return
+			}
+
+			// Forcing the policies on the table owner is rolled back with the transaction
+			err = tm.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				if err := execInTransaction(ctx, `ALTER TABLE orders FORCE ROW LEVEL SECURITY`); err != nil {
+					t.Fatalf("forcing row level security: %v", err)
+				}
+				if _, err := repo.ListDeletedBefore(ctx, time.Now().Add(time.Minute)); !errors.Is(err, repository.ErrRowLevelSecurity) {
+					t.Errorf("ListDeletedBefore error = %v under row level security, want %v", err, repository.ErrRowLevelSecurity)
+				}
+				return errUnitOfWork
+			})
+			if !errors.Is(err, errUnitOfWork) {
+				t.Fatalf("WithinTransaction error = %v, want %v", err, errUnitOfWork)
+			}
+		})
+	}
+}
+
+// execInTransaction runs query in the transaction of ctx
+func execInTransaction(ctx context.Context, query string) error {
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		_, err := tx.ExecContext(ctx, query)
+		return err
+	}
+	if tx, ok := pgxTxFromContext(ctx); ok {
+		_, err := tx.Exec(ctx, query)
+		return err
+	}
+	return errors.New("no transaction in the context")
+}
+
+// TestOrderHistoryListByOrderIDs checks that the batched history lookup returns
+// the entries of the given orders of the tenant only, oldest first
+func TestOrderHistoryListByOrderIDs(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) repository.OrderHistoryRepository
+	}{
+		{"sqlite", func(t *testing.T) repository.OrderHistoryRepository {
+			config := database.DefaultConfig()
+			config.Driver = database.DriverSQLite
+			config.SQLitePath = filepath.Join(t.TempDir(), "orders.db")
+
+			db, err := database.ConnectSQLite(&config)
+			if err != nil {
+				t.Fatalf("connecting to SQLite: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return NewSQLiteOrderHistoryRepository(db)
+		}},
+		{"postgres", func(t *testing.T) repository.OrderHistoryRepository {
+			openPostgresOrderRepository(t)
+			config := postgresConfig(t)
+
+			db, err := database.Connect(&config)
+			if err != nil {
+				t.Fatalf("connecting to PostgreSQL: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return NewPostgresOrderHistoryRepository(db, false)
+		}},
+		{"pgx", func(t *testing.T) repository.OrderHistoryRepository {
+			openPostgresOrderRepository(t)
+			config := postgresConfig(t)
+
+			pool, err := database.ConnectPool(&config, ConfigurePgxPool)
+			if err != nil {
+				t.Fatalf("connecting to PostgreSQL: %v", err)
+			}
+			t.Cleanup(pool.Close)
+			return NewPgxOrderHistoryRepository(pool, false)
+		}},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			history := backend.open(t)
+			ctx, otherCtx := newTenant(), newTenant()
+
+			// Two orders of the tenant with two entries each, and one of another tenant
+			first, second, other := uuid.New(), uuid.New(), uuid.New()
+			start := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+			appends := []struct {
+				ctx     context.Context
+				orderID uuid.UUID
+				action  entity.OrderHistoryAction
+			}{
+				{ctx, first, entity.OrderHistoryCreated},
+				{ctx, second, entity.OrderHistoryCreated},
+				{otherCtx, other, entity.OrderHistoryCreated},
+				{ctx, second, entity.OrderHistoryDeleted},
+				{ctx, first, entity.OrderHistoryUpdated},
+			}
+			for i, a := range appends {
+				entry := &entity.OrderHistoryEntry{
+					ID:        uuid.New(),
+					OrderID:   a.orderID,
+					Action:    a.action,
+					Actor:     "test",
+					CreatedAt: start.Add(time.Duration(i) * time.Second),
+				}
+				if err := history.Append(a.ctx, entry); err != nil {
+					t.Fatalf("Append: %v", err)
+				}
+			}
+
+			entries, err := history.ListByOrderIDs(ctx, []string{first.String(), second.String(), other.String(), first.String()})
+			if err != nil {
+				t.Fatalf("ListByOrderIDs: %v", err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.OrderID.String()+":"+string(entry.Action))
+			}
+			want := []string{
+				first.String() + ":created", second.String() + ":created",
+				second.String() + ":deleted", first.String() + ":updated",
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("entries = %q, want %q", got, want)
+			}
+
+			if entries, err := history.ListByOrderIDs(ctx, nil); err != nil || len(entries) != 0 {
+				t.Errorf("ListByOrderIDs(nil) = %d entries, %v, want none", len(entries), err)
+			}
+			if _, err := history.ListByOrderIDs(ctx, []string{first.String(), "not-a-uuid"}); !errors.Is(err, repository.ErrInvalidOrderID) {
+				t.Errorf("ListByOrderIDs error = %v for an invalid ID, want %v", err, repository.ErrInvalidOrderID)
+			}
+		})
+	}
+}
+
+// orderSummaryBackends opens the OrderSummaryRepository implementations,
+// PostgreSQL ones only when postgresDSNEnv is set
+var orderSummaryBackends = []struct {
+	name string
+	open func(t *testing.T) repository.OrderSummaryRepository
+}{
+	{"sqlite", func(t *testing.T) repository.OrderSummaryRepository {
+		config := database.DefaultConfig()
+		config.Driver = database.DriverSQLite
+		config.SQLitePath = filepath.Join(t.TempDir(), "orders.db")
+
+		db, err := database.ConnectSQLite(&config)
+		if err != nil {
+			t.Fatalf("connecting to SQLite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return NewSQLiteOrderSummaryRepository(db)
+	}},
+	{"postgres", func(t *testing.T) repository.OrderSummaryRepository {
+		openPostgresOrderRepository(t)
+		config := postgresConfig(t)
+
+		db, err := database.Connect(&config)
+		if err != nil {
+			t.Fatalf("connecting to PostgreSQL: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return NewPostgresOrderSummaryRepository(db, false)
+	}},
+	{"pgx", func(t *testing.T) repository.OrderSummaryRepository {
+		openPostgresOrderRepository(t)
+		config := postgresConfig(t)
+
+		pool, err := database.ConnectPool(&config, ConfigurePgxPool)
+		if err != nil {
+			t.Fatalf("connecting to PostgreSQL: %v", err)
+		}
+		t.Cleanup(pool.Close)
+		return NewPgxOrderSummaryRepository(pool, false)
+	}},
+}
+
+// saveSummaries saves the summaries of orders created in the tenant of ctx
+func saveSummaries(t *testing.T, ctx context.Context, summaries repository.OrderSummaryRepository, orders ...*entity.Order) {
+	t.Helper()
+	tenantID, _ := tenant.FromContext(ctx)
+	for _, order := range orders {
+		order.TenantID = tenantID
+		summary := &entity.OrderSummary{}
+		summary.Apply(entity.NewOrderHistoryEntry(order.ID, entity.OrderHistoryCreated, nil), order)
+		if err := summaries.Save(ctx, summary); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+}
+
+// TestOrderSummarySearch checks that the search matches the item names of the
+// orders as well as their descriptions, and excerpts them in the snippet
+func TestOrderSummarySearch(t *testing.T) {
+	for _, backend := range orderSummaryBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			summaries := backend.open(t)
+			ctx := newTenant()
+
+			withItem := entity.NewOrder("Pedido da loja")
+			withItem.SetItems([]entity.OrderItem{{Name: "Teclado mecânico", Quantity: 1, UnitPrice: 34900}})
+			withoutItem := entity.NewOrder("Teclado na descrição")
+			other := entity.NewOrder("Pedido sem relação")
+			saveSummaries(t, ctx, summaries, withItem, withoutItem, other)
+
+			query, err := entity.ParseSearchQuery("mecânico")
+			if err != nil {
+				t.Fatalf("ParseSearchQuery: %v", err)
+			}
+			results, err := summaries.Search(ctx, query, repository.SearchOptions{Limit: 10})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(results) != 1 || results[0].Summary.ID != withItem.ID {
+				t.Fatalf("Search(%q) returned %d results, want the order with the item", query.Text, len(results))
+			}
+			if !slices.Equal(results[0].Summary.Items, withItem.Items) {
+				t.Errorf("Search returned items %+v, want %+v", results[0].Summary.Items, withItem.Items)
+			}
+			if want := "<mark>mecânico</mark>"; !strings.Contains(results[0].Snippet, want) {
+				t.Errorf("snippet %q does not contain %q", results[0].Snippet, want)
+			}
+
+			query, err = entity.ParseSearchQuery("teclado")
+			if err != nil {
+				t.Fatalf("ParseSearchQuery: %v", err)
+			}
+			results, err = summaries.Search(ctx, query, repository.SearchOptions{Limit: 10})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			var ids []uuid.UUID
+			for _, result := range results {
+				ids = append(ids, result.Summary.ID)
+			}
+			if len(ids) != 2 || !slices.Contains(ids, withItem.ID) || !slices.Contains(ids, withoutItem.ID) {
+				t.Errorf("Search(%q) returned %v, want %s and %s", query.Text, ids, withItem.ID, withoutItem.ID)
+			}
+		})
+	}
+}
+
+// TestOrderSummaryStats checks that the stats count the orders and sum their
+// totals per period, status and currency
+func TestOrderSummaryStats(t *testing.T) {
+	for _, backend := range orderSummaryBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			summaries := backend.open(t)
+			ctx := newTenant()
+
+			day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+			newOrder := func(createdAt time.Time, currency string, status entity.OrderStatus, unitPrice int64) *entity.Order {
+				order := entity.NewOrder("stats")
+				order.SetItems([]entity.OrderItem{{Name: "Café", Quantity: 2, UnitPrice: unitPrice}})
+				order.Currency = currency
+				order.Status = status
+				order.CreatedAt, order.UpdatedAt = createdAt, createdAt
+				return order
+			}
+			saveSummaries(t, ctx, summaries,
+				newOrder(day.Add(9*time.Hour), "BRL", entity.OrderStatusPending, 1000),
+				newOrder(day.Add(18*time.Hour), "BRL", entity.OrderStatusPending, 250),
+				newOrder(day.Add(20*time.Hour), "USD", entity.OrderStatusPending, 300),
+				newOrder(day.Add(30*time.Hour), "BRL", entity.OrderStatusCancelled, 700),
+			)
+
+			rows, err := summaries.Stats(ctx, entity.OrderStatsQuery{
+				GroupBy:  entity.StatsGroupByDay,
+				From:     day,
+				To:       day.AddDate(0, 0, 2),
+				Location: time.UTC,
+			})
+			if err != nil {
+				t.Fatalf("Stats: %v", err)
+			}
+			var got []string
+			for _, row := range rows {
+				got = append(got, fmt.Sprintf("%s %s %s %d %d", row.Bucket.Format(time.DateOnly), row.Status,
+					row.Currency, row.Count, row.Amount))
+			}
+			want := []string{
+				"2026-03-10 pending BRL 2 2500",
+				"2026-03-10 pending USD 1 600",
+				"2026-03-11 cancelled BRL 1 1400",
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("Stats rows = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgxOrderEventStore implements the OrderEventStore interface using pgx. It
+// shares its SQL with PostgresOrderEventStore.
+type PgxOrderEventStore struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *PgxOrderRepository
+}
+
+// NewPgxOrderEventStore creates a new instance of PgxOrderEventStore
+func NewPgxOrderEventStore(pool *pgxpool.Pool, rowLevelSecurity bool) repository.OrderEventStore {
+	return &PgxOrderEventStore{
+		orders: &PgxOrderRepository{pool: pool, rowLevelSecurity: rowLevelSecurity},
+	}
+}
+
+// Append saves a new event at the end of the stream of its order
+func (s *PgxOrderEventStore) Append(ctx context.Context, event *entity.OrderEvent) error {
+	q, tenantID, release, err := s.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	event.TenantID = tenantID
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("error encoding order event data: %w", err)
+	}
+
+	tag, err := q.Exec(ctx, pgAppendOrderEvent, pgUUID(event.ID), event.TenantID, pgUUID(event.OrderID),
+		event.Sequence, event.Type, string(data), event.OccurredAt)
+	if err != nil {
+		return fmt.Errorf("error appending order event: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrOrderConcurrentModification
+	}
+
+	return nil
+}
+
+// Load retrieves the events of an order after the given sequence, oldest first
+func (s *PgxOrderEventStore) Load(ctx context.Context, orderID uuid.UUID, afterSequence int64) ([]*entity.OrderEvent, error) {
+	q, tenantID, release, err := s.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.Query(ctx, pgLoadOrderEvents, tenantID, pgUUID(orderID), afterSequence)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order events: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPgxOrderEvents(rows)
+}
+
+// SaveSnapshot saves a snapshot of an order
+func (s *PgxOrderEventStore) SaveSnapshot(ctx context.Context, snapshot *entity.OrderSnapshot) error {
+	q, tenantID, release, err := s.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	snapshot.TenantID = tenantID
+
+	state, err := json.Marshal(snapshot.State)
+	if err != nil {
+		return fmt.Errorf("error encoding order snapshot: %w", err)
+	}
+
+	_, err = q.Exec(ctx, pgSaveOrderSnapshot, snapshot.TenantID, pgUUID(snapshot.OrderID), snapshot.Sequence,
+		string(state), snapshot.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving order snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LatestSnapshot retrieves the most recent snapshot of an order, nil when there is none
+func (s *PgxOrderEventStore) LatestSnapshot(ctx context.Context, orderID uuid.UUID) (*entity.OrderSnapshot, error) {
+	q, tenantID, release, err := s.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	snapshot := &entity.OrderSnapshot{}
+	var id pgtype.UUID
+	var state []byte
+
+	err = q.QueryRow(ctx, pgLatestOrderSnapshot, tenantID, pgUUID(orderID)).
+		Scan(&snapshot.TenantID, &id, &snapshot.Sequence, &state, &snapshot.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting order snapshot: %w", err)
+	}
+
+	snapshot.OrderID = uuid.UUID(id.Bytes)
+	if err := json.Unmarshal(state, &snapshot.State); err != nil {
+		return nil, fmt.Errorf("invalid stored order snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// collectPgxOrderEvents scans every event of rows
+func collectPgxOrderEvents(rows pgx.Rows) ([]*entity.OrderEvent, error) {
+	var events []*entity.OrderEvent
+	for rows.Next() {
+		event := &entity.OrderEvent{}
+		var id, orderID pgtype.UUID
+		var data []byte
+
+		err := rows.Scan(&id, &event.TenantID, &orderID, &event.Sequence, &event.Type, &data, &event.OccurredAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order event: %w", err)
+		}
+
+		event.ID = uuid.UUID(id.Bytes)
+		event.OrderID = uuid.UUID(orderID.Bytes)
+		if err := json.Unmarshal(data, &event.Data); err != nil {
+			return nil, fmt.Errorf("invalid stored order event data: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order events: %w", err)
+	}
+
+	return events, nil
+}
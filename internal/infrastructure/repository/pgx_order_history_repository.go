@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgxOrderHistoryRepository implements the OrderHistoryRepository interface using pgx and prepared statements
+type PgxOrderHistoryRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *PgxOrderRepository
+}
+
+// NewPgxOrderHistoryRepository creates a new instance of PgxOrderHistoryRepository. The pool
+// must be configured with ConfigurePgxPool so the statements are prepared.
+func NewPgxOrderHistoryRepository(pool *pgxpool.Pool, rowLevelSecurity bool) repository.OrderHistoryRepository {
+	return &PgxOrderHistoryRepository{
+		orders: &PgxOrderRepository{pool: pool, rowLevelSecurity: rowLevelSecurity},
+	}
+}
+
+// Append saves a new history entry to the database
+func (r *PgxOrderHistoryRepository) Append(ctx context.Context, entry *entity.OrderHistoryEntry) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	entry.TenantID = tenantID
+
+	changes, err := marshalChanges(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(ctx, stmtAppendOrderHistory, pgUUID(entry.ID), entry.TenantID, pgUUID(entry.OrderID),
+		string(entry.Action), entry.Actor, entry.ClaimedActor, pgText(entry.RequestID), changes, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating order history entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListByOrderID retrieves the history of an order, oldest first
+func (r *PgxOrderHistoryRepository) ListByOrderID(ctx context.Context, orderID string) ([]*entity.OrderHistoryEntry, error) {
+	id, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.Query(ctx, stmtListOrderHistory, tenantID, pgUUID(id))
+	if err != nil {
+		return nil, fmt.Errorf("error querying order history: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPgxOrderHistory(rows)
+}
+
+// ListByOrderIDs retrieves the history of several orders, oldest first
+func (r *PgxOrderHistoryRepository) ListByOrderIDs(ctx context.Context, orderIDs []string) ([]*entity.OrderHistoryEntry, error) {
+	ids, err := parseOrderIDs(orderIDs)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	pgIDs := make([]pgtype.UUID, 0, len(ids))
+	for _, id := range ids {
+		pgIDs = append(pgIDs, pgUUID(id))
+	}
+
+	rows, err := q.Query(ctx, stmtListOrdersHistory, tenantID, pgIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order history: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPgxOrderHistory(rows)
+}
+
+// collectPgxOrderHistory scans every history entry of rows
+func collectPgxOrderHistory(rows pgx.Rows) ([]*entity.OrderHistoryEntry, error) {
+	var entries []*entity.OrderHistoryEntry
+	for rows.Next() {
+		entry := &entity.OrderHistoryEntry{}
+		var entryID, entryOrderID pgtype.UUID
+		var action string
+		var requestID pgtype.Text
+		var changes []byte
+
+		err := rows.Scan(&entryID, &entry.TenantID, &entryOrderID, &action, &entry.Actor, &entry.ClaimedActor,
+			&requestID, &changes, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order history entry: %w", err)
+		}
+
+		entry.ID = uuid.UUID(entryID.Bytes)
+		entry.OrderID = uuid.UUID(entryOrderID.Bytes)
+		entry.Action = entity.OrderHistoryAction(action)
+		entry.RequestID = requestID.String
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("invalid stored order history changes: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order history: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,497 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgxQuerier is satisfied by *pgxpool.Pool, *pgxpool.Conn and pgx.Tx
+type pgxQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// Prepared statement names, each connection prepares them when it is opened
+const (
+	stmtCreateOrder              = "orders_create"
+	stmtListOrders               = "orders_list"
+	stmtGetOrderByID             = "orders_get_by_id"
+	stmtGetOrderByIdempotencyKey = "orders_get_by_idempotency_key"
+	stmtUpdateOrder              = "orders_update"
+	stmtDeleteOrder              = "orders_delete"
+	stmtRestoreOrder             = "orders_restore"
+	stmtPurgeOrder               = "orders_purge"
+	stmtListDeletedOrders        = "orders_list_deleted"
+	stmtUpsertOrder              = "orders_upsert"
+	stmtRemoveOrder              = "orders_remove"
+	stmtAppendOrderHistory       = "order_history_append"
+	stmtListOrderHistory         = "order_history_list"
+	stmtListOrdersHistory        = "order_history_list_many"
+)
+
+// pgxStatements maps prepared statement names to their SQL
+var pgxStatements = map[string]string{
+	stmtCreateOrder: `
+		INSERT INTO orders (id, tenant_id, description, items, currency, total_amount, status, idempotency_key,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (tenant_id, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING`,
+	stmtListOrders: `
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
+		FROM orders
+		WHERE tenant_id = $1 AND ($2 OR deleted_at IS NULL)
+		ORDER BY created_at DESC`,
+	stmtGetOrderByID: `
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
+		FROM orders
+		WHERE tenant_id = $1 AND id = $2 AND ($3 OR deleted_at IS NULL)`,
+	stmtGetOrderByIdempotencyKey: `
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
+		FROM orders
+		WHERE tenant_id = $1 AND idempotency_key = $2`,
+	stmtUpdateOrder: `
+		UPDATE orders
+		SET description = $1, items = $2, total_amount = $3, status = $4, updated_at = $5
+		WHERE tenant_id = $6 AND id = $7 AND deleted_at IS NULL`,
+	stmtDeleteOrder: `
+		UPDATE orders
+		SET deleted_at = $1, updated_at = $1
+		WHERE tenant_id = $2 AND id = $3 AND deleted_at IS NULL`,
+	stmtRestoreOrder: `
+		UPDATE orders
+		SET deleted_at = NULL, updated_at = $1
+		WHERE tenant_id = $2 AND id = $3 AND deleted_at IS NOT NULL`,
+	stmtPurgeOrder: `DELETE FROM orders WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NOT NULL`,
+	stmtListDeletedOrders: `
+		SELECT tenant_id, id FROM orders
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at`,
+	stmtUpsertOrder: pgUpsertOrder,
+	stmtRemoveOrder: `DELETE FROM orders WHERE tenant_id = $1 AND id = $2`,
+	stmtAppendOrderHistory: `
+		INSERT INTO order_history (id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+	stmtListOrderHistory: `
+		SELECT id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at
+		FROM order_history
+		WHERE tenant_id = $1 AND order_id = $2
+		ORDER BY created_at, id`,
+	stmtListOrdersHistory: `
+		SELECT id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at
+		FROM order_history
+		WHERE tenant_id = $1 AND order_id = ANY($2::uuid[])
+		ORDER BY created_at, id`,
+}
+
+// orderColumns are the columns written by COPY, in scanPgxOrder order
+var orderColumns = []string{"id", "tenant_id", "description", "items", "currency", "total_amount", "status",
+	"idempotency_key", "created_at", "updated_at"}
+
+// ConfigurePgxPool installs the pgx tracer and prepares the order statements on
+// every new connection. Pass it to database.ConnectPool.
+func ConfigurePgxPool(config *pgxpool.Config) {
+	config.ConnConfig.Tracer = pgxTracer{}
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		for name, sql := range pgxStatements {
+			if _, err := conn.Prepare(ctx, name, sql); err != nil {
+				return fmt.Errorf("error preparing statement %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// PgxOrderRepository implements the OrderRepository interface using pgx and
+// prepared statements, with native UUID and timestamptz encoding
+type PgxOrderRepository struct {
+	pool             *pgxpool.Pool
+	rowLevelSecurity bool
+}
+
+// NewPgxOrderRepository creates a new instance of PgxOrderRepository. The pool
+// must be configured with ConfigurePgxPool so the statements are prepared.
+func NewPgxOrderRepository(pool *pgxpool.Pool, rowLevelSecurity bool) repository.OrderRepository {
+	return &PgxOrderRepository{
+		pool:             pool,
+		rowLevelSecurity: rowLevelSecurity,
+	}
+}
+
+// scope returns the tenant of ctx and a querier bound to it, which is the
+// transaction of ctx when the call runs inside TransactionManager
+func (r *PgxOrderRepository) scope(ctx context.Context) (pgxQuerier, string, func(), error) {
+	tenantID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if tx, ok := pgxTxFromContext(ctx); ok {
+		return tx, tenantID, func() {}, nil
+	}
+
+	if !r.rowLevelSecurity {
+		return r.pool, tenantID, func() {}, nil
+	}
+
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error acquiring connection: %w", err)
+	}
+
+	if _, err := conn.Exec(ctx, `SELECT set_config('app.tenant_id', $1, false)`, tenantID); err != nil {
+		conn.Release()
+		return nil, "", nil, fmt.Errorf("error setting tenant on connection: %w", err)
+	}
+
+	release := func() {
+		// Reset the setting before the connection goes back to the pool
+		conn.Exec(context.Background(), `SELECT set_config('app.tenant_id', '', false)`)
+		conn.Release()
+	}
+
+	return conn, tenantID, release, nil
+}
+
+// Create saves a new order to the database
+func (r *PgxOrderRepository) Create(ctx context.Context, order *entity.Order) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	order.TenantID = tenantID
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
+	tag, err := q.Exec(ctx, stmtCreateOrder, pgUUID(order.ID), order.TenantID, order.Description, items,
+		order.Currency, order.TotalAmount, string(order.Status), pgText(order.IdempotencyKey), order.CreatedAt,
+		order.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating order: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrDuplicateIdempotencyKey
+	}
+
+	return nil
+}
+
+// CreateMany saves orders of the current tenant in bulk with COPY. Unlike Create
+// it does not skip duplicate idempotency keys, the whole copy fails instead.
+func (r *PgxOrderRepository) CreateMany(ctx context.Context, orders []*entity.Order) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	rows := make([][]any, len(orders))
+	for i, order := range orders {
+		order.TenantID = tenantID
+		items, err := marshalItems(order.Items)
+		if err != nil {
+			return err
+		}
+		rows[i] = []any{pgUUID(order.ID), order.TenantID, order.Description, items, order.Currency, order.TotalAmount,
+			string(order.Status), pgText(order.IdempotencyKey), order.CreatedAt, order.UpdatedAt}
+	}
+
+	if _, err := q.CopyFrom(ctx, pgx.Identifier{"orders"}, orderColumns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("error copying orders: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves the orders of the current tenant from the database
+func (r *PgxOrderRepository) List(ctx context.Context, options repository.ListOptions) ([]*entity.Order, error) {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.Query(ctx, stmtListOrders, tenantID, options.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*entity.Order
+	for rows.Next() {
+		order, err := scanPgxOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// GetByID retrieves an order by its ID
+func (r *PgxOrderRepository) GetByID(ctx context.Context, id string) (*entity.Order, error) {
+	return r.getByID(ctx, id, false)
+}
+
+// GetByIDIncludingDeleted retrieves an order by its ID even when it is soft deleted
+func (r *PgxOrderRepository) GetByIDIncludingDeleted(ctx context.Context, id string) (*entity.Order, error) {
+	return r.getByID(ctx, id, true)
+}
+
+// getByID retrieves an order by its ID, soft deleted ones only when includeDeleted is set
+func (r *PgxOrderRepository) getByID(ctx context.Context, id string, includeDeleted bool) (*entity.Order, error) {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+	}
+
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	order, err := scanPgxOrder(q.QueryRow(ctx, stmtGetOrderByID, tenantID, pgUUID(orderID), includeDeleted))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("error getting order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetByIdempotencyKey retrieves an order by the idempotency key it was created with
+func (r *PgxOrderRepository) GetByIdempotencyKey(ctx context.Context, key string) (*entity.Order, error) {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	order, err := scanPgxOrder(q.QueryRow(ctx, stmtGetOrderByIdempotencyKey, tenantID, key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("error getting order: %w", err)
+	}
+
+	return order, nil
+}
+
+// Update updates an existing order in the database
+func (r *PgxOrderRepository) Update(ctx context.Context, order *entity.Order) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
+	tag, err := q.Exec(ctx, stmtUpdateOrder, order.Description, items, order.TotalAmount, string(order.Status),
+		order.UpdatedAt, tenantID, pgUUID(order.ID))
+	if err != nil {
+		return fmt.Errorf("error updating order: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrOrderNotFound
+	}
+
+	return nil
+}
+
+// Delete soft deletes an order by setting deleted_at
+func (r *PgxOrderRepository) Delete(ctx context.Context, id string) error {
+	return r.execByID(ctx, "deleting", stmtDeleteOrder, id, time.Now())
+}
+
+// Restore clears deleted_at of a soft deleted order
+func (r *PgxOrderRepository) Restore(ctx context.Context, id string) error {
+	return r.execByID(ctx, "restoring", stmtRestoreOrder, id, time.Now())
+}
+
+// Purge permanently removes a soft deleted order
+func (r *PgxOrderRepository) Purge(ctx context.Context, id string) error {
+	return r.execByID(ctx, "purging", stmtPurgeOrder, id)
+}
+
+// ListDeletedBefore returns the orders of every tenant soft deleted before the
+// given time. The role must bypass row level security, as for PostgresOrderRepository.
+func (r *PgxOrderRepository) ListDeletedBefore(ctx context.Context, before time.Time) ([]repository.DeletedOrder, error) {
+	var q pgxQuerier = r.pool
+	if tx, ok := pgxTxFromContext(ctx); ok {
+		q = tx
+	}
+
+	var restricted bool
+	if err := q.QueryRow(ctx, pgOrdersRowSecurityActiveQuery).Scan(&restricted); err != nil {
+		return nil, fmt.Errorf("error checking row level security: %w", err)
+	}
+	if restricted {
+		return nil, repository.ErrRowLevelSecurity
+	}
+
+	rows, err := q.Query(ctx, stmtListDeletedOrders, before)
+	if err != nil {
+		return nil, fmt.Errorf("error listing deleted orders: %w", err)
+	}
+	defer rows.Close()
+
+	var deleted []repository.DeletedOrder
+	for rows.Next() {
+		var order repository.DeletedOrder
+		var id uuid.UUID
+		if err := rows.Scan(&order.TenantID, &id); err != nil {
+			return nil, fmt.Errorf("error scanning deleted order: %w", err)
+		}
+		order.ID = id.String()
+		deleted = append(deleted, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing deleted orders: %w", err)
+	}
+	return deleted, nil
+}
+
+// Upsert saves the complete state of an order, so the table serves as the
+// projection of event-sourced orders
+func (r *PgxOrderRepository) Upsert(ctx context.Context, order *entity.Order) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	order.TenantID = tenantID
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
+	var deletedAt pgtype.Timestamptz
+	if order.DeletedAt != nil {
+		deletedAt = pgtype.Timestamptz{Time: *order.DeletedAt, Valid: true}
+	}
+
+	_, err = q.Exec(ctx, stmtUpsertOrder, pgUUID(order.ID), order.TenantID, order.Description, items, order.Currency,
+		order.TotalAmount, string(order.Status), pgText(order.IdempotencyKey), order.CreatedAt, order.UpdatedAt, deletedAt)
+	if err != nil {
+		return fmt.Errorf("error upserting order: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the row of an order whether or not it is soft deleted
+func (r *PgxOrderRepository) Remove(ctx context.Context, id uuid.UUID) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, err := q.Exec(ctx, stmtRemoveOrder, tenantID, pgUUID(id)); err != nil {
+		return fmt.Errorf("error removing order: %w", err)
+	}
+
+	return nil
+}
+
+// execByID runs a prepared statement affecting a single order of the current tenant.
+// The statement takes args first, then the tenant and the order ID as the last two parameters.
+func (r *PgxOrderRepository) execByID(ctx context.Context, action, statement, id string, args ...any) error {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+	}
+
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	tag, err := q.Exec(ctx, statement, append(args, tenantID, pgUUID(orderID))...)
+	if err != nil {
+		return fmt.Errorf("error %s order: %w", action, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrOrderNotFound
+	}
+
+	return nil
+}
+
+// scanPgxOrder scans a single order row using the native pgx types
+func scanPgxOrder(row pgx.Row) (*entity.Order, error) {
+	order := &entity.Order{}
+	var id pgtype.UUID
+	var items []byte
+	var status string
+	var idempotencyKey pgtype.Text
+	var deletedAt pgtype.Timestamptz
+
+	err := row.Scan(&id, &order.TenantID, &order.Description, &items, &order.Currency, &order.TotalAmount, &status,
+		&idempotencyKey, &order.CreatedAt, &order.UpdatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Items, err = unmarshalItems(items); err != nil {
+		return nil, err
+	}
+
+	order.ID = uuid.UUID(id.Bytes)
+	order.Status = entity.OrderStatus(status)
+	order.IdempotencyKey = idempotencyKey.String
+	if deletedAt.Valid {
+		order.DeletedAt = &deletedAt.Time
+	}
+	return order, nil
+}
+
+// pgUUID encodes a UUID in the binary uuid format
+func pgUUID(id uuid.UUID) pgtype.UUID {
+	return pgtype.UUID{Bytes: id, Valid: true}
+}
+
+// pgText maps an empty string to SQL NULL
+func pgText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
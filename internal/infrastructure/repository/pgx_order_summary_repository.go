@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgxOrderSummaryRepository implements the OrderSummaryRepository interface using
+// pgx. It shares its SQL with PostgresOrderSummaryRepository.
+type PgxOrderSummaryRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *PgxOrderRepository
+}
+
+// NewPgxOrderSummaryRepository creates a new instance of PgxOrderSummaryRepository
+func NewPgxOrderSummaryRepository(pool *pgxpool.Pool, rowLevelSecurity bool) repository.OrderSummaryRepository {
+	return &PgxOrderSummaryRepository{
+		orders: &PgxOrderRepository{pool: pool, rowLevelSecurity: rowLevelSecurity},
+	}
+}
+
+// List retrieves the order summaries of the current tenant, newest first
+func (r *PgxOrderSummaryRepository) List(ctx context.Context, options repository.ListOptions) ([]*entity.OrderSummary, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.Query(ctx, pgListOrderSummaries, tenantID, options.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []*entity.OrderSummary
+	for rows.Next() {
+		summary, err := scanPgxOrderSummary(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order summary: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// Search retrieves the order summaries of the current tenant matching query
+// through the search_vector GIN index, most relevant first
+func (r *PgxOrderSummaryRepository) Search(ctx context.Context, query entity.SearchQuery, options repository.SearchOptions) ([]*entity.OrderSearchResult, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.Query(ctx, pgSearchOrderSummaries, tenantID, pgTSQuery(query), options.IncludeDeleted, options.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching order summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*entity.OrderSearchResult
+	for rows.Next() {
+		result := &entity.OrderSearchResult{}
+		var id pgtype.UUID
+		var items []byte
+		var status, lastAction string
+		var deletedAt pgtype.Timestamptz
+		var rank float32
+
+		summary := &entity.OrderSummary{}
+		err := rows.Scan(&id, &summary.TenantID, &summary.Description, &items, &summary.Currency,
+			&summary.TotalAmount, &status, &summary.CreatedAt, &summary.UpdatedAt, &deletedAt, &summary.Version,
+			&summary.CreatedBy, &lastAction, &summary.LastActor, &summary.LastChangedAt, &summary.StatusChangedAt,
+			&rank, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order search result: %w", err)
+		}
+
+		if summary.Items, err = unmarshalItems(items); err != nil {
+			return nil, err
+		}
+		summary.ID = uuid.UUID(id.Bytes)
+		summary.Status = entity.OrderStatus(status)
+		summary.LastAction = entity.OrderHistoryAction(lastAction)
+		if deletedAt.Valid {
+			summary.DeletedAt = &deletedAt.Time
+		}
+		result.Summary = summary
+		result.Rank = float64(rank)
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// Stats counts and sums the orders of the current tenant per period, status and
+// currency, bucketed by date_trunc in the time zone of the query
+func (r *PgxOrderSummaryRepository) Stats(ctx context.Context, query entity.OrderStatsQuery) ([]*entity.OrderStatsRow, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.Query(ctx, pgOrderSummaryStats, tenantID, string(query.GroupBy), query.Location.String(),
+		query.From, query.To, query.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*entity.OrderStatsRow
+	for rows.Next() {
+		row := &entity.OrderStatsRow{}
+		var status string
+		if err := rows.Scan(&row.Bucket, &status, &row.Currency, &row.Count, &row.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning order stats: %w", err)
+		}
+		row.Bucket = row.Bucket.In(query.Location)
+		row.Status = entity.OrderStatus(status)
+		stats = append(stats, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Find retrieves the summary of an order, nil when there is none
+func (r *PgxOrderSummaryRepository) Find(ctx context.Context, id uuid.UUID) (*entity.OrderSummary, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	summary, err := scanPgxOrderSummary(q.QueryRow(ctx, pgFindOrderSummary, tenantID, pgUUID(id)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting order summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+// Save inserts or replaces the summary of an order
+func (r *PgxOrderSummaryRepository) Save(ctx context.Context, summary *entity.OrderSummary) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	summary.TenantID = tenantID
+
+	items, err := marshalItems(summary.Items)
+	if err != nil {
+		return err
+	}
+
+	var deletedAt pgtype.Timestamptz
+	if summary.DeletedAt != nil {
+		deletedAt = pgtype.Timestamptz{Time: *summary.DeletedAt, Valid: true}
+	}
+
+	_, err = q.Exec(ctx, pgSaveOrderSummary, pgUUID(summary.ID), summary.TenantID, summary.Description, items,
+		summary.Currency, summary.TotalAmount, string(summary.Status), summary.CreatedAt, summary.UpdatedAt, deletedAt,
+		summary.Version, summary.CreatedBy, string(summary.LastAction), summary.LastActor, summary.LastChangedAt,
+		summary.StatusChangedAt)
+	if err != nil {
+		return fmt.Errorf("error saving order summary: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the summary of an order
+func (r *PgxOrderSummaryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, err := q.Exec(ctx, pgDeleteOrderSummary, tenantID, pgUUID(id)); err != nil {
+		return fmt.Errorf("error deleting order summary: %w", err)
+	}
+
+	return nil
+}
+
+// Rebuild replaces the summaries of every tenant, run it inside a transaction
+// so readers keep seeing the previous summaries until it commits. The role must
+// bypass row level security, as the policies would hide the other tenants.
+func (r *PgxOrderSummaryRepository) Rebuild(ctx context.Context) (int64, error) {
+	var q pgxQuerier = r.orders.pool
+	if tx, ok := pgxTxFromContext(ctx); ok {
+		q = tx
+	}
+
+	var restricted bool
+	if err := q.QueryRow(ctx, pgRowSecurityActiveQuery).Scan(&restricted); err != nil {
+		return 0, fmt.Errorf("error checking row level security: %w", err)
+	}
+	if restricted {
+		return 0, repository.ErrRowLevelSecurity
+	}
+
+	if _, err := q.Exec(ctx, `DELETE FROM order_summaries`); err != nil {
+		return 0, fmt.Errorf("error clearing order summaries: %w", err)
+	}
+
+	tag, err := q.Exec(ctx, orderSummaryRebuildQuery)
+	if err != nil {
+		return 0, fmt.Errorf("error rebuilding order summaries: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// scanPgxOrderSummary scans a single order summary row using the native pgx types
+func scanPgxOrderSummary(row pgx.Row) (*entity.OrderSummary, error) {
+	summary := &entity.OrderSummary{}
+	var id pgtype.UUID
+	var items []byte
+	var status, lastAction string
+	var deletedAt pgtype.Timestamptz
+
+	err := row.Scan(&id, &summary.TenantID, &summary.Description, &items, &summary.Currency, &summary.TotalAmount,
+		&status, &summary.CreatedAt, &summary.UpdatedAt, &deletedAt, &summary.Version, &summary.CreatedBy,
+		&lastAction, &summary.LastActor, &summary.LastChangedAt, &summary.StatusChangedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if summary.Items, err = unmarshalItems(items); err != nil {
+		return nil, err
+	}
+	summary.ID = uuid.UUID(id.Bytes)
+	summary.Status = entity.OrderStatus(status)
+	summary.LastAction = entity.OrderHistoryAction(lastAction)
+	if deletedAt.Valid {
+		summary.DeletedAt = &deletedAt.Time
+	}
+	return summary, nil
+}
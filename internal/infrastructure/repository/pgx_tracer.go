@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// pgxTracer starts a client span for every statement and COPY sent through pgx
+type pgxTracer struct{}
+
+// TraceQueryStart opens the span, resolving prepared statement names to their SQL
+func (pgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	query := data.SQL
+	if statement, ok := pgxStatements[query]; ok {
+		query = statement
+	}
+
+	ctx, span := startQuerySpan(ctx, "postgresql", query)
+	if query != data.SQL {
+		span.SetAttributes(attribute.String("db.query.summary", data.SQL))
+	}
+	return ctx
+}
+
+// TraceQueryEnd records the outcome of the statement
+func (pgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endPgxSpan(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+// TraceCopyFromStart opens the span of a COPY FROM
+func (pgxTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx, _ = startQuerySpan(ctx, "postgresql",
+		"COPY "+data.TableName.Sanitize()+" ("+strings.Join(data.ColumnNames, ", ")+") FROM STDIN")
+	return ctx
+}
+
+// TraceCopyFromEnd records the outcome of the COPY FROM
+func (pgxTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	endPgxSpan(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+// endPgxSpan ends the span stored in ctx by the start hooks
+func endPgxSpan(ctx context.Context, rowsAffected int64, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", rowsAffected))
+	}
+	if err == pgx.ErrNoRows {
+		err = nil
+	}
+	endSpan(span, err)
+	span.End()
+}
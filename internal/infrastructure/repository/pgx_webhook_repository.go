@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgxWebhookRepository implements the WebhookRepository interface using pgx. It
+// shares its SQL with PostgresWebhookRepository, pgx prepares and caches the
+// statements per connection on first use.
+type PgxWebhookRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *PgxOrderRepository
+}
+
+// NewPgxWebhookRepository creates a new instance of PgxWebhookRepository
+func NewPgxWebhookRepository(pool *pgxpool.Pool, rowLevelSecurity bool) repository.WebhookRepository {
+	return &PgxWebhookRepository{
+		orders: &PgxOrderRepository{pool: pool, rowLevelSecurity: rowLevelSecurity},
+	}
+}
+
+// CreateEndpoint saves a new webhook endpoint to the database
+func (r *PgxWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *entity.WebhookEndpoint) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	endpoint.TenantID = tenantID
+
+	_, err = q.Exec(ctx, pgCreateWebhookEndpoint, pgUUID(endpoint.ID), endpoint.TenantID, endpoint.URL,
+		endpoint.EventTypes, endpoint.Secret, endpoint.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating webhook endpoint: %w", err)
+	}
+
+	return nil
+}
+
+// ListEndpoints retrieves the webhook endpoints of the current tenant
+func (r *PgxWebhookRepository) ListEndpoints(ctx context.Context) ([]*entity.WebhookEndpoint, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.Query(ctx, pgListWebhookEndpoints, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*entity.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanPgxWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook endpoints: %w", err)
+	}
+
+	return endpoints, nil
+}
+
+// GetEndpoint retrieves a webhook endpoint by its ID
+func (r *PgxWebhookRepository) GetEndpoint(ctx context.Context, id string) (*entity.WebhookEndpoint, error) {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	endpoint, err := scanPgxWebhookEndpoint(q.QueryRow(ctx, pgGetWebhookEndpoint, tenantID, pgUUID(endpointID)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrWebhookEndpointNotFound
+		}
+		return nil, fmt.Errorf("error getting webhook endpoint: %w", err)
+	}
+
+	return endpoint, nil
+}
+
+// DeleteEndpoint removes a webhook endpoint, its deliveries are removed by the foreign key
+func (r *PgxWebhookRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	tag, err := q.Exec(ctx, pgDeleteWebhookEndpoint, tenantID, pgUUID(endpointID))
+	if err != nil {
+		return fmt.Errorf("error deleting webhook endpoint: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrWebhookEndpointNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery saves a new delivery, ignoring events already queued for the endpoint
+func (r *PgxWebhookRepository) CreateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	delivery.TenantID = tenantID
+
+	_, err = q.Exec(ctx, pgCreateWebhookDelivery, pgUUID(delivery.ID), delivery.TenantID, pgUUID(delivery.EndpointID),
+		pgUUID(delivery.EventID), delivery.EventType, string(delivery.Payload), string(delivery.Status),
+		delivery.Attempts, delivery.NextAttemptAt, delivery.CreatedAt, delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a webhook delivery by its ID
+func (r *PgxWebhookRepository) GetDelivery(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, repository.ErrWebhookDeliveryNotFound
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	delivery, err := scanPgxWebhookDelivery(q.QueryRow(ctx, pgGetWebhookDelivery, tenantID, pgUUID(deliveryID)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("error getting webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ListDeliveries retrieves the most recent deliveries of an endpoint
+func (r *PgxWebhookRepository) ListDeliveries(ctx context.Context, endpointID string, status entity.WebhookDeliveryStatus, limit int) ([]*entity.WebhookDelivery, error) {
+	id, err := uuid.Parse(endpointID)
+	if err != nil {
+		return nil, repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.Query(ctx, pgListWebhookDeliveries, tenantID, pgUUID(id), string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPgxWebhookDeliveries(rows)
+}
+
+// UpdateDelivery saves the status, attempts and last result of a delivery
+func (r *PgxWebhookRepository) UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var deliveredAt pgtype.Timestamptz
+	if delivery.DeliveredAt != nil {
+		deliveredAt = pgtype.Timestamptz{Time: *delivery.DeliveredAt, Valid: true}
+	}
+
+	tag, err := q.Exec(ctx, pgUpdateWebhookDelivery, string(delivery.Status), delivery.Attempts,
+		delivery.NextAttemptAt, pgtype.Int4{Int32: int32(delivery.LastStatusCode), Valid: delivery.LastStatusCode != 0},
+		pgText(delivery.LastError), delivery.UpdatedAt, deliveredAt, tenantID, pgUUID(delivery.ID))
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrWebhookDeliveryNotFound
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries leases the due deliveries of every tenant, skipping rows
+// locked by other workers
+func (r *PgxWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error) {
+	var q pgxQuerier = r.orders.pool
+	if tx, ok := pgxTxFromContext(ctx); ok {
+		q = tx
+	}
+
+	rows, err := q.Query(ctx, pgClaimDueWebhookDeliveries, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPgxWebhookDeliveries(rows)
+}
+
+// scanPgxWebhookEndpoint scans a single webhook endpoint row
+func scanPgxWebhookEndpoint(row pgx.Row) (*entity.WebhookEndpoint, error) {
+	endpoint := &entity.WebhookEndpoint{}
+	var id pgtype.UUID
+
+	err := row.Scan(&id, &endpoint.TenantID, &endpoint.URL, &endpoint.EventTypes, &endpoint.Secret, &endpoint.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.ID = uuid.UUID(id.Bytes)
+	return endpoint, nil
+}
+
+// scanPgxWebhookDelivery scans a single webhook delivery row
+func scanPgxWebhookDelivery(row pgx.Row) (*entity.WebhookDelivery, error) {
+	delivery := &entity.WebhookDelivery{}
+	var id, endpointID, eventID pgtype.UUID
+	var status string
+	var lastStatusCode pgtype.Int4
+	var lastError pgtype.Text
+	var deliveredAt pgtype.Timestamptz
+
+	err := row.Scan(&id, &delivery.TenantID, &endpointID, &eventID, &delivery.EventType, &delivery.Payload,
+		&status, &delivery.Attempts, &delivery.NextAttemptAt, &lastStatusCode, &lastError,
+		&delivery.CreatedAt, &delivery.UpdatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.ID = uuid.UUID(id.Bytes)
+	delivery.EndpointID = uuid.UUID(endpointID.Bytes)
+	delivery.EventID = uuid.UUID(eventID.Bytes)
+	delivery.Status = entity.WebhookDeliveryStatus(status)
+	delivery.LastStatusCode = int(lastStatusCode.Int32)
+	delivery.LastError = lastError.String
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
+
+// collectPgxWebhookDeliveries scans every delivery of rows
+func collectPgxWebhookDeliveries(rows pgx.Rows) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanPgxWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
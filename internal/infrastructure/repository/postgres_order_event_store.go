@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// SQL shared by the PostgreSQL and pgx order event stores
+const (
+	orderEventColumns = `id, tenant_id, order_id, sequence, type, data, occurred_at`
+
+	pgAppendOrderEvent = `
+		INSERT INTO order_events (id, tenant_id, order_id, sequence, type, data, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (order_id, sequence) DO NOTHING`
+	pgLoadOrderEvents = `
+		SELECT ` + orderEventColumns + `
+		FROM order_events
+		WHERE tenant_id = $1 AND order_id = $2 AND sequence > $3
+		ORDER BY sequence`
+	pgSaveOrderSnapshot = `
+		INSERT INTO order_snapshots (tenant_id, order_id, sequence, state, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (order_id, sequence) DO NOTHING`
+	pgLatestOrderSnapshot = `
+		SELECT tenant_id, order_id, sequence, state, created_at
+		FROM order_snapshots
+		WHERE tenant_id = $1 AND order_id = $2
+		ORDER BY sequence DESC
+		LIMIT 1`
+	pgListDeletedOrderEvents = `
+		SELECT ` + orderEventColumns + `
+		FROM order_events e
+		WHERE e.type = 'order.deleted' AND e.occurred_at < $1
+		  AND e.sequence = (SELECT MAX(sequence) FROM order_events l WHERE l.order_id = e.order_id)
+		ORDER BY e.occurred_at`
+)
+
+// PostgresOrderEventStore implements the OrderEventStore interface using PostgreSQL
+type PostgresOrderEventStore struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *PostgresOrderRepository
+}
+
+// NewPostgresOrderEventStore creates a new instance of PostgresOrderEventStore,
+// rowLevelSecurity has the same meaning as in NewPostgresOrderRepository
+func NewPostgresOrderEventStore(db *sql.DB, rowLevelSecurity bool) repository.OrderEventStore {
+	return &PostgresOrderEventStore{
+		orders: &PostgresOrderRepository{db: db, rowLevelSecurity: rowLevelSecurity},
+	}
+}
+
+// Append saves a new event at the end of the stream of its order
+func (s *PostgresOrderEventStore) Append(ctx context.Context, event *entity.OrderEvent) error {
+	q, tenantID, release, err := s.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	event.TenantID = tenantID
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("error encoding order event data: %w", err)
+	}
+
+	result, err := q.ExecContext(ctx, pgAppendOrderEvent, event.ID, event.TenantID, event.OrderID, event.Sequence,
+		event.Type, string(data), event.OccurredAt)
+	if err != nil {
+		return fmt.Errorf("error appending order event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrOrderConcurrentModification
+	}
+
+	return nil
+}
+
+// Load retrieves the events of an order after the given sequence, oldest first
+func (s *PostgresOrderEventStore) Load(ctx context.Context, orderID uuid.UUID, afterSequence int64) ([]*entity.OrderEvent, error) {
+	q, tenantID, release, err := s.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.QueryContext(ctx, pgLoadOrderEvents, tenantID, orderID, afterSequence)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order events: %w", err)
+	}
+	defer rows.Close()
+
+	return collectOrderEvents(rows)
+}
+
+// SaveSnapshot saves a snapshot of an order
+func (s *PostgresOrderEventStore) SaveSnapshot(ctx context.Context, snapshot *entity.OrderSnapshot) error {
+	q, tenantID, release, err := s.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	snapshot.TenantID = tenantID
+
+	state, err := json.Marshal(snapshot.State)
+	if err != nil {
+		return fmt.Errorf("error encoding order snapshot: %w", err)
+	}
+
+	_, err = q.ExecContext(ctx, pgSaveOrderSnapshot, snapshot.TenantID, snapshot.OrderID, snapshot.Sequence,
+		string(state), snapshot.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving order snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LatestSnapshot retrieves the most recent snapshot of an order, nil when there is none
+func (s *PostgresOrderEventStore) LatestSnapshot(ctx context.Context, orderID uuid.UUID) (*entity.OrderSnapshot, error) {
+	q, tenantID, release, err := s.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	snapshot := &entity.OrderSnapshot{}
+	var state []byte
+
+	err = q.QueryRowContext(ctx, pgLatestOrderSnapshot, tenantID, orderID).
+		Scan(&snapshot.TenantID, &snapshot.OrderID, &snapshot.Sequence, &state, &snapshot.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting order snapshot: %w", err)
+	}
+
+	if err := json.Unmarshal(state, &snapshot.State); err != nil {
+		return nil, fmt.Errorf("invalid stored order snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// collectOrderEvents scans every event of rows
+func collectOrderEvents(rows *sql.Rows) ([]*entity.OrderEvent, error) {
+	var events []*entity.OrderEvent
+	for rows.Next() {
+		event := &entity.OrderEvent{}
+		var data []byte
+
+		err := rows.Scan(&event.ID, &event.TenantID, &event.OrderID, &event.Sequence, &event.Type, &data, &event.OccurredAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order event: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &event.Data); err != nil {
+			return nil, fmt.Errorf("invalid stored order event data: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order events: %w", err)
+	}
+
+	return events, nil
+}
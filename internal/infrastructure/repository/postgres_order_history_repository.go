@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresOrderHistoryRepository implements the OrderHistoryRepository interface using PostgreSQL
+type PostgresOrderHistoryRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *PostgresOrderRepository
+}
+
+// NewPostgresOrderHistoryRepository creates a new instance of PostgresOrderHistoryRepository,
+// rowLevelSecurity has the same meaning as in NewPostgresOrderRepository
+func NewPostgresOrderHistoryRepository(db *sql.DB, rowLevelSecurity bool) repository.OrderHistoryRepository {
+	return &PostgresOrderHistoryRepository{
+		orders: &PostgresOrderRepository{db: db, rowLevelSecurity: rowLevelSecurity},
+	}
+}
+
+// Append saves a new history entry to the database
+func (r *PostgresOrderHistoryRepository) Append(ctx context.Context, entry *entity.OrderHistoryEntry) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	entry.TenantID = tenantID
+
+	changes, err := marshalChanges(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO order_history (id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err = q.ExecContext(ctx, query, entry.ID, entry.TenantID, entry.OrderID, entry.Action, entry.Actor,
+		entry.ClaimedActor, nullString(entry.RequestID), changes, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating order history entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListByOrderID retrieves the history of an order, oldest first
+func (r *PostgresOrderHistoryRepository) ListByOrderID(ctx context.Context, orderID string) ([]*entity.OrderHistoryEntry, error) {
+	id, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	query := `
+		SELECT id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at
+		FROM order_history
+		WHERE tenant_id = $1 AND order_id = $2
+		ORDER BY created_at, id
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order history: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPostgresOrderHistory(rows)
+}
+
+// ListByOrderIDs retrieves the history of several orders, oldest first
+func (r *PostgresOrderHistoryRepository) ListByOrderIDs(ctx context.Context, orderIDs []string) ([]*entity.OrderHistoryEntry, error) {
+	ids, err := parseOrderIDs(orderIDs)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	query := `
+		SELECT id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at
+		FROM order_history
+		WHERE tenant_id = $1 AND order_id = ANY($2::uuid[])
+		ORDER BY created_at, id
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return nil, fmt.Errorf("error querying order history: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPostgresOrderHistory(rows)
+}
+
+// collectPostgresOrderHistory scans every history entry of rows
+func collectPostgresOrderHistory(rows *sql.Rows) ([]*entity.OrderHistoryEntry, error) {
+	var entries []*entity.OrderHistoryEntry
+	for rows.Next() {
+		entry := &entity.OrderHistoryEntry{}
+		var requestID sql.NullString
+		var changes []byte
+
+		err := rows.Scan(&entry.ID, &entry.TenantID, &entry.OrderID, &entry.Action, &entry.Actor, &entry.ClaimedActor,
+			&requestID, &changes, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order history entry: %w", err)
+		}
+
+		entry.RequestID = requestID.String
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("invalid stored order history changes: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parseOrderIDs parses the IDs of a history lookup, dropping duplicates
+func parseOrderIDs(orderIDs []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(orderIDs))
+	seen := make(map[uuid.UUID]bool, len(orderIDs))
+	for _, orderID := range orderIDs {
+		id, err := uuid.Parse(orderID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// uuidStrings formats ids in their canonical form
+func uuidStrings(ids []uuid.UUID) []string {
+	formatted := make([]string, 0, len(ids))
+	for _, id := range ids {
+		formatted = append(formatted, id.String())
+	}
+	return formatted
+}
+
+// marshalChanges encodes the field changes of an entry as a JSON array
+func marshalChanges(changes []entity.FieldChange) (string, error) {
+	if changes == nil {
+		changes = []entity.FieldChange{}
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return "", fmt.Errorf("error encoding order history changes: %w", err)
+	}
+	return string(data), nil
+}
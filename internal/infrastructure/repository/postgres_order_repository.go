@@ -3,50 +3,145 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"curso-go-clean-arch/internal/domain/entity"
 	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
 
 	"github.com/google/uuid"
 )
 
+// querier is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// pgUpsertOrder writes the complete state of an order, shared by the PostgreSQL
+// and pgx repositories. An existing row of another tenant is left untouched.
+const pgUpsertOrder = `
+	INSERT INTO orders (id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+		updated_at, deleted_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT (id) DO UPDATE
+	SET description = EXCLUDED.description, items = EXCLUDED.items, currency = EXCLUDED.currency,
+		total_amount = EXCLUDED.total_amount, status = EXCLUDED.status,
+		idempotency_key = EXCLUDED.idempotency_key, updated_at = EXCLUDED.updated_at,
+		deleted_at = EXCLUDED.deleted_at
+	WHERE orders.tenant_id = EXCLUDED.tenant_id`
+
 // PostgresOrderRepository implements the OrderRepository interface using PostgreSQL
 type PostgresOrderRepository struct {
-	db *sql.DB
+	db               *sql.DB
+	rowLevelSecurity bool
 }
 
-// NewPostgresOrderRepository creates a new instance of PostgresOrderRepository
-func NewPostgresOrderRepository(db *sql.DB) repository.OrderRepository {
+// NewPostgresOrderRepository creates a new instance of PostgresOrderRepository.
+// When rowLevelSecurity is true, app.tenant_id is set on the connection used by
+// each operation so the orders_tenant_isolation policy applies as well.
+func NewPostgresOrderRepository(db *sql.DB, rowLevelSecurity bool) repository.OrderRepository {
 	return &PostgresOrderRepository{
-		db: db,
+		db:               db,
+		rowLevelSecurity: rowLevelSecurity,
+	}
+}
+
+// scope returns the tenant of ctx and a querier bound to it, which is the
+// transaction of ctx when the call runs inside TransactionManager
+func (r *PostgresOrderRepository) scope(ctx context.Context) (querier, string, func(), error) {
+	tenantID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		return newTracedQuerier(tx, "postgresql"), tenantID, func() {}, nil
+	}
+
+	if !r.rowLevelSecurity {
+		return newTracedQuerier(r.db, "postgresql"), tenantID, func() {}, nil
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error acquiring connection: %w", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, false)`, tenantID); err != nil {
+		conn.Close()
+		return nil, "", nil, fmt.Errorf("error setting tenant on connection: %w", err)
+	}
+
+	release := func() {
+		// Reset the setting before the connection goes back to the pool
+		conn.ExecContext(context.Background(), `SELECT set_config('app.tenant_id', '', false)`)
+		conn.Close()
 	}
+
+	return newTracedQuerier(conn, "postgresql"), tenantID, release, nil
 }
 
 // Create saves a new order to the database
 func (r *PostgresOrderRepository) Create(ctx context.Context, order *entity.Order) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	order.TenantID = tenantID
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO orders (id, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO orders (id, tenant_id, description, items, currency, total_amount, status, idempotency_key,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (tenant_id, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
 	`
 
-	_, err := r.db.ExecContext(ctx, query, order.ID, order.Description, order.CreatedAt, order.UpdatedAt)
+	result, err := q.ExecContext(ctx, query, order.ID, order.TenantID, order.Description, items, order.Currency,
+		order.TotalAmount, order.Status, nullString(order.IdempotencyKey), order.CreatedAt, order.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("error creating order: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrDuplicateIdempotencyKey
+	}
+
 	return nil
 }
 
-// List retrieves all orders from the database
-func (r *PostgresOrderRepository) List(ctx context.Context) ([]*entity.Order, error) {
+// List retrieves the orders of the current tenant from the database
+func (r *PostgresOrderRepository) List(ctx context.Context, options repository.ListOptions) ([]*entity.Order, error) {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	query := `
-		SELECT id, description, created_at, updated_at
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
 		FROM orders
+		WHERE tenant_id = $1 AND ($2 OR deleted_at IS NULL)
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := q.QueryContext(ctx, query, tenantID, options.IncludeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("error querying orders: %w", err)
 	}
@@ -54,8 +149,7 @@ func (r *PostgresOrderRepository) List(ctx context.Context) ([]*entity.Order, er
 
 	var orders []*entity.Order
 	for rows.Next() {
-		order := &entity.Order{}
-		err := rows.Scan(&order.ID, &order.Description, &order.CreatedAt, &order.UpdatedAt)
+		order, err := scanOrder(rows)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning order: %w", err)
 		}
@@ -71,24 +165,64 @@ func (r *PostgresOrderRepository) List(ctx context.Context) ([]*entity.Order, er
 
 // GetByID retrieves an order by its ID
 func (r *PostgresOrderRepository) GetByID(ctx context.Context, id string) (*entity.Order, error) {
+	return r.getByID(ctx, id, false)
+}
+
+// GetByIDIncludingDeleted retrieves an order by its ID even when it is soft deleted
+func (r *PostgresOrderRepository) GetByIDIncludingDeleted(ctx context.Context, id string) (*entity.Order, error) {
+	return r.getByID(ctx, id, true)
+}
+
+// getByID retrieves an order by its ID, soft deleted ones only when includeDeleted is set
+func (r *PostgresOrderRepository) getByID(ctx context.Context, id string, includeDeleted bool) (*entity.Order, error) {
 	orderID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid order ID: %w", err)
+		return nil, fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
 	}
 
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	query := `
-		SELECT id, description, created_at, updated_at
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
 		FROM orders
-		WHERE id = $1
+		WHERE tenant_id = $1 AND id = $2 AND ($3 OR deleted_at IS NULL)
 	`
 
-	order := &entity.Order{}
-	err = r.db.QueryRowContext(ctx, query, orderID).Scan(
-		&order.ID, &order.Description, &order.CreatedAt, &order.UpdatedAt,
-	)
+	order, err := scanOrder(q.QueryRowContext(ctx, query, tenantID, orderID, includeDeleted))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("error getting order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetByIdempotencyKey retrieves an order by the idempotency key it was created with
+func (r *PostgresOrderRepository) GetByIdempotencyKey(ctx context.Context, key string) (*entity.Order, error) {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	query := `
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
+		FROM orders
+		WHERE tenant_id = $1 AND idempotency_key = $2
+	`
+
+	order, err := scanOrder(q.QueryRowContext(ctx, query, tenantID, key))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("order not found")
+			return nil, repository.ErrOrderNotFound
 		}
 		return nil, fmt.Errorf("error getting order: %w", err)
 	}
@@ -98,13 +232,25 @@ func (r *PostgresOrderRepository) GetByID(ctx context.Context, id string) (*enti
 
 // Update updates an existing order in the database
 func (r *PostgresOrderRepository) Update(ctx context.Context, order *entity.Order) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE orders
-		SET description = $1, updated_at = $2
-		WHERE id = $3
+		SET description = $1, items = $2, total_amount = $3, status = $4, updated_at = $5
+		WHERE tenant_id = $6 AND id = $7 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.ExecContext(ctx, query, order.Description, order.UpdatedAt, order.ID)
+	result, err := q.ExecContext(ctx, query, order.Description, items, order.TotalAmount, order.Status, order.UpdatedAt,
+		tenantID, order.ID)
 	if err != nil {
 		return fmt.Errorf("error updating order: %w", err)
 	}
@@ -115,24 +261,140 @@ func (r *PostgresOrderRepository) Update(ctx context.Context, order *entity.Orde
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("order not found")
+		return repository.ErrOrderNotFound
 	}
 
 	return nil
 }
 
-// Delete removes an order from the database
+// Delete soft deletes an order by setting deleted_at
 func (r *PostgresOrderRepository) Delete(ctx context.Context, id string) error {
+	query := `
+		UPDATE orders
+		SET deleted_at = $1, updated_at = $1
+		WHERE tenant_id = $2 AND id = $3 AND deleted_at IS NULL
+	`
+	return r.execByID(ctx, "deleting", query, id, time.Now())
+}
+
+// Restore clears deleted_at of a soft deleted order
+func (r *PostgresOrderRepository) Restore(ctx context.Context, id string) error {
+	query := `
+		UPDATE orders
+		SET deleted_at = NULL, updated_at = $1
+		WHERE tenant_id = $2 AND id = $3 AND deleted_at IS NOT NULL
+	`
+	return r.execByID(ctx, "restoring", query, id, time.Now())
+}
+
+// Purge permanently removes a soft deleted order
+func (r *PostgresOrderRepository) Purge(ctx context.Context, id string) error {
+	query := `DELETE FROM orders WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NOT NULL`
+	return r.execByID(ctx, "purging", query, id)
+}
+
+// ListDeletedBefore returns the orders of every tenant soft deleted before the
+// given time. It runs without a tenant, so the role must bypass row level
+// security, as the policies would hide every order.
+func (r *PostgresOrderRepository) ListDeletedBefore(ctx context.Context, before time.Time) ([]repository.DeletedOrder, error) {
+	var q querier = newTracedQuerier(r.db, "postgresql")
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		q = newTracedQuerier(tx, "postgresql")
+	}
+
+	var restricted bool
+	if err := q.QueryRowContext(ctx, pgOrdersRowSecurityActiveQuery).Scan(&restricted); err != nil {
+		return nil, fmt.Errorf("error checking row level security: %w", err)
+	}
+	if restricted {
+		return nil, repository.ErrRowLevelSecurity
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT tenant_id, id FROM orders
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at`, before)
+	if err != nil {
+		return nil, fmt.Errorf("error listing deleted orders: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDeletedOrders(rows)
+}
+
+// scanDeletedOrders reads the tenant and ID of each row
+func scanDeletedOrders(rows *sql.Rows) ([]repository.DeletedOrder, error) {
+	var deleted []repository.DeletedOrder
+	for rows.Next() {
+		var order repository.DeletedOrder
+		if err := rows.Scan(&order.TenantID, &order.ID); err != nil {
+			return nil, fmt.Errorf("error scanning deleted order: %w", err)
+		}
+		deleted = append(deleted, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing deleted orders: %w", err)
+	}
+	return deleted, nil
+}
+
+// Upsert saves the complete state of an order, so the table serves as the
+// projection of event-sourced orders
+func (r *PostgresOrderRepository) Upsert(ctx context.Context, order *entity.Order) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	order.TenantID = tenantID
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, pgUpsertOrder, order.ID, order.TenantID, order.Description, items, order.Currency,
+		order.TotalAmount, order.Status, nullString(order.IdempotencyKey), order.CreatedAt, order.UpdatedAt, order.DeletedAt)
+	if err != nil {
+		return fmt.Errorf("error upserting order: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the row of an order whether or not it is soft deleted
+func (r *PostgresOrderRepository) Remove(ctx context.Context, id uuid.UUID) error {
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, err := q.ExecContext(ctx, `DELETE FROM orders WHERE tenant_id = $1 AND id = $2`, tenantID, id); err != nil {
+		return fmt.Errorf("error removing order: %w", err)
+	}
+
+	return nil
+}
+
+// execByID runs a statement affecting a single order of the current tenant. The
+// statement takes args first, then the tenant and the order ID as the last two parameters.
+func (r *PostgresOrderRepository) execByID(ctx context.Context, action, query, id string, args ...any) error {
 	orderID, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("invalid order ID: %w", err)
+		return fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
 	}
 
-	query := `DELETE FROM orders WHERE id = $1`
+	q, tenantID, release, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	result, err := r.db.ExecContext(ctx, query, orderID)
+	result, err := q.ExecContext(ctx, query, append(args, tenantID, orderID)...)
 	if err != nil {
-		return fmt.Errorf("error deleting order: %w", err)
+		return fmt.Errorf("error %s order: %w", action, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -141,8 +403,66 @@ func (r *PostgresOrderRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("order not found")
+		return repository.ErrOrderNotFound
 	}
 
 	return nil
 }
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanOrder scans a single order row
+func scanOrder(row rowScanner) (*entity.Order, error) {
+	order := &entity.Order{}
+	var items []byte
+	var idempotencyKey sql.NullString
+	var deletedAt sql.NullTime
+
+	err := row.Scan(&order.ID, &order.TenantID, &order.Description, &items, &order.Currency, &order.TotalAmount,
+		&order.Status, &idempotencyKey, &order.CreatedAt, &order.UpdatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Items, err = unmarshalItems(items); err != nil {
+		return nil, err
+	}
+
+	order.IdempotencyKey = idempotencyKey.String
+	if deletedAt.Valid {
+		order.DeletedAt = &deletedAt.Time
+	}
+	return order, nil
+}
+
+// nullString maps an empty string to SQL NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// marshalItems encodes the items of an order as a JSON array
+func marshalItems(items []entity.OrderItem) (string, error) {
+	if items == nil {
+		items = []entity.OrderItem{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", fmt.Errorf("error encoding order items: %w", err)
+	}
+	return string(data), nil
+}
+
+// unmarshalItems decodes the items stored by marshalItems, nil when there are none
+func unmarshalItems(data []byte) ([]entity.OrderItem, error) {
+	var items []entity.OrderItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid stored order items: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return items, nil
+}
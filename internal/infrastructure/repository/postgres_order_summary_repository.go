@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// orderSummaryColumns are the columns of order_summaries, in scan order
+const orderSummaryColumns = `id, tenant_id, description, items, currency, total_amount, status, created_at,
+	updated_at, deleted_at, version, created_by, last_action, last_actor, last_changed_at, status_changed_at`
+
+// pgRowSecurityActiveQuery reports whether policies filter the tables the rebuild
+// reads or writes for the current role, which then does not see every tenant
+const pgRowSecurityActiveQuery = `
+	SELECT row_security_active('orders') OR row_security_active('order_history')
+		OR row_security_active('order_summaries')`
+
+// pgOrdersRowSecurityActiveQuery reports whether policies filter orders for the
+// current role, which then does not see the orders of every tenant
+const pgOrdersRowSecurityActiveQuery = `SELECT row_security_active('orders')`
+
+// orderSummaryRebuildQuery projects every order from orders and order_history.
+// It takes no parameters, so it is shared by every backend and the migrations.
+const orderSummaryRebuildQuery = `
+	INSERT INTO order_summaries (` + orderSummaryColumns + `)
+	SELECT o.id, o.tenant_id, o.description, o.items, o.currency, o.total_amount, o.status, o.created_at,
+		o.updated_at, o.deleted_at,
+		(SELECT COUNT(*) FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id),
+		COALESCE((SELECT h.actor FROM order_history h
+			WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id AND h.action = 'created'
+			ORDER BY h.created_at LIMIT 1), ''),
+		COALESCE((SELECT h.action FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id
+			ORDER BY h.created_at DESC, h.id DESC LIMIT 1), ''),
+		COALESCE((SELECT h.actor FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id
+			ORDER BY h.created_at DESC, h.id DESC LIMIT 1), ''),
+		COALESCE((SELECT MAX(h.created_at) FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id),
+			o.updated_at),
+		COALESCE((SELECT MAX(h.created_at) FROM order_history h
+			WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id AND h.action = 'status_changed'),
+			o.created_at)
+	FROM orders o`
+
+// SQL shared by the PostgreSQL and pgx order summary repositories
+const (
+	pgListOrderSummaries = `
+		SELECT ` + orderSummaryColumns + `
+		FROM order_summaries
+		WHERE tenant_id = $1 AND ($2 OR deleted_at IS NULL)
+		ORDER BY created_at DESC`
+	pgFindOrderSummary = `
+		SELECT ` + orderSummaryColumns + `
+		FROM order_summaries
+		WHERE tenant_id = $1 AND id = $2`
+	pgSaveOrderSummary = `
+		INSERT INTO order_summaries (` + orderSummaryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (id) DO UPDATE
+		SET description = EXCLUDED.description, items = EXCLUDED.items, currency = EXCLUDED.currency,
+			total_amount = EXCLUDED.total_amount, status = EXCLUDED.status, updated_at = EXCLUDED.updated_at,
+			deleted_at = EXCLUDED.deleted_at, version = EXCLUDED.version, created_by = EXCLUDED.created_by,
+			last_action = EXCLUDED.last_action, last_actor = EXCLUDED.last_actor,
+			last_changed_at = EXCLUDED.last_changed_at, status_changed_at = EXCLUDED.status_changed_at
+		WHERE order_summaries.tenant_id = EXCLUDED.tenant_id`
+	pgDeleteOrderSummary   = `DELETE FROM order_summaries WHERE tenant_id = $1 AND id = $2`
+	pgSearchOrderSummaries = `
+		SELECT ` + orderSummaryColumns + `,
+			ts_rank_cd(search_vector, query) AS rank,
+			ts_headline('simple', description || COALESCE((SELECT ' | ' || string_agg(item->>'name', ' | ')
+				FROM jsonb_array_elements(items) item), ''), query,
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" ... "')
+		FROM order_summaries, to_tsquery('simple', $2) query
+		WHERE tenant_id = $1 AND ($3 OR deleted_at IS NULL) AND search_vector @@ query
+		ORDER BY rank DESC, created_at DESC
+		LIMIT $4`
+	pgOrderSummaryStats = `
+		SELECT date_trunc($2, created_at, $3) AS bucket, status, currency, COUNT(*), SUM(total_amount)::bigint
+		FROM order_summaries
+		WHERE tenant_id = $1 AND created_at >= $4 AND created_at < $5 AND ($6 OR deleted_at IS NULL)
+		GROUP BY bucket, status, currency
+		ORDER BY bucket, status, currency`
+)
+
+// PostgresOrderSummaryRepository implements the OrderSummaryRepository interface using PostgreSQL
+type PostgresOrderSummaryRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *PostgresOrderRepository
+}
+
+// NewPostgresOrderSummaryRepository creates a new instance of PostgresOrderSummaryRepository,
+// rowLevelSecurity has the same meaning as in NewPostgresOrderRepository
+func NewPostgresOrderSummaryRepository(db *sql.DB, rowLevelSecurity bool) repository.OrderSummaryRepository {
+	return &PostgresOrderSummaryRepository{
+		orders: &PostgresOrderRepository{db: db, rowLevelSecurity: rowLevelSecurity},
+	}
+}
+
+// List retrieves the order summaries of the current tenant, newest first
+func (r *PostgresOrderSummaryRepository) List(ctx context.Context, options repository.ListOptions) ([]*entity.OrderSummary, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.QueryContext(ctx, pgListOrderSummaries, tenantID, options.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []*entity.OrderSummary
+	for rows.Next() {
+		summary, err := scanOrderSummary(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order summary: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// Search retrieves the order summaries of the current tenant matching query
+// through the search_vector GIN index, most relevant first
+func (r *PostgresOrderSummaryRepository) Search(ctx context.Context, query entity.SearchQuery, options repository.SearchOptions) ([]*entity.OrderSearchResult, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.QueryContext(ctx, pgSearchOrderSummaries, tenantID, pgTSQuery(query), options.IncludeDeleted, options.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching order summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*entity.OrderSearchResult
+	for rows.Next() {
+		summary := &entity.OrderSummary{}
+		result := &entity.OrderSearchResult{Summary: summary}
+		var items []byte
+		var deletedAt sql.NullTime
+
+		err := rows.Scan(&summary.ID, &summary.TenantID, &summary.Description, &items, &summary.Currency,
+			&summary.TotalAmount, &summary.Status, &summary.CreatedAt, &summary.UpdatedAt, &deletedAt,
+			&summary.Version, &summary.CreatedBy, &summary.LastAction, &summary.LastActor, &summary.LastChangedAt,
+			&summary.StatusChangedAt, &result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order search result: %w", err)
+		}
+
+		if summary.Items, err = unmarshalItems(items); err != nil {
+			return nil, err
+		}
+		if deletedAt.Valid {
+			summary.DeletedAt = &deletedAt.Time
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// Stats counts and sums the orders of the current tenant per period, status and
+// currency, bucketed by date_trunc in the time zone of the query
+func (r *PostgresOrderSummaryRepository) Stats(ctx context.Context, query entity.OrderStatsQuery) ([]*entity.OrderStatsRow, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.QueryContext(ctx, pgOrderSummaryStats, tenantID, string(query.GroupBy), query.Location.String(),
+		query.From, query.To, query.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*entity.OrderStatsRow
+	for rows.Next() {
+		row := &entity.OrderStatsRow{}
+		if err := rows.Scan(&row.Bucket, &row.Status, &row.Currency, &row.Count, &row.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning order stats: %w", err)
+		}
+		row.Bucket = row.Bucket.In(query.Location)
+		stats = append(stats, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Find retrieves the summary of an order, nil when there is none
+func (r *PostgresOrderSummaryRepository) Find(ctx context.Context, id uuid.UUID) (*entity.OrderSummary, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	summary, err := scanOrderSummary(q.QueryRowContext(ctx, pgFindOrderSummary, tenantID, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting order summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+// Save inserts or replaces the summary of an order
+func (r *PostgresOrderSummaryRepository) Save(ctx context.Context, summary *entity.OrderSummary) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	summary.TenantID = tenantID
+
+	items, err := marshalItems(summary.Items)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, pgSaveOrderSummary, summary.ID, summary.TenantID, summary.Description, items,
+		summary.Currency, summary.TotalAmount, summary.Status, summary.CreatedAt, summary.UpdatedAt, summary.DeletedAt,
+		summary.Version, summary.CreatedBy, summary.LastAction, summary.LastActor, summary.LastChangedAt,
+		summary.StatusChangedAt)
+	if err != nil {
+		return fmt.Errorf("error saving order summary: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the summary of an order
+func (r *PostgresOrderSummaryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, err := q.ExecContext(ctx, pgDeleteOrderSummary, tenantID, id); err != nil {
+		return fmt.Errorf("error deleting order summary: %w", err)
+	}
+
+	return nil
+}
+
+// Rebuild replaces the summaries of every tenant, run it inside a transaction
+// so readers keep seeing the previous summaries until it commits. The role must
+// bypass row level security, as the policies would hide the other tenants.
+func (r *PostgresOrderSummaryRepository) Rebuild(ctx context.Context) (int64, error) {
+	var q querier = newTracedQuerier(r.orders.db, "postgresql")
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		q = newTracedQuerier(tx, "postgresql")
+	}
+
+	var restricted bool
+	if err := q.QueryRowContext(ctx, pgRowSecurityActiveQuery).Scan(&restricted); err != nil {
+		return 0, fmt.Errorf("error checking row level security: %w", err)
+	}
+	if restricted {
+		return 0, repository.ErrRowLevelSecurity
+	}
+
+	return rebuildOrderSummaries(ctx, q)
+}
+
+// rebuildOrderSummaries empties order_summaries and projects every order again
+func rebuildOrderSummaries(ctx context.Context, q querier) (int64, error) {
+	if _, err := q.ExecContext(ctx, `DELETE FROM order_summaries`); err != nil {
+		return 0, fmt.Errorf("error clearing order summaries: %w", err)
+	}
+
+	result, err := q.ExecContext(ctx, orderSummaryRebuildQuery)
+	if err != nil {
+		return 0, fmt.Errorf("error rebuilding order summaries: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// pgTSQuery converts a search query to the to_tsquery syntax: terms joined with
+// &, phrases with <-> and prefixes suffixed with :*. Search words only contain
+// letters and digits, so they need no quoting.
+func pgTSQuery(query entity.SearchQuery) string {
+	terms := make([]string, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		phrase := strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			phrase += ":*"
+		}
+		if len(term.Words) > 1 {
+			phrase = "(" + phrase + ")"
+		}
+		terms = append(terms, phrase)
+	}
+	return strings.Join(terms, " & ")
+}
+
+// scanOrderSummary scans a single order summary row
+func scanOrderSummary(row rowScanner) (*entity.OrderSummary, error) {
+	summary := &entity.OrderSummary{}
+	var items []byte
+	var deletedAt sql.NullTime
+
+	err := row.Scan(&summary.ID, &summary.TenantID, &summary.Description, &items, &summary.Currency,
+		&summary.TotalAmount, &summary.Status, &summary.CreatedAt, &summary.UpdatedAt, &deletedAt, &summary.Version,
+		&summary.CreatedBy, &summary.LastAction, &summary.LastActor, &summary.LastChangedAt, &summary.StatusChangedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if summary.Items, err = unmarshalItems(items); err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		summary.DeletedAt = &deletedAt.Time
+	}
+	return summary, nil
+}
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// webhookDeliveryColumns are the columns selected for a delivery, in scan order
+const webhookDeliveryColumns = `id, tenant_id, endpoint_id, event_id, event_type, payload, status, attempts,
+	next_attempt_at, last_status_code, last_error, created_at, updated_at, delivered_at`
+
+// PostgreSQL queries shared by the lib/pq and pgx webhook repositories
+const (
+	pgCreateWebhookEndpoint = `
+		INSERT INTO webhook_endpoints (id, tenant_id, url, event_types, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	pgListWebhookEndpoints = `
+		SELECT id, tenant_id, url, event_types, secret, created_at
+		FROM webhook_endpoints
+		WHERE tenant_id = $1
+		ORDER BY created_at`
+	pgGetWebhookEndpoint = `
+		SELECT id, tenant_id, url, event_types, secret, created_at
+		FROM webhook_endpoints
+		WHERE tenant_id = $1 AND id = $2`
+	pgDeleteWebhookEndpoint = `DELETE FROM webhook_endpoints WHERE tenant_id = $1 AND id = $2`
+	pgCreateWebhookDelivery = `
+		INSERT INTO webhook_deliveries (id, tenant_id, endpoint_id, event_id, event_type, payload, status,
+			attempts, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (endpoint_id, event_id) DO NOTHING`
+	pgGetWebhookDelivery = `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE tenant_id = $1 AND id = $2`
+	pgListWebhookDeliveries = `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE tenant_id = $1 AND endpoint_id = $2 AND ($3 = '' OR status = $3)
+		ORDER BY created_at DESC
+		LIMIT $4`
+	pgUpdateWebhookDelivery = `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5,
+			updated_at = $6, delivered_at = $7
+		WHERE tenant_id = $8 AND id = $9`
+	pgClaimDueWebhookDeliveries = `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+)
+
+// PostgresWebhookRepository implements the WebhookRepository interface using PostgreSQL
+type PostgresWebhookRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *PostgresOrderRepository
+}
+
+// NewPostgresWebhookRepository creates a new instance of PostgresWebhookRepository,
+// rowLevelSecurity has the same meaning as in NewPostgresOrderRepository
+func NewPostgresWebhookRepository(db *sql.DB, rowLevelSecurity bool) repository.WebhookRepository {
+	return &PostgresWebhookRepository{
+		orders: &PostgresOrderRepository{db: db, rowLevelSecurity: rowLevelSecurity},
+	}
+}
+
+// CreateEndpoint saves a new webhook endpoint to the database
+func (r *PostgresWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *entity.WebhookEndpoint) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	endpoint.TenantID = tenantID
+
+	_, err = q.ExecContext(ctx, pgCreateWebhookEndpoint, endpoint.ID, endpoint.TenantID, endpoint.URL,
+		pq.Array(endpoint.EventTypes), endpoint.Secret, endpoint.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating webhook endpoint: %w", err)
+	}
+
+	return nil
+}
+
+// ListEndpoints retrieves the webhook endpoints of the current tenant
+func (r *PostgresWebhookRepository) ListEndpoints(ctx context.Context) ([]*entity.WebhookEndpoint, error) {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.QueryContext(ctx, pgListWebhookEndpoints, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*entity.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook endpoints: %w", err)
+	}
+
+	return endpoints, nil
+}
+
+// GetEndpoint retrieves a webhook endpoint by its ID
+func (r *PostgresWebhookRepository) GetEndpoint(ctx context.Context, id string) (*entity.WebhookEndpoint, error) {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	endpoint, err := scanWebhookEndpoint(q.QueryRowContext(ctx, pgGetWebhookEndpoint, tenantID, endpointID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrWebhookEndpointNotFound
+		}
+		return nil, fmt.Errorf("error getting webhook endpoint: %w", err)
+	}
+
+	return endpoint, nil
+}
+
+// DeleteEndpoint removes a webhook endpoint, its deliveries are removed by the foreign key
+func (r *PostgresWebhookRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	result, err := q.ExecContext(ctx, pgDeleteWebhookEndpoint, tenantID, endpointID)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook endpoint: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrWebhookEndpointNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery saves a new delivery, ignoring events already queued for the endpoint
+func (r *PostgresWebhookRepository) CreateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	delivery.TenantID = tenantID
+
+	_, err = q.ExecContext(ctx, pgCreateWebhookDelivery, delivery.ID, delivery.TenantID, delivery.EndpointID,
+		delivery.EventID, delivery.EventType, string(delivery.Payload), delivery.Status, delivery.Attempts,
+		delivery.NextAttemptAt, delivery.CreatedAt, delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a webhook delivery by its ID
+func (r *PostgresWebhookRepository) GetDelivery(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, repository.ErrWebhookDeliveryNotFound
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	delivery, err := scanWebhookDelivery(q.QueryRowContext(ctx, pgGetWebhookDelivery, tenantID, deliveryID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("error getting webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ListDeliveries retrieves the most recent deliveries of an endpoint
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, endpointID string, status entity.WebhookDeliveryStatus, limit int) ([]*entity.WebhookDelivery, error) {
+	id, err := uuid.Parse(endpointID)
+	if err != nil {
+		return nil, repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := q.QueryContext(ctx, pgListWebhookDeliveries, tenantID, id, string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return collectWebhookDeliveries(rows)
+}
+
+// UpdateDelivery saves the status, attempts and last result of a delivery
+func (r *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	q, tenantID, release, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	result, err := q.ExecContext(ctx, pgUpdateWebhookDelivery, delivery.Status, delivery.Attempts,
+		delivery.NextAttemptAt, nullStatusCode(delivery.LastStatusCode), nullString(delivery.LastError),
+		delivery.UpdatedAt, delivery.DeliveredAt, tenantID, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrWebhookDeliveryNotFound
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries leases the due deliveries of every tenant, skipping rows
+// locked by other workers
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error) {
+	var q querier = newTracedQuerier(r.orders.db, "postgresql")
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		q = newTracedQuerier(tx, "postgresql")
+	}
+
+	rows, err := q.QueryContext(ctx, pgClaimDueWebhookDeliveries, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return collectWebhookDeliveries(rows)
+}
+
+// scanWebhookEndpoint scans a single webhook endpoint row
+func scanWebhookEndpoint(row rowScanner) (*entity.WebhookEndpoint, error) {
+	endpoint := &entity.WebhookEndpoint{}
+	err := row.Scan(&endpoint.ID, &endpoint.TenantID, &endpoint.URL, pq.Array(&endpoint.EventTypes),
+		&endpoint.Secret, &endpoint.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// scanWebhookDelivery scans a single webhook delivery row
+func scanWebhookDelivery(row rowScanner) (*entity.WebhookDelivery, error) {
+	delivery := &entity.WebhookDelivery{}
+	var lastStatusCode sql.NullInt64
+	var lastError sql.NullString
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(&delivery.ID, &delivery.TenantID, &delivery.EndpointID, &delivery.EventID, &delivery.EventType,
+		&delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &lastStatusCode,
+		&lastError, &delivery.CreatedAt, &delivery.UpdatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.LastStatusCode = int(lastStatusCode.Int64)
+	delivery.LastError = lastError.String
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
+
+// collectWebhookDeliveries scans every delivery of rows
+func collectWebhookDeliveries(rows *sql.Rows) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// nullStatusCode maps a missing HTTP status code to SQL NULL
+func nullStatusCode(code int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(code), Valid: code != 0}
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteOrderEventStore implements the OrderEventStore interface using SQLite
+type SQLiteOrderEventStore struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *SQLiteOrderRepository
+}
+
+// NewSQLiteOrderEventStore creates a new instance of SQLiteOrderEventStore
+func NewSQLiteOrderEventStore(db *sql.DB) repository.OrderEventStore {
+	return &SQLiteOrderEventStore{
+		orders: &SQLiteOrderRepository{db: db},
+	}
+}
+
+// Append saves a new event at the end of the stream of its order
+func (s *SQLiteOrderEventStore) Append(ctx context.Context, event *entity.OrderEvent) error {
+	q, tenantID, err := s.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	event.TenantID = tenantID
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("error encoding order event data: %w", err)
+	}
+
+	query := `
+		INSERT INTO order_events (id, tenant_id, order_id, sequence, type, data, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (order_id, sequence) DO NOTHING
+	`
+
+	result, err := q.ExecContext(ctx, query, event.ID.String(), event.TenantID, event.OrderID.String(), event.Sequence,
+		event.Type, string(data), formatSQLiteTime(event.OccurredAt))
+	if err != nil {
+		return fmt.Errorf("error appending order event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrOrderConcurrentModification
+	}
+
+	return nil
+}
+
+// Load retrieves the events of an order after the given sequence, oldest first
+func (s *SQLiteOrderEventStore) Load(ctx context.Context, orderID uuid.UUID, afterSequence int64) ([]*entity.OrderEvent, error) {
+	q, tenantID, err := s.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + orderEventColumns + `
+		FROM order_events
+		WHERE tenant_id = ? AND order_id = ? AND sequence > ?
+		ORDER BY sequence
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID, orderID.String(), afterSequence)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order events: %w", err)
+	}
+	defer rows.Close()
+
+	return collectSQLiteOrderEvents(rows)
+}
+
+// SaveSnapshot saves a snapshot of an order
+func (s *SQLiteOrderEventStore) SaveSnapshot(ctx context.Context, snapshot *entity.OrderSnapshot) error {
+	q, tenantID, err := s.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot.TenantID = tenantID
+
+	state, err := json.Marshal(snapshot.State)
+	if err != nil {
+		return fmt.Errorf("error encoding order snapshot: %w", err)
+	}
+
+	query := `
+		INSERT INTO order_snapshots (tenant_id, order_id, sequence, state, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (order_id, sequence) DO NOTHING
+	`
+
+	_, err = q.ExecContext(ctx, query, snapshot.TenantID, snapshot.OrderID.String(), snapshot.Sequence,
+		string(state), formatSQLiteTime(snapshot.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error saving order snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LatestSnapshot retrieves the most recent snapshot of an order, nil when there is none
+func (s *SQLiteOrderEventStore) LatestSnapshot(ctx context.Context, orderID uuid.UUID) (*entity.OrderSnapshot, error) {
+	q, tenantID, err := s.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT tenant_id, sequence, state, created_at
+		FROM order_snapshots
+		WHERE tenant_id = ? AND order_id = ?
+		ORDER BY sequence DESC
+		LIMIT 1
+	`
+
+	snapshot := &entity.OrderSnapshot{OrderID: orderID}
+	var state, createdAt string
+
+	err = q.QueryRowContext(ctx, query, tenantID, orderID.String()).
+		Scan(&snapshot.TenantID, &snapshot.Sequence, &state, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting order snapshot: %w", err)
+	}
+
+	if snapshot.CreatedAt, err = time.Parse(sqliteTimeFormat, createdAt); err != nil {
+		return nil, fmt.Errorf("invalid stored created_at %q: %w", createdAt, err)
+	}
+	if err := json.Unmarshal([]byte(state), &snapshot.State); err != nil {
+		return nil, fmt.Errorf("invalid stored order snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// collectSQLiteOrderEvents scans every event of rows
+func collectSQLiteOrderEvents(rows *sql.Rows) ([]*entity.OrderEvent, error) {
+	var events []*entity.OrderEvent
+	for rows.Next() {
+		event, err := scanSQLiteOrderEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order events: %w", err)
+	}
+
+	return events, nil
+}
+
+// scanSQLiteOrderEvent scans a single event row, parsing the text encoded IDs, data and timestamp
+func scanSQLiteOrderEvent(row rowScanner) (*entity.OrderEvent, error) {
+	event := &entity.OrderEvent{}
+	var id, orderID, data, occurredAt string
+
+	err := row.Scan(&id, &event.TenantID, &orderID, &event.Sequence, &event.Type, &data, &occurredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid stored event ID %q: %w", id, err)
+	}
+	if event.OrderID, err = uuid.Parse(orderID); err != nil {
+		return nil, fmt.Errorf("invalid stored order ID %q: %w", orderID, err)
+	}
+	if event.OccurredAt, err = time.Parse(sqliteTimeFormat, occurredAt); err != nil {
+		return nil, fmt.Errorf("invalid stored occurred_at %q: %w", occurredAt, err)
+	}
+	if err := json.Unmarshal([]byte(data), &event.Data); err != nil {
+		return nil, fmt.Errorf("invalid stored event data: %w", err)
+	}
+
+	return event, nil
+}
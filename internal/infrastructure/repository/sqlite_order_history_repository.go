@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteOrderHistoryRepository implements the OrderHistoryRepository interface using SQLite
+type SQLiteOrderHistoryRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *SQLiteOrderRepository
+}
+
+// NewSQLiteOrderHistoryRepository creates a new instance of SQLiteOrderHistoryRepository
+func NewSQLiteOrderHistoryRepository(db *sql.DB) repository.OrderHistoryRepository {
+	return &SQLiteOrderHistoryRepository{
+		orders: &SQLiteOrderRepository{db: db},
+	}
+}
+
+// Append saves a new history entry to the database
+func (r *SQLiteOrderHistoryRepository) Append(ctx context.Context, entry *entity.OrderHistoryEntry) error {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry.TenantID = tenantID
+
+	changes, err := marshalChanges(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO order_history (id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = q.ExecContext(ctx, query, entry.ID.String(), entry.TenantID, entry.OrderID.String(), entry.Action,
+		entry.Actor, entry.ClaimedActor, nullString(entry.RequestID), changes, formatSQLiteTime(entry.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating order history entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListByOrderID retrieves the history of an order, oldest first
+func (r *SQLiteOrderHistoryRepository) ListByOrderID(ctx context.Context, orderID string) ([]*entity.OrderHistoryEntry, error) {
+	id, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+	}
+
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at
+		FROM order_history
+		WHERE tenant_id = ? AND order_id = ?
+		ORDER BY created_at, id
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID, id.String())
+	if err != nil {
+		return nil, fmt.Errorf("error querying order history: %w", err)
+	}
+	defer rows.Close()
+
+	return collectSQLiteOrderHistory(rows)
+}
+
+// ListByOrderIDs retrieves the history of several orders, oldest first
+func (r *SQLiteOrderHistoryRepository) ListByOrderIDs(ctx context.Context, orderIDs []string) ([]*entity.OrderHistoryEntry, error) {
+	ids, err := parseOrderIDs(orderIDs)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The IDs are passed as a JSON array, so the statement does not depend on their number
+	idsJSON, err := json.Marshal(uuidStrings(ids))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding order IDs: %w", err)
+	}
+
+	query := `
+		SELECT id, tenant_id, order_id, action, actor, claimed_actor, request_id, changes, created_at
+		FROM order_history
+		WHERE tenant_id = ? AND order_id IN (SELECT value FROM json_each(?))
+		ORDER BY created_at, id
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID, string(idsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("error querying order history: %w", err)
+	}
+	defer rows.Close()
+
+	return collectSQLiteOrderHistory(rows)
+}
+
+// collectSQLiteOrderHistory scans every history entry of rows
+func collectSQLiteOrderHistory(rows *sql.Rows) ([]*entity.OrderHistoryEntry, error) {
+	var entries []*entity.OrderHistoryEntry
+	for rows.Next() {
+		entry, err := scanSQLiteOrderHistoryEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order history entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// scanSQLiteOrderHistoryEntry scans a single history row, parsing the text encoded IDs, changes and timestamp
+func scanSQLiteOrderHistoryEntry(row rowScanner) (*entity.OrderHistoryEntry, error) {
+	entry := &entity.OrderHistoryEntry{}
+	var id, orderID, changes, createdAt string
+	var requestID sql.NullString
+
+	err := row.Scan(&id, &entry.TenantID, &orderID, &entry.Action, &entry.Actor, &entry.ClaimedActor, &requestID, &changes, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid stored entry ID %q: %w", id, err)
+	}
+	if entry.OrderID, err = uuid.Parse(orderID); err != nil {
+		return nil, fmt.Errorf("invalid stored order ID %q: %w", orderID, err)
+	}
+	if entry.CreatedAt, err = time.Parse(sqliteTimeFormat, createdAt); err != nil {
+		return nil, fmt.Errorf("invalid stored created_at %q: %w", createdAt, err)
+	}
+	if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+		return nil, fmt.Errorf("invalid stored changes: %w", err)
+	}
+
+	entry.RequestID = requestID.String
+	return entry, nil
+}
@@ -0,0 +1,395 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// sqliteTimeFormat is fixed width so timestamps stored as text sort chronologically
+const sqliteTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// SQLiteOrderRepository implements the OrderRepository interface using SQLite
+type SQLiteOrderRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteOrderRepository creates a new instance of SQLiteOrderRepository
+func NewSQLiteOrderRepository(db *sql.DB) repository.OrderRepository {
+	return &SQLiteOrderRepository{
+		db: db,
+	}
+}
+
+// scope returns the tenant of ctx and a querier for it, which is the
+// transaction of ctx when the call runs inside TransactionManager
+func (r *SQLiteOrderRepository) scope(ctx context.Context) (querier, string, error) {
+	tenantID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		return newTracedQuerier(tx, "sqlite"), tenantID, nil
+	}
+
+	return newTracedQuerier(r.db, "sqlite"), tenantID, nil
+}
+
+// Create saves a new order to the database
+func (r *SQLiteOrderRepository) Create(ctx context.Context, order *entity.Order) error {
+	q, tenantID, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	order.TenantID = tenantID
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO orders (id, tenant_id, description, items, currency, total_amount, status, idempotency_key,
+			created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant_id, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+	`
+
+	result, err := q.ExecContext(ctx, query, order.ID.String(), order.TenantID, order.Description, items,
+		order.Currency, order.TotalAmount, order.Status, nullString(order.IdempotencyKey),
+		formatSQLiteTime(order.CreatedAt), formatSQLiteTime(order.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrDuplicateIdempotencyKey
+	}
+
+	return nil
+}
+
+// List retrieves the orders of the current tenant from the database
+func (r *SQLiteOrderRepository) List(ctx context.Context, options repository.ListOptions) ([]*entity.Order, error) {
+	q, tenantID, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
+		FROM orders
+		WHERE tenant_id = ? AND (? OR deleted_at IS NULL)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID, options.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*entity.Order
+	for rows.Next() {
+		order, err := scanSQLiteOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// GetByID retrieves an order by its ID
+func (r *SQLiteOrderRepository) GetByID(ctx context.Context, id string) (*entity.Order, error) {
+	return r.getByID(ctx, id, false)
+}
+
+// GetByIDIncludingDeleted retrieves an order by its ID even when it is soft deleted
+func (r *SQLiteOrderRepository) GetByIDIncludingDeleted(ctx context.Context, id string) (*entity.Order, error) {
+	return r.getByID(ctx, id, true)
+}
+
+// getByID retrieves an order by its ID, soft deleted ones only when includeDeleted is set
+func (r *SQLiteOrderRepository) getByID(ctx context.Context, id string, includeDeleted bool) (*entity.Order, error) {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+	}
+
+	q, tenantID, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
+		FROM orders
+		WHERE tenant_id = ? AND id = ? AND (? OR deleted_at IS NULL)
+	`
+
+	order, err := scanSQLiteOrder(q.QueryRowContext(ctx, query, tenantID, orderID.String(), includeDeleted))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("error getting order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetByIdempotencyKey retrieves an order by the idempotency key it was created with
+func (r *SQLiteOrderRepository) GetByIdempotencyKey(ctx context.Context, key string) (*entity.Order, error) {
+	q, tenantID, err := r.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, tenant_id, description, items, currency, total_amount, status, idempotency_key, created_at,
+			updated_at, deleted_at
+		FROM orders
+		WHERE tenant_id = ? AND idempotency_key = ?
+	`
+
+	order, err := scanSQLiteOrder(q.QueryRowContext(ctx, query, tenantID, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("error getting order: %w", err)
+	}
+
+	return order, nil
+}
+
+// Update updates an existing order in the database
+func (r *SQLiteOrderRepository) Update(ctx context.Context, order *entity.Order) error {
+	q, tenantID, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE orders
+		SET description = ?, items = ?, total_amount = ?, status = ?, updated_at = ?
+		WHERE tenant_id = ? AND id = ? AND deleted_at IS NULL
+	`
+
+	result, err := q.ExecContext(ctx, query, order.Description, items, order.TotalAmount, order.Status,
+		formatSQLiteTime(order.UpdatedAt), tenantID, order.ID.String())
+	if err != nil {
+		return fmt.Errorf("error updating order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrOrderNotFound
+	}
+
+	return nil
+}
+
+// Delete soft deletes an order by setting deleted_at
+func (r *SQLiteOrderRepository) Delete(ctx context.Context, id string) error {
+	now := formatSQLiteTime(time.Now())
+	query := `
+		UPDATE orders
+		SET deleted_at = ?, updated_at = ?
+		WHERE tenant_id = ? AND id = ? AND deleted_at IS NULL
+	`
+	return r.execByID(ctx, "deleting", query, id, now, now)
+}
+
+// Restore clears deleted_at of a soft deleted order
+func (r *SQLiteOrderRepository) Restore(ctx context.Context, id string) error {
+	query := `
+		UPDATE orders
+		SET deleted_at = NULL, updated_at = ?
+		WHERE tenant_id = ? AND id = ? AND deleted_at IS NOT NULL
+	`
+	return r.execByID(ctx, "restoring", query, id, formatSQLiteTime(time.Now()))
+}
+
+// Purge permanently removes a soft deleted order
+func (r *SQLiteOrderRepository) Purge(ctx context.Context, id string) error {
+	query := `DELETE FROM orders WHERE tenant_id = ? AND id = ? AND deleted_at IS NOT NULL`
+	return r.execByID(ctx, "purging", query, id)
+}
+
+// ListDeletedBefore returns the orders of every tenant soft deleted before the given time
+func (r *SQLiteOrderRepository) ListDeletedBefore(ctx context.Context, before time.Time) ([]repository.DeletedOrder, error) {
+	var q querier = newTracedQuerier(r.db, "sqlite")
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		q = newTracedQuerier(tx, "sqlite")
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT tenant_id, id FROM orders
+		WHERE deleted_at IS NOT NULL AND deleted_at < ?
+		ORDER BY deleted_at`, formatSQLiteTime(before))
+	if err != nil {
+		return nil, fmt.Errorf("error listing deleted orders: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDeletedOrders(rows)
+}
+
+// Upsert saves the complete state of an order, so the table serves as the
+// projection of event-sourced orders
+func (r *SQLiteOrderRepository) Upsert(ctx context.Context, order *entity.Order) error {
+	q, tenantID, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	order.TenantID = tenantID
+
+	items, err := marshalItems(order.Items)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO orders (id, tenant_id, description, items, currency, total_amount, status, idempotency_key,
+			created_at, updated_at, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE
+		SET description = excluded.description, items = excluded.items, currency = excluded.currency,
+			total_amount = excluded.total_amount, status = excluded.status,
+			idempotency_key = excluded.idempotency_key, updated_at = excluded.updated_at,
+			deleted_at = excluded.deleted_at
+		WHERE orders.tenant_id = excluded.tenant_id
+	`
+
+	var deletedAt sql.NullString
+	if order.DeletedAt != nil {
+		deletedAt = sql.NullString{String: formatSQLiteTime(*order.DeletedAt), Valid: true}
+	}
+
+	_, err = q.ExecContext(ctx, query, order.ID.String(), order.TenantID, order.Description, items, order.Currency,
+		order.TotalAmount, order.Status, nullString(order.IdempotencyKey), formatSQLiteTime(order.CreatedAt),
+		formatSQLiteTime(order.UpdatedAt), deletedAt)
+	if err != nil {
+		return fmt.Errorf("error upserting order: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the row of an order whether or not it is soft deleted
+func (r *SQLiteOrderRepository) Remove(ctx context.Context, id uuid.UUID) error {
+	q, tenantID, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.ExecContext(ctx, `DELETE FROM orders WHERE tenant_id = ? AND id = ?`, tenantID, id.String()); err != nil {
+		return fmt.Errorf("error removing order: %w", err)
+	}
+
+	return nil
+}
+
+// execByID runs a statement affecting a single order of the current tenant. The
+// statement takes args first, then the tenant and the order ID as the last two parameters.
+func (r *SQLiteOrderRepository) execByID(ctx context.Context, action, query, id string, args ...any) error {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+	}
+
+	q, tenantID, err := r.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.ExecContext(ctx, query, append(args, tenantID, orderID.String())...)
+	if err != nil {
+		return fmt.Errorf("error %s order: %w", action, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrOrderNotFound
+	}
+
+	return nil
+}
+
+// scanSQLiteOrder scans a single order row, parsing the text encoded ID, items and timestamps
+func scanSQLiteOrder(row rowScanner) (*entity.Order, error) {
+	order := &entity.Order{}
+	var id, items, createdAt, updatedAt string
+	var idempotencyKey, deletedAt sql.NullString
+
+	err := row.Scan(&id, &order.TenantID, &order.Description, &items, &order.Currency, &order.TotalAmount,
+		&order.Status, &idempotencyKey, &createdAt, &updatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Items, err = unmarshalItems([]byte(items)); err != nil {
+		return nil, err
+	}
+	if order.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid stored order ID %q: %w", id, err)
+	}
+	if order.CreatedAt, err = time.Parse(sqliteTimeFormat, createdAt); err != nil {
+		return nil, fmt.Errorf("invalid stored created_at %q: %w", createdAt, err)
+	}
+	if order.UpdatedAt, err = time.Parse(sqliteTimeFormat, updatedAt); err != nil {
+		return nil, fmt.Errorf("invalid stored updated_at %q: %w", updatedAt, err)
+	}
+	if deletedAt.Valid {
+		t, err := time.Parse(sqliteTimeFormat, deletedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored deleted_at %q: %w", deletedAt.String, err)
+		}
+		order.DeletedAt = &t
+	}
+
+	order.IdempotencyKey = idempotencyKey.String
+	return order, nil
+}
+
+// formatSQLiteTime formats t in UTC with sqliteTimeFormat
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
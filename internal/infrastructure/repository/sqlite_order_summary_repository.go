@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteOrderSummaryRepository implements the OrderSummaryRepository interface using SQLite
+type SQLiteOrderSummaryRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *SQLiteOrderRepository
+}
+
+// NewSQLiteOrderSummaryRepository creates a new instance of SQLiteOrderSummaryRepository
+func NewSQLiteOrderSummaryRepository(db *sql.DB) repository.OrderSummaryRepository {
+	return &SQLiteOrderSummaryRepository{
+		orders: &SQLiteOrderRepository{db: db},
+	}
+}
+
+// List retrieves the order summaries of the current tenant, newest first
+func (r *SQLiteOrderSummaryRepository) List(ctx context.Context, options repository.ListOptions) ([]*entity.OrderSummary, error) {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + orderSummaryColumns + `
+		FROM order_summaries
+		WHERE tenant_id = ? AND (? OR deleted_at IS NULL)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID, options.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []*entity.OrderSummary
+	for rows.Next() {
+		summary, err := scanSQLiteOrderSummary(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning order summary: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// Search retrieves the order summaries of the current tenant matching query.
+// SQLite has no text index here, the summaries of the tenant are scanned in memory.
+func (r *SQLiteOrderSummaryRepository) Search(ctx context.Context, query entity.SearchQuery, options repository.SearchOptions) ([]*entity.OrderSearchResult, error) {
+	summaries, err := r.List(ctx, repository.ListOptions{IncludeDeleted: options.IncludeDeleted})
+	if err != nil {
+		return nil, err
+	}
+
+	return searchInMemory(summaries, query, options.Limit), nil
+}
+
+// Stats counts and sums the orders of the current tenant per period, status and
+// currency. SQLite has no time zone database, so SQL aggregates the orders per
+// UTC minute, which every zone offset preserves, and the minutes are bucketed
+// in the query's zone.
+func (r *SQLiteOrderSummaryRepository) Stats(ctx context.Context, query entity.OrderStatsQuery) ([]*entity.OrderStatsRow, error) {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statsQuery := `
+		SELECT substr(created_at, 1, 16) AS minute, status, currency, COUNT(*), SUM(total_amount)
+		FROM order_summaries
+		WHERE tenant_id = ? AND created_at >= ? AND created_at < ? AND (? OR deleted_at IS NULL)
+		GROUP BY minute, status, currency
+		ORDER BY minute, status, currency
+	`
+
+	rows, err := q.QueryContext(ctx, statsQuery, tenantID, formatSQLiteTime(query.From), formatSQLiteTime(query.To),
+		query.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying order stats: %w", err)
+	}
+	defer rows.Close()
+
+	type statsKey struct {
+		bucket   time.Time
+		status   entity.OrderStatus
+		currency string
+	}
+	var stats []*entity.OrderStatsRow
+	totals := make(map[statsKey]*entity.OrderStatsRow)
+	for rows.Next() {
+		var minute, currency string
+		var status entity.OrderStatus
+		var count, amount int64
+		if err := rows.Scan(&minute, &status, &currency, &count, &amount); err != nil {
+			return nil, fmt.Errorf("error scanning order stats: %w", err)
+		}
+
+		t, err := time.Parse("2006-01-02T15:04", minute)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored created_at %q: %w", minute, err)
+		}
+
+		// Minutes come in order, so buckets are appended in order too
+		key := statsKey{query.GroupBy.Truncate(t, query.Location), status, currency}
+		row, ok := totals[key]
+		if !ok {
+			row = &entity.OrderStatsRow{Bucket: key.bucket, Status: status, Currency: currency}
+			totals[key] = row
+			stats = append(stats, row)
+		}
+		row.Count += count
+		row.Amount += amount
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Find retrieves the summary of an order, nil when there is none
+func (r *SQLiteOrderSummaryRepository) Find(ctx context.Context, id uuid.UUID) (*entity.OrderSummary, error) {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + orderSummaryColumns + `
+		FROM order_summaries
+		WHERE tenant_id = ? AND id = ?
+	`
+
+	summary, err := scanSQLiteOrderSummary(q.QueryRowContext(ctx, query, tenantID, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting order summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+// Save inserts or replaces the summary of an order
+func (r *SQLiteOrderSummaryRepository) Save(ctx context.Context, summary *entity.OrderSummary) error {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	summary.TenantID = tenantID
+
+	items, err := marshalItems(summary.Items)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO order_summaries (` + orderSummaryColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE
+		SET description = excluded.description, items = excluded.items, currency = excluded.currency,
+			total_amount = excluded.total_amount, status = excluded.status, updated_at = excluded.updated_at,
+			deleted_at = excluded.deleted_at, version = excluded.version, created_by = excluded.created_by,
+			last_action = excluded.last_action, last_actor = excluded.last_actor,
+			last_changed_at = excluded.last_changed_at, status_changed_at = excluded.status_changed_at
+		WHERE order_summaries.tenant_id = excluded.tenant_id
+	`
+
+	var deletedAt sql.NullString
+	if summary.DeletedAt != nil {
+		deletedAt = sql.NullString{String: formatSQLiteTime(*summary.DeletedAt), Valid: true}
+	}
+
+	_, err = q.ExecContext(ctx, query, summary.ID.String(), summary.TenantID, summary.Description, items,
+		summary.Currency, summary.TotalAmount, summary.Status, formatSQLiteTime(summary.CreatedAt), formatSQLiteTime(summary.UpdatedAt), deletedAt, summary.Version,
+		summary.CreatedBy, summary.LastAction, summary.LastActor, formatSQLiteTime(summary.LastChangedAt),
+		formatSQLiteTime(summary.StatusChangedAt))
+	if err != nil {
+		return fmt.Errorf("error saving order summary: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the summary of an order
+func (r *SQLiteOrderSummaryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM order_summaries WHERE tenant_id = ? AND id = ?`
+	if _, err := q.ExecContext(ctx, query, tenantID, id.String()); err != nil {
+		return fmt.Errorf("error deleting order summary: %w", err)
+	}
+
+	return nil
+}
+
+// Rebuild replaces the summaries of every tenant, run it inside a transaction
+// so readers keep seeing the previous summaries until it commits
+func (r *SQLiteOrderSummaryRepository) Rebuild(ctx context.Context) (int64, error) {
+	var q querier = newTracedQuerier(r.orders.db, "sqlite")
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		q = newTracedQuerier(tx, "sqlite")
+	}
+
+	return rebuildOrderSummaries(ctx, q)
+}
+
+// scanSQLiteOrderSummary scans a single order summary row, parsing the text encoded ID, items and timestamps
+func scanSQLiteOrderSummary(row rowScanner) (*entity.OrderSummary, error) {
+	summary := &entity.OrderSummary{}
+	var id, items, createdAt, updatedAt, lastChangedAt, statusChangedAt string
+	var deletedAt sql.NullString
+
+	err := row.Scan(&id, &summary.TenantID, &summary.Description, &items, &summary.Currency, &summary.TotalAmount,
+		&summary.Status, &createdAt, &updatedAt, &deletedAt, &summary.Version, &summary.CreatedBy, &summary.LastAction, &summary.LastActor,
+		&lastChangedAt, &statusChangedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if summary.Items, err = unmarshalItems([]byte(items)); err != nil {
+		return nil, err
+	}
+	if summary.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid stored order ID %q: %w", id, err)
+	}
+
+	timestamps := []struct {
+		name  string
+		value string
+		dest  *time.Time
+	}{
+		{"created_at", createdAt, &summary.CreatedAt},
+		{"updated_at", updatedAt, &summary.UpdatedAt},
+		{"last_changed_at", lastChangedAt, &summary.LastChangedAt},
+		{"status_changed_at", statusChangedAt, &summary.StatusChangedAt},
+	}
+	for _, ts := range timestamps {
+		if *ts.dest, err = time.Parse(sqliteTimeFormat, ts.value); err != nil {
+			return nil, fmt.Errorf("invalid stored %s %q: %w", ts.name, ts.value, err)
+		}
+	}
+	if deletedAt.Valid {
+		t, err := time.Parse(sqliteTimeFormat, deletedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored deleted_at %q: %w", deletedAt.String, err)
+		}
+		summary.DeletedAt = &t
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,393 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteWebhookRepository implements the WebhookRepository interface using SQLite
+type SQLiteWebhookRepository struct {
+	// orders provides the tenant scope shared with the order repository
+	orders *SQLiteOrderRepository
+}
+
+// NewSQLiteWebhookRepository creates a new instance of SQLiteWebhookRepository
+func NewSQLiteWebhookRepository(db *sql.DB) repository.WebhookRepository {
+	return &SQLiteWebhookRepository{
+		orders: &SQLiteOrderRepository{db: db},
+	}
+}
+
+// CreateEndpoint saves a new webhook endpoint to the database
+func (r *SQLiteWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *entity.WebhookEndpoint) error {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	endpoint.TenantID = tenantID
+
+	eventTypes, err := json.Marshal(endpoint.EventTypes)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook event types: %w", err)
+	}
+
+	query := `
+		INSERT INTO webhook_endpoints (id, tenant_id, url, event_types, secret, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = q.ExecContext(ctx, query, endpoint.ID.String(), endpoint.TenantID, endpoint.URL, string(eventTypes),
+		endpoint.Secret, formatSQLiteTime(endpoint.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating webhook endpoint: %w", err)
+	}
+
+	return nil
+}
+
+// ListEndpoints retrieves the webhook endpoints of the current tenant
+func (r *SQLiteWebhookRepository) ListEndpoints(ctx context.Context) ([]*entity.WebhookEndpoint, error) {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, tenant_id, url, event_types, secret, created_at
+		FROM webhook_endpoints
+		WHERE tenant_id = ?
+		ORDER BY created_at
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*entity.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanSQLiteWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook endpoints: %w", err)
+	}
+
+	return endpoints, nil
+}
+
+// GetEndpoint retrieves a webhook endpoint by its ID
+func (r *SQLiteWebhookRepository) GetEndpoint(ctx context.Context, id string) (*entity.WebhookEndpoint, error) {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, tenant_id, url, event_types, secret, created_at
+		FROM webhook_endpoints
+		WHERE tenant_id = ? AND id = ?
+	`
+
+	endpoint, err := scanSQLiteWebhookEndpoint(q.QueryRowContext(ctx, query, tenantID, endpointID.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrWebhookEndpointNotFound
+		}
+		return nil, fmt.Errorf("error getting webhook endpoint: %w", err)
+	}
+
+	return endpoint, nil
+}
+
+// DeleteEndpoint removes a webhook endpoint, its deliveries are removed by the foreign key
+func (r *SQLiteWebhookRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE tenant_id = ? AND id = ?`,
+		tenantID, endpointID.String())
+	if err != nil {
+		return fmt.Errorf("error deleting webhook endpoint: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrWebhookEndpointNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery saves a new delivery, ignoring events already queued for the endpoint
+func (r *SQLiteWebhookRepository) CreateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	delivery.TenantID = tenantID
+
+	query := `
+		INSERT INTO webhook_deliveries (id, tenant_id, endpoint_id, event_id, event_type, payload, status,
+			attempts, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (endpoint_id, event_id) DO NOTHING
+	`
+
+	_, err = q.ExecContext(ctx, query, delivery.ID.String(), delivery.TenantID, delivery.EndpointID.String(),
+		delivery.EventID.String(), delivery.EventType, string(delivery.Payload), delivery.Status, delivery.Attempts,
+		formatSQLiteTime(delivery.NextAttemptAt), formatSQLiteTime(delivery.CreatedAt), formatSQLiteTime(delivery.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a webhook delivery by its ID
+func (r *SQLiteWebhookRepository) GetDelivery(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, repository.ErrWebhookDeliveryNotFound
+	}
+
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE tenant_id = ? AND id = ?`
+
+	delivery, err := scanSQLiteWebhookDelivery(q.QueryRowContext(ctx, query, tenantID, deliveryID.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("error getting webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ListDeliveries retrieves the most recent deliveries of an endpoint
+func (r *SQLiteWebhookRepository) ListDeliveries(ctx context.Context, endpointID string, status entity.WebhookDeliveryStatus, limit int) ([]*entity.WebhookDelivery, error) {
+	id, err := uuid.Parse(endpointID)
+	if err != nil {
+		return nil, repository.ErrWebhookEndpointNotFound
+	}
+
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE tenant_id = ? AND endpoint_id = ? AND (? = '' OR status = ?)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+
+	rows, err := q.QueryContext(ctx, query, tenantID, id.String(), string(status), string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return collectSQLiteWebhookDeliveries(rows)
+}
+
+// UpdateDelivery saves the status, attempts and last result of a delivery
+func (r *SQLiteWebhookRepository) UpdateDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	q, tenantID, err := r.orders.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	var deliveredAt sql.NullString
+	if delivery.DeliveredAt != nil {
+		deliveredAt = sql.NullString{String: formatSQLiteTime(*delivery.DeliveredAt), Valid: true}
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?,
+			updated_at = ?, delivered_at = ?
+		WHERE tenant_id = ? AND id = ?
+	`
+
+	result, err := q.ExecContext(ctx, query, delivery.Status, delivery.Attempts, formatSQLiteTime(delivery.NextAttemptAt),
+		nullStatusCode(delivery.LastStatusCode), nullString(delivery.LastError), formatSQLiteTime(delivery.UpdatedAt),
+		deliveredAt, tenantID, delivery.ID.String())
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrWebhookDeliveryNotFound
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries leases the due deliveries of every tenant. SQLite serializes
+// writers, so the UPDATE alone keeps concurrent workers from claiming the same rows.
+func (r *SQLiteWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error) {
+	var q querier = newTracedQuerier(r.orders.db, "sqlite")
+	if tx, ok := sqlTxFromContext(ctx); ok {
+		q = newTracedQuerier(tx, "sqlite")
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	rows, err := q.QueryContext(ctx, query, formatSQLiteTime(now.Add(lease)), formatSQLiteTime(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return collectSQLiteWebhookDeliveries(rows)
+}
+
+// scanSQLiteWebhookEndpoint scans a single webhook endpoint row, decoding the JSON event types
+func scanSQLiteWebhookEndpoint(row rowScanner) (*entity.WebhookEndpoint, error) {
+	endpoint := &entity.WebhookEndpoint{}
+	var id, eventTypes, createdAt string
+
+	err := row.Scan(&id, &endpoint.TenantID, &endpoint.URL, &eventTypes, &endpoint.Secret, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid stored endpoint ID %q: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(eventTypes), &endpoint.EventTypes); err != nil {
+		return nil, fmt.Errorf("invalid stored event types %q: %w", eventTypes, err)
+	}
+	if endpoint.CreatedAt, err = time.Parse(sqliteTimeFormat, createdAt); err != nil {
+		return nil, fmt.Errorf("invalid stored created_at %q: %w", createdAt, err)
+	}
+
+	return endpoint, nil
+}
+
+// scanSQLiteWebhookDelivery scans a single webhook delivery row, parsing the text encoded IDs and timestamps
+func scanSQLiteWebhookDelivery(row rowScanner) (*entity.WebhookDelivery, error) {
+	delivery := &entity.WebhookDelivery{}
+	var id, endpointID, eventID, payload, nextAttemptAt, createdAt, updatedAt string
+	var lastStatusCode sql.NullInt64
+	var lastError, deliveredAt sql.NullString
+
+	err := row.Scan(&id, &delivery.TenantID, &endpointID, &eventID, &delivery.EventType, &payload,
+		&delivery.Status, &delivery.Attempts, &nextAttemptAt, &lastStatusCode, &lastError,
+		&createdAt, &updatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *uuid.UUID
+	}{
+		{"ID", id, &delivery.ID},
+		{"endpoint ID", endpointID, &delivery.EndpointID},
+		{"event ID", eventID, &delivery.EventID},
+	} {
+		if *field.dest, err = uuid.Parse(field.value); err != nil {
+			return nil, fmt.Errorf("invalid stored %s %q: %w", field.name, field.value, err)
+		}
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Time
+	}{
+		{"next_attempt_at", nextAttemptAt, &delivery.NextAttemptAt},
+		{"created_at", createdAt, &delivery.CreatedAt},
+		{"updated_at", updatedAt, &delivery.UpdatedAt},
+	} {
+		if *field.dest, err = time.Parse(sqliteTimeFormat, field.value); err != nil {
+			return nil, fmt.Errorf("invalid stored %s %q: %w", field.name, field.value, err)
+		}
+	}
+
+	if deliveredAt.Valid {
+		t, err := time.Parse(sqliteTimeFormat, deliveredAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored delivered_at %q: %w", deliveredAt.String, err)
+		}
+		delivery.DeliveredAt = &t
+	}
+
+	delivery.Payload = []byte(payload)
+	delivery.LastStatusCode = int(lastStatusCode.Int64)
+	delivery.LastError = lastError.String
+	return delivery, nil
+}
+
+// collectSQLiteWebhookDeliveries scans every delivery of rows
+func collectSQLiteWebhookDeliveries(rows *sql.Rows) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanSQLiteWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"curso-go-clean-arch/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedQuerier wraps a querier and starts a client span for every SQL statement
+type tracedQuerier struct {
+	querier
+	system string
+}
+
+// newTracedQuerier creates a querier that traces statements sent to the given database system
+func newTracedQuerier(q querier, system string) querier {
+	return &tracedQuerier{querier: q, system: system}
+}
+
+// ExecContext traces a statement that returns no rows
+func (q *tracedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := q.start(ctx, query)
+	defer span.End()
+
+	result, err := q.querier.ExecContext(ctx, query, args...)
+	if err == nil {
+		if rows, rowsErr := result.RowsAffected(); rowsErr == nil {
+			span.SetAttributes(attribute.Int64("db.rows_affected", rows))
+		}
+	}
+	endSpan(span, err)
+	return result, err
+}
+
+// QueryContext traces a statement that returns rows
+func (q *tracedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := q.start(ctx, query)
+	defer span.End()
+
+	rows, err := q.querier.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+// QueryRowContext traces a statement that returns at most one row
+func (q *tracedQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := q.start(ctx, query)
+	defer span.End()
+
+	row := q.querier.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+// start opens a client span named after the SQL operation
+func (q *tracedQuerier) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	return startQuerySpan(ctx, q.system, query)
+}
+
+// startQuerySpan opens a client span for a statement sent to the given database system
+func startQuerySpan(ctx context.Context, system, query string) (context.Context, trace.Span) {
+	query = strings.Join(strings.Fields(query), " ")
+
+	operation, _, _ := strings.Cut(query, " ")
+	return tracing.Tracer().Start(ctx, system+" "+strings.ToUpper(operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", system),
+			attribute.String("db.operation.name", strings.ToUpper(operation)),
+			attribute.String("db.query.text", query),
+		),
+	)
+}
+
+// endSpan records a statement error on the span
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sqlTxKey and pgxTxKey store the active transaction in the context
+type (
+	sqlTxKey struct{}
+	pgxTxKey struct{}
+)
+
+// sqlTx is the database/sql transaction carried in the context, depth counts open savepoints
+type sqlTx struct {
+	tx          *sql.Tx
+	depth       int
+	afterCommit []func(ctx context.Context)
+}
+
+// SQLTransactionManager implements TransactionManager for database/sql, used with
+// PostgresOrderRepository and SQLiteOrderRepository
+type SQLTransactionManager struct {
+	db               *sql.DB
+	rowLevelSecurity bool
+}
+
+// NewSQLTransactionManager creates a transaction manager for db. With
+// rowLevelSecurity, app.tenant_id is set for the transaction.
+func NewSQLTransactionManager(db *sql.DB, rowLevelSecurity bool) repository.TransactionManager {
+	return &SQLTransactionManager{
+		db:               db,
+		rowLevelSecurity: rowLevelSecurity,
+	}
+}
+
+// WithinTransaction runs fn in a transaction, or in a savepoint when ctx already carries one
+func (m *SQLTransactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if current, ok := ctx.Value(sqlTxKey{}).(*sqlTx); ok {
+		return m.withinSavepoint(ctx, current, fn)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	current := &sqlTx{tx: tx}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("error committing transaction: %w", commitErr)
+			return
+		}
+		for _, fn := range current.afterCommit {
+			fn(ctx)
+		}
+	}()
+
+	if m.rowLevelSecurity {
+		if tenantID, ok := tenant.FromContext(ctx); ok {
+			// is_local = true scopes the setting to this transaction
+			if _, err := tx.ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenantID); err != nil {
+				return fmt.Errorf("error setting tenant on transaction: %w", err)
+			}
+		}
+	}
+
+	return fn(context.WithValue(ctx, sqlTxKey{}, current))
+}
+
+// withinSavepoint runs a nested unit of work so its failure only undoes its own changes
+func (m *SQLTransactionManager) withinSavepoint(ctx context.Context, current *sqlTx, fn func(ctx context.Context) error) (err error) {
+	nested := &sqlTx{tx: current.tx, depth: current.depth + 1}
+	savepoint := fmt.Sprintf("sp_%d", nested.depth)
+
+	if _, err := current.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return fmt.Errorf("error creating savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			current.tx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(p)
+		}
+		if err != nil {
+			current.tx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+savepoint)
+			return
+		}
+		if _, releaseErr := current.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); releaseErr != nil {
+			err = fmt.Errorf("error releasing savepoint: %w", releaseErr)
+			return
+		}
+		// Callbacks of a released savepoint run when the outer transaction commits
+		current.afterCommit = append(current.afterCommit, nested.afterCommit...)
+	}()
+
+	return fn(context.WithValue(ctx, sqlTxKey{}, nested))
+}
+
+// AfterCommit runs fn once the transaction of ctx commits, or immediately without one
+func (m *SQLTransactionManager) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	current, ok := ctx.Value(sqlTxKey{}).(*sqlTx)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	current.afterCommit = append(current.afterCommit, fn)
+}
+
+// sqlTxFromContext returns the database/sql transaction of ctx, if any
+func sqlTxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	current, ok := ctx.Value(sqlTxKey{}).(*sqlTx)
+	if !ok {
+		return nil, false
+	}
+	return current.tx, true
+}
+
+// pgxTx is the pgx transaction carried in the context, a savepoint when nested
+type pgxTx struct {
+	tx          pgx.Tx
+	afterCommit []func(ctx context.Context)
+}
+
+// PgxTransactionManager implements TransactionManager for pgx, used with PgxOrderRepository
+type PgxTransactionManager struct {
+	pool             *pgxpool.Pool
+	rowLevelSecurity bool
+}
+
+// NewPgxTransactionManager creates a transaction manager for pool. With
+// rowLevelSecurity, app.tenant_id is set for the transaction.
+func NewPgxTransactionManager(pool *pgxpool.Pool, rowLevelSecurity bool) repository.TransactionManager {
+	return &PgxTransactionManager{
+		pool:             pool,
+		rowLevelSecurity: rowLevelSecurity,
+	}
+}
+
+// WithinTransaction runs fn in a transaction, or in a savepoint when ctx already carries one
+func (m *PgxTransactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	var tx pgx.Tx
+	current, nested := ctx.Value(pgxTxKey{}).(*pgxTx)
+	if nested {
+		// Begin on a transaction creates a savepoint
+		tx, err = current.tx.Begin(ctx)
+	} else {
+		tx, err = m.pool.Begin(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	scope := &pgxTx{tx: tx}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(context.Background())
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback(context.Background())
+			return
+		}
+		if commitErr := tx.Commit(ctx); commitErr != nil {
+			err = fmt.Errorf("error committing transaction: %w", commitErr)
+			return
+		}
+		if nested {
+			// Callbacks of a released savepoint run when the outer transaction commits
+			current.afterCommit = append(current.afterCommit, scope.afterCommit...)
+			return
+		}
+		for _, fn := range scope.afterCommit {
+			fn(ctx)
+		}
+	}()
+
+	if m.rowLevelSecurity && !nested {
+		if tenantID, ok := tenant.FromContext(ctx); ok {
+			// is_local = true scopes the setting to this transaction
+			if _, err := tx.Exec(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenantID); err != nil {
+				return fmt.Errorf("error setting tenant on transaction: %w", err)
+			}
+		}
+	}
+
+	return fn(context.WithValue(ctx, pgxTxKey{}, scope))
+}
+
+// AfterCommit runs fn once the transaction of ctx commits, or immediately without one
+func (m *PgxTransactionManager) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	current, ok := ctx.Value(pgxTxKey{}).(*pgxTx)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	current.afterCommit = append(current.afterCommit, fn)
+}
+
+// pgxTxFromContext returns the pgx transaction of ctx, if any
+func pgxTxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	current, ok := ctx.Value(pgxTxKey{}).(*pgxTx)
+	if !ok {
+		return nil, false
+	}
+	return current.tx, true
+}
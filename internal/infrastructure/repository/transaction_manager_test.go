@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"curso-go-clean-arch/internal/database"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// errUnitOfWork fails a unit of work on purpose
+var errUnitOfWork = errors.New("unit of work failed")
+
+// TestTransactionManager runs the same checks against every TransactionManager
+// implementation, PostgreSQL ones only when postgresDSNEnv is set
+func TestTransactionManager(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) (repository.OrderRepository, repository.TransactionManager)
+	}{
+		{"sqlite", openSQLiteTransactionManager},
+		{"postgres", openPostgresTransactionManager},
+		{"pgx", openPgxTransactionManager},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			repo, tm := backend.open(t)
+
+			t.Run("Commit", func(t *testing.T) { testTransactionCommit(t, repo, tm) })
+			t.Run("RollbackOnError", func(t *testing.T) { testTransactionRollbackOnError(t, repo, tm) })
+			t.Run("RollbackOnPanic", func(t *testing.T) { testTransactionRollbackOnPanic(t, repo, tm) })
+			t.Run("SavepointRollback", func(t *testing.T) { testSavepointRollback(t, repo, tm) })
+			t.Run("SavepointRelease", func(t *testing.T) { testSavepointRelease(t, repo, tm) })
+			t.Run("NestedSavepoints", func(t *testing.T) { testNestedSavepoints(t, repo, tm) })
+			t.Run("OuterRollbackAfterSavepoint", func(t *testing.T) { testOuterRollbackAfterSavepoint(t, repo, tm) })
+			t.Run("AfterCommitWithoutTransaction", func(t *testing.T) { testAfterCommitWithoutTransaction(t, tm) })
+		})
+	}
+}
+
+func openSQLiteTransactionManager(t *testing.T) (repository.OrderRepository, repository.TransactionManager) {
+	config := database.DefaultConfig()
+	config.Driver = database.DriverSQLite
+	config.SQLitePath = filepath.Join(t.TempDir(), "orders.db")
+
+	db, err := database.ConnectSQLite(&config)
+	if err != nil {
+		t.Fatalf("connecting to SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewSQLiteOrderRepository(db), NewSQLTransactionManager(db, false)
+}
+
+func openPostgresTransactionManager(t *testing.T) (repository.OrderRepository, repository.TransactionManager) {
+	// Migrates the test database
+	openPostgresOrderRepository(t)
+	config := postgresConfig(t)
+
+	db, err := database.Connect(&config)
+	if err != nil {
+		t.Fatalf("connecting to PostgreSQL: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewPostgresOrderRepository(db, false), NewSQLTransactionManager(db, false)
+}
+
+func openPgxTransactionManager(t *testing.T) (repository.OrderRepository, repository.TransactionManager) {
+	// Migrates the test database
+	openPostgresOrderRepository(t)
+	config := postgresConfig(t)
+
+	pool, err := database.ConnectPool(&config, ConfigurePgxPool)
+	if err != nil {
+		t.Fatalf("connecting to PostgreSQL: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return NewPgxOrderRepository(pool, false), NewPgxTransactionManager(pool, false)
+}
+
+// callbacks records the AfterCommit callbacks that ran, in order
+type callbacks struct {
+	ran []string
+	// inTransaction is set when a callback ran with a transaction in its ctx
+	inTransaction bool
+}
+
+// register adds an AfterCommit callback recording name
+func (c *callbacks) register(ctx context.Context, tm repository.TransactionManager, name string) {
+	tm.AfterCommit(ctx, func(ctx context.Context) {
+		_, sqlOK := sqlTxFromContext(ctx)
+		_, pgxOK := pgxTxFromContext(ctx)
+		c.inTransaction = c.inTransaction || sqlOK || pgxOK
+		c.ran = append(c.ran, name)
+	})
+}
+
+// assertRan fails unless exactly the named callbacks ran, in order, outside a transaction
+func (c *callbacks) assertRan(t *testing.T, names ...string) {
+	t.Helper()
+	if !slices.Equal(c.ran, names) {
+		t.Errorf("callbacks ran = %q, want %q", c.ran, names)
+	}
+	if c.inTransaction {
+		t.Error("a callback ran with the transaction still in its context")
+	}
+}
+
+// assertExists fails unless the order is stored or not as wanted
+func assertExists(t *testing.T, ctx context.Context, repo repository.OrderRepository, order *entity.Order, want bool) {
+	t.Helper()
+
+	_, err := repo.GetByID(ctx, order.ID.String())
+	switch {
+	case want && err != nil:
+		t.Errorf("order %q: GetByID: %v, want it committed", order.Description, err)
+	case !want && !errors.Is(err, repository.ErrOrderNotFound):
+		t.Errorf("order %q: GetByID error = %v, want it rolled back", order.Description, err)
+	}
+}
+
+func testTransactionCommit(t *testing.T, repo repository.OrderRepository, tm repository.TransactionManager) {
+	ctx := newTenant()
+	order := entity.NewOrder("committed")
+	var cb callbacks
+
+	err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, order); err != nil {
+			return err
+		}
+		cb.register(ctx, tm, "commit")
+		if len(cb.ran) > 0 {
+			t.Error("AfterCommit callback ran before the commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+
+	cb.assertRan(t, "commit")
+	assertExists(t, ctx, repo, order, true)
+}
+
+func testTransactionRollbackOnError(t *testing.T, repo repository.OrderRepository, tm repository.TransactionManager) {
+	ctx := newTenant()
+	order := entity.NewOrder("rolled back")
+	var cb callbacks
+
+	err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, order); err != nil {
+			return err
+		}
+		cb.register(ctx, tm, "rollback")
+		return errUnitOfWork
+	})
+	if !errors.Is(err, errUnitOfWork) {
+		t.Fatalf("WithinTransaction error = %v, want %v", err, errUnitOfWork)
+	}
+
+	cb.assertRan(t)
+	assertExists(t, ctx, repo, order, false)
+}
+
+func testTransactionRollbackOnPanic(t *testing.T, repo repository.OrderRepository, tm repository.TransactionManager) {
+	ctx := newTenant()
+	order := entity.NewOrder("panicked")
+	var cb callbacks
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the panic to propagate", p)
+			}
+		}()
+		tm.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, order); err != nil {
+				return err
+			}
+			cb.register(ctx, tm, "panic")
+			panic("boom")
+		})
+	}()
+
+	cb.assertRan(t)
+	assertExists(t, ctx, repo, order, false)
+}
+
+func testSavepointRollback(t *testing.T, repo repository.OrderRepository, tm repository.TransactionManager) {
+	ctx := newTenant()
+	outer := entity.NewOrder("outer")
+	inner := entity.NewOrder("inner")
+	var cb callbacks
+
+	err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, outer); err != nil {
+			return err
+		}
+		cb.register(ctx, tm, "outer")
+
+		err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, inner); err != nil {
+				return err
+			}
+			cb.register(ctx, tm, "inner")
+			return errUnitOfWork
+		})
+		if !errors.Is(err, errUnitOfWork) {
+			t.Errorf("nested WithinTransaction error = %v, want %v", err, errUnitOfWork)
+		}
+
+		// The transaction is still usable after the savepoint rolled back
+		_, err = repo.GetByID(ctx, outer.ID.String())
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+
+	cb.assertRan(t, "outer")
+	assertExists(t, ctx, repo, outer, true)
+	assertExists(t, ctx, repo, inner, false)
+}
+
+func testSavepointRelease(t *testing.T, repo repository.OrderRepository, tm repository.TransactionManager) {
+	ctx := newTenant()
+	inner := entity.NewOrder("inner")
+	var cb callbacks
+
+	err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+		cb.register(ctx, tm, "before")
+
+		err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, inner); err != nil {
+				return err
+			}
+			cb.register(ctx, tm, "inner")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(cb.ran) > 0 {
+			t.Error("AfterCommit callback ran when the savepoint was released")
+		}
+
+		cb.register(ctx, tm, "after")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+
+	cb.assertRan(t, "before", "inner", "after")
+	assertExists(t, ctx, repo, inner, true)
+}
+
+func testNestedSavepoints(t *testing.T, repo repository.OrderRepository, tm repository.TransactionManager) {
+	ctx := newTenant()
+	middle := entity.NewOrder("middle")
+	innermost := entity.NewOrder("innermost")
+	var cb callbacks
+
+	err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+		return tm.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, middle); err != nil {
+				return err
+			}
+			cb.register(ctx, tm, "middle")
+
+			err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+				if err := repo.Create(ctx, innermost); err != nil {
+					return err
+				}
+				cb.register(ctx, tm, "innermost")
+				return errUnitOfWork
+			})
+			if !errors.Is(err, errUnitOfWork) {
+				t.Errorf("innermost WithinTransaction error = %v, want %v", err, errUnitOfWork)
+			}
+
+			// A later savepoint at the same depth works after the rollback
+			return tm.WithinTransaction(ctx, func(ctx context.Context) error {
+				cb.register(ctx, tm, "sibling")
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+
+	cb.assertRan(t, "middle", "sibling")
+	assertExists(t, ctx, repo, middle, true)
+	assertExists(t, ctx, repo, innermost, false)
+}
+
+func testOuterRollbackAfterSavepoint(t *testing.T, repo repository.OrderRepository, tm repository.TransactionManager) {
+	ctx := newTenant()
+	inner := entity.NewOrder("inner")
+	var cb callbacks
+
+	err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := tm.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, inner); err != nil {
+				return err
+			}
+			cb.register(ctx, tm, "inner")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return errUnitOfWork
+	})
+	if !errors.Is(err, errUnitOfWork) {
+		t.Fatalf("WithinTransaction error = %v, want %v", err, errUnitOfWork)
+	}
+
+	cb.assertRan(t)
+	assertExists(t, ctx, repo, inner, false)
+}
+
+func testAfterCommitWithoutTransaction(t *testing.T, tm repository.TransactionManager) {
+	var cb callbacks
+	cb.register(newTenant(), tm, "immediate")
+	cb.assertRan(t, "immediate")
+}
@@ -0,0 +1,108 @@
+// Package locale carries the language of the caller, negotiated from the
+// Accept-Language header or gRPC metadata, so messages such as validation
+// errors can be translated.
+package locale
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/text/language"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Supported locales, named as in github.com/go-playground/locales
+const (
+	English             = "en"
+	BrazilianPortuguese = "pt_BR"
+)
+
+// Default is the locale used when the caller accepts none of the supported ones
+const Default = English
+
+// Header is the HTTP header negotiating the locale
+const Header = "Accept-Language"
+
+// MetadataKey is the gRPC metadata key negotiating the locale
+const MetadataKey = "accept-language"
+
+// matcher picks a supported locale, English first as the fallback
+var matcher = language.NewMatcher([]language.Tag{language.English, language.BrazilianPortuguese})
+
+// locales maps the matcher tags to locale names
+var locales = []string{English, BrazilianPortuguese}
+
+type contextKey struct{}
+
+// WithLocale returns a copy of ctx carrying the given locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale stored in ctx, Default when none is present
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok {
+		return locale
+	}
+	return Default
+}
+
+// Negotiate returns the supported locale best matching an Accept-Language
+// value, such as "pt-BR,pt;q=0.9,en;q=0.8"; any Portuguese matches pt_BR
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return Default
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return locales[index]
+}
+
+// Middleware negotiates the locale of every HTTP request
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithLocale(r.Context(), Negotiate(r.Header.Get(Header)))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UnaryServerInterceptor negotiates the locale of every unary gRPC call
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(fromIncomingContext(ctx), req)
+	}
+}
+
+// StreamServerInterceptor negotiates the locale of every gRPC stream
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &localeStream{ServerStream: ss, ctx: fromIncomingContext(ss.Context())})
+	}
+}
+
+// fromIncomingContext negotiates the locale from incoming gRPC metadata
+func fromIncomingContext(ctx context.Context) context.Context {
+	var acceptLanguage string
+	if values := metadata.ValueFromIncomingContext(ctx, MetadataKey); len(values) > 0 {
+		acceptLanguage = values[0]
+	}
+	return WithLocale(ctx, Negotiate(acceptLanguage))
+}
+
+// localeStream overrides the context of a server stream
+type localeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the locale-aware context
+func (s *localeStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// GraphQLExtension returns a gqlgen extension that logs every GraphQL operation.
+// The HTTP access log is written by Middleware; this adds the operation details.
+func GraphQLExtension() graphql.HandlerExtension {
+	return graphQLExtension{}
+}
+
+// graphQLExtension logs operation name, type, error count and latency
+type graphQLExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = graphQLExtension{}
+
+// ExtensionName returns the extension name
+func (graphQLExtension) ExtensionName() string {
+	return "Logging"
+}
+
+// Validate is a no-op, the extension works with any schema
+func (graphQLExtension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse logs the operation once its response has been produced
+func (graphQLExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	start := time.Now()
+	resp := next(ctx)
+
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+	oc := graphql.GetOperationContext(ctx)
+
+	var operationType string
+	if oc.Operation != nil {
+		operationType = string(oc.Operation.Operation)
+	}
+
+	level := slog.LevelInfo
+	var errorCount int
+	if resp != nil && len(resp.Errors) > 0 {
+		level = slog.LevelWarn
+		errorCount = len(resp.Errors)
+	}
+
+	slog.Log(ctx, level, "graphql operation completed",
+		slog.String("transport", "graphql"),
+		slog.String("operation", oc.OperationName),
+		slog.String("operation_type", operationType),
+		slog.Int("errors", errorCount),
+		slog.Duration("latency", time.Since(start)),
+	)
+
+	return resp
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"curso-go-clean-arch/internal/requestid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerInterceptor propagates the request ID from gRPC metadata and logs every unary call
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = withIncomingRequestID(ctx)
+
+		resp, err := handler(ctx, req)
+
+		var size int
+		if msg, ok := resp.(proto.Message); ok && err == nil {
+			size = proto.Size(msg)
+		}
+		logCall(ctx, info.FullMethod, err, size, start)
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor propagates the request ID from gRPC metadata and logs every stream
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withIncomingRequestID(ss.Context())
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, info.FullMethod, err, 0, start)
+
+		return err
+	}
+}
+
+// withIncomingRequestID reads or generates the request ID and sends it back as a header
+func withIncomingRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestid.MetadataKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = requestid.Sanitize(id)
+
+	grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id))
+	return requestid.WithRequestID(ctx, id)
+}
+
+// logCall writes one access log entry for a finished gRPC call
+func logCall(ctx context.Context, method string, err error, size int, start time.Time) {
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+	}
+
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	attrs := []slog.Attr{
+		slog.String("transport", "grpc"),
+		slog.String("method", method),
+		slog.String("remote_addr", remoteAddr),
+		slog.String("status", code.String()),
+		slog.Int("bytes", size),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	slog.LogAttrs(ctx, level, "request completed", attrs...)
+}
+
+// serverStream overrides the context of a server stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the request ID
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
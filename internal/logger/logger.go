@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"curso-go-clean-arch/internal/requestid"
+	"curso-go-clean-arch/internal/tenant"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config holds logging configuration
+type Config struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+// DefaultConfig returns the default logging configuration
+func DefaultConfig() Config {
+	return Config{
+		Level:  "info",
+		Format: "json",
+	}
+}
+
+// Validate checks the logging configuration
+func (c *Config) Validate() error {
+	switch strings.ToLower(c.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("level: must be debug, info, warn or error, got %q", c.Level)
+	}
+	switch strings.ToLower(c.Format) {
+	case "json", "text":
+	default:
+		return fmt.Errorf("format: must be json or text, got %q", c.Format)
+	}
+	return nil
+}
+
+// New creates a structured logger writing to w. Records logged with a context
+// carrying a request ID, tenant or span automatically include them as attributes.
+func New(config *Config, w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{Level: parseLevel(config.Level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(config.Format, "text") {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// Setup creates the application logger and installs it as the slog default
+func Setup(config *Config) *slog.Logger {
+	logger := New(config, os.Stdout)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// parseLevel converts a level name to a slog.Level, defaulting to info
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// contextHandler adds request scoped attributes found in the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds request_id, tenant_id and trace_id before delegating to the wrapped handler
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if tenantID, ok := tenant.FromContext(ctx); ok {
+		record.AddAttrs(slog.String("tenant_id", tenantID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs keeps the context handler when attributes are added
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the context handler when a group is opened
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "orders"
+
+// Metrics holds the Prometheus registry and the collectors shared by all servers
+type Metrics struct {
+	registry          *prometheus.Registry
+	requests          *prometheus.CounterVec
+	requestDuration   *prometheus.HistogramVec
+	useCaseExecutions *prometheus.CounterVec
+	useCaseDuration   *prometheus.HistogramVec
+	cacheRequests     *prometheus.CounterVec
+	webhookDeliveries *prometheus.HistogramVec
+	eventsPublished   *prometheus.CounterVec
+}
+
+// New creates the metrics registry, registering Go runtime, process and
+// database pool collectors alongside the application metrics
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Total number of requests handled, by transport, operation and status.",
+		}, []string{"transport", "operation", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Request latency in seconds, by transport, operation and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"transport", "operation", "status"}),
+		useCaseExecutions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "usecase_executions_total",
+			Help:      "Total number of use case executions, by use case and result.",
+		}, []string{"usecase", "result"}),
+		useCaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "usecase_duration_seconds",
+			Help:      "Use case execution latency in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"usecase"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Total number of repository cache lookups, by operation and result (hit or miss).",
+		}, []string{"operation", "result"}),
+		webhookDeliveries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "webhook_delivery_duration_seconds",
+			Help:      "Webhook delivery attempt latency in seconds, by event type and result (succeeded, retrying or dead).",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"event_type", "result"}),
+		eventsPublished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_published_total",
+			Help:      "Total number of order events sent to the message broker, by event type and result (published, retrying, failed or dropped).",
+		}, []string{"event_type", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.useCaseExecutions,
+		m.useCaseDuration,
+		m.cacheRequests,
+		m.webhookDeliveries,
+		m.eventsPublished,
+	)
+
+	if db != nil {
+		m.registry.MustRegister(
+			collectors.NewDBStatsCollector(db, "orders"),
+			newOrderStatusCollector(db),
+		)
+	}
+
+	return m
+}
+
+// Handler returns the HTTP handler serving the /metrics endpoint
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Registry exposes the underlying registry so other packages can register collectors
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// ObserveRequest records one finished request
+func (m *Metrics) ObserveRequest(transport, operation, status string, duration time.Duration) {
+	m.requests.WithLabelValues(transport, operation, status).Inc()
+	m.requestDuration.WithLabelValues(transport, operation, status).Observe(duration.Seconds())
+}
+
+// Start implements usecase.Observer, counting executions and errors per use case
+func (m *Metrics) Start(ctx context.Context, useCase string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		result := "success"
+		if err != nil {
+			result = "error"
+		}
+		m.useCaseExecutions.WithLabelValues(useCase, result).Inc()
+		m.useCaseDuration.WithLabelValues(useCase).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveCache implements cache.Observer, counting hits and misses per operation
+func (m *Metrics) ObserveCache(operation string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheRequests.WithLabelValues(operation, result).Inc()
+}
+
+// ObserveWebhookDelivery implements webhook.Observer, recording the latency and result of delivery attempts
+func (m *Metrics) ObserveWebhookDelivery(eventType, result string, duration time.Duration) {
+	m.webhookDeliveries.WithLabelValues(eventType, result).Observe(duration.Seconds())
+}
+
+// ObserveEventPublish implements events.Observer, counting published order events
+func (m *Metrics) ObserveEventPublish(eventType, result string) {
+	m.eventsPublished.WithLabelValues(eventType, result).Inc()
+}
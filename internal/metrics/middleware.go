@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Middleware records request count and latency for HTTP requests.
+// operation maps a request to a low cardinality operation label.
+func (m *Metrics) Middleware(transport string, operation func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rw, r)
+
+			m.ObserveRequest(transport, operation(r), strconv.Itoa(rw.status), time.Since(start))
+		})
+	}
+}
+
+// UnaryServerInterceptor records request count and latency for unary gRPC calls
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.ObserveRequest("grpc", path.Base(info.FullMethod), status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records request count and latency for gRPC streams
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.ObserveRequest("grpc", path.Base(info.FullMethod), status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
+
+// GraphQLExtension returns a gqlgen extension recording count and latency per operation
+func (m *Metrics) GraphQLExtension() graphql.HandlerExtension {
+	return &graphQLExtension{metrics: m}
+}
+
+// graphQLExtension labels GraphQL operations by their name, or by their first
+// root field for anonymous operations
+type graphQLExtension struct {
+	metrics *Metrics
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &graphQLExtension{}
+
+// ExtensionName returns the extension name
+func (e *graphQLExtension) ExtensionName() string {
+	return "Metrics"
+}
+
+// Validate is a no-op, the extension works with any schema
+func (e *graphQLExtension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse records the operation once its response has been produced
+func (e *graphQLExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	start := time.Now()
+	resp := next(ctx)
+
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	result := "ok"
+	if resp == nil || len(resp.Errors) > 0 {
+		result = "error"
+	}
+
+	e.metrics.ObserveRequest("graphql", operationName(graphql.GetOperationContext(ctx)), result, time.Since(start))
+	return resp
+}
+
+// operationName returns a stable label for a GraphQL operation
+func operationName(oc *graphql.OperationContext) string {
+	if oc.OperationName != "" {
+		return oc.OperationName
+	}
+	if oc.Operation != nil && len(oc.Operation.SelectionSet) > 0 {
+		if field, ok := oc.Operation.SelectionSet[0].(*ast.Field); ok {
+			return field.Name
+		}
+	}
+	return "anonymous"
+}
+
+// statusRecorder records the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write marks the header as written with the default status
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming responses
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
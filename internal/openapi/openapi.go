@@ -0,0 +1,104 @@
+// Package openapi serves the OpenAPI 3.1 document of the REST API and checks
+// that it documents every route of the router.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// Spec is the parsed OpenAPI document
+type Spec struct {
+	json  []byte
+	paths map[string]map[string]bool
+}
+
+// Load parses the embedded OpenAPI document
+func Load() (*Spec, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(document, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing OpenAPI document: %w", err)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding OpenAPI document: %w", err)
+	}
+
+	spec := &Spec{json: data, paths: make(map[string]map[string]bool)}
+	paths, _ := raw["paths"].(map[string]any)
+	for path, item := range paths {
+		operations, _ := item.(map[string]any)
+		spec.paths[path] = make(map[string]bool, len(operations))
+		for method := range operations {
+			spec.paths[path][strings.ToUpper(method)] = true
+		}
+	}
+	return spec, nil
+}
+
+// Documents reports whether the document describes method on the path template
+func (s *Spec) Documents(method, path string) bool {
+	return s.paths[path][method]
+}
+
+// Handler serves the document as JSON
+func (s *Spec) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.json)
+	})
+}
+
+// DocsHandler serves an HTML reference rendering the document in the browser,
+// self-contained so it works offline
+func DocsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(docsPage)
+	})
+}
+
+// CheckRoutes returns an error listing the routes of router, as method and
+// path template, that the document does not describe. Routes matching any
+// method, such as redirects, are skipped.
+func (s *Spec) CheckRoutes(router *mux.Router) error {
+	var missing []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if !s.Documents(method, path) {
+				missing = append(missing, method+" "+path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.New("routes missing from the OpenAPI document: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
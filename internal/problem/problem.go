@@ -0,0 +1,135 @@
+// Package problem writes HTTP errors as RFC 7807 application/problem+json
+// documents, so clients get a machine-readable type and the request ID to
+// report instead of text that may leak internals.
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"curso-go-clean-arch/internal/requestid"
+)
+
+// ContentType is the media type of problem documents
+const ContentType = "application/problem+json"
+
+// Problem types, relative URIs identifying the kind of error
+const (
+	TypeInvalidRequest = "/problems/invalid-request"
+	TypeValidation     = "/problems/validation-error"
+	TypeInvalidTenant  = "/problems/invalid-tenant"
+	TypeUnauthorized   = "/problems/unauthorized"
+	TypeForbidden      = "/problems/forbidden"
+	TypeNotFound       = "/problems/not-found"
+	TypeConflict       = "/problems/conflict"
+	TypeRateLimited    = "/problems/rate-limited"
+	TypeInternal       = "/problems/internal-error"
+)
+
+// titles are the short summaries of the problem types, the same for every occurrence
+var titles = map[string]string{
+	TypeInvalidRequest: "Invalid request",
+	TypeValidation:     "Validation failed",
+	TypeInvalidTenant:  "Invalid tenant",
+	TypeUnauthorized:   "Unauthorized",
+	TypeForbidden:      "Forbidden",
+	TypeNotFound:       "Not found",
+	TypeConflict:       "Conflict",
+	TypeRateLimited:    "Too many requests",
+	TypeInternal:       "Internal server error",
+}
+
+// FieldError describes an invalid field of the request
+type FieldError struct {
+	// Field is the JSON name of the field
+	Field string `json:"field"`
+	// Code is the failed rule, such as required or max
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem document with the request ID and, for
+// validation problems, the invalid fields as extension members
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// New creates a problem of the given type and status
+func New(problemType string, status int, detail string) *Problem {
+	return &Problem{
+		Type:   problemType,
+		Title:  titles[problemType],
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Write sends p for the request r, filling the instance and request ID
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	p.Instance = r.URL.Path
+	p.RequestID = requestid.FromContext(r.Context())
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// BadRequest writes a 400 invalid request problem
+func BadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	Write(w, r, New(TypeInvalidRequest, http.StatusBadRequest, detail))
+}
+
+// Validation writes a 400 problem listing the invalid fields
+func Validation(w http.ResponseWriter, r *http.Request, errors []FieldError) {
+	p := New(TypeValidation, http.StatusBadRequest, "One or more fields are invalid")
+	p.Errors = errors
+	Write(w, r, p)
+}
+
+// NotFound writes a 404 problem
+func NotFound(w http.ResponseWriter, r *http.Request, detail string) {
+	Write(w, r, New(TypeNotFound, http.StatusNotFound, detail))
+}
+
+// Conflict writes a 409 problem
+func Conflict(w http.ResponseWriter, r *http.Request, detail string) {
+	Write(w, r, New(TypeConflict, http.StatusConflict, detail))
+}
+
+// Forbidden writes a 403 problem
+func Forbidden(w http.ResponseWriter, r *http.Request, detail string) {
+	Write(w, r, New(TypeForbidden, http.StatusForbidden, detail))
+}
+
+// Internal logs err with message and writes a 500 problem that does not expose it
+func Internal(w http.ResponseWriter, r *http.Request, message string, err error) {
+	slog.ErrorContext(r.Context(), message, "error", err)
+	Write(w, r, New(TypeInternal, http.StatusInternalServerError, "The request could not be completed, report the request ID if the problem persists"))
+}
+
+// NotFoundHandler answers requests matching no route
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NotFound(w, r, "No route matches "+r.URL.Path)
+	})
+}
+
+// MethodNotAllowedHandler answers requests whose route does not accept their method
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, &Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusMethodNotAllowed),
+			Status: http.StatusMethodNotAllowed,
+			Detail: "Method " + r.Method + " is not allowed on " + r.URL.Path,
+		})
+	})
+}
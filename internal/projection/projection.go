@@ -0,0 +1,41 @@
+package projection
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// OrderSummaryProjector keeps the order summaries of the query side up to date.
+// It is an order change hook, so each summary is written in the transaction of
+// the change it reflects.
+type OrderSummaryProjector struct {
+	summaries repository.OrderSummaryRepository
+}
+
+// NewOrderSummaryProjector creates a projector writing to summaries
+func NewOrderSummaryProjector(summaries repository.OrderSummaryRepository) *OrderSummaryProjector {
+	return &OrderSummaryProjector{
+		summaries: summaries,
+	}
+}
+
+// OrderChanged applies a recorded change to the summary of its order, removing
+// the summary of a purged order
+func (p *OrderSummaryProjector) OrderChanged(ctx context.Context, entry *entity.OrderHistoryEntry, order *entity.Order) error {
+	if entry.Action == entity.OrderHistoryPurged {
+		return p.summaries.Delete(ctx, entry.OrderID)
+	}
+
+	summary, err := p.summaries.Find(ctx, entry.OrderID)
+	if err != nil {
+		return err
+	}
+	if summary == nil {
+		summary = &entity.OrderSummary{}
+	}
+
+	summary.Apply(entry, order)
+	return p.summaries.Save(ctx, summary)
+}
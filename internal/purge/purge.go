@@ -0,0 +1,96 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/usecase"
+)
+
+// Config holds the background purge configuration
+type Config struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"PURGE_ENABLED"`
+	// Retention is how long soft deleted orders are kept before being purged
+	Retention time.Duration `yaml:"retention" toml:"retention" env:"PURGE_RETENTION"`
+	Interval  time.Duration `yaml:"interval" toml:"interval" env:"PURGE_INTERVAL"`
+}
+
+// DefaultConfig returns the default purge configuration
+func DefaultConfig() Config {
+	return Config{
+		Enabled:   true,
+		Retention: 30 * 24 * time.Hour,
+		Interval:  time.Hour,
+	}
+}
+
+// Validate checks the purge configuration
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Retention <= 0 {
+		errs = append(errs, fmt.Errorf("retention: must be positive, got %s", c.Retention))
+	}
+	if c.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("interval: must be positive, got %s", c.Interval))
+	}
+	return errors.Join(errs...)
+}
+
+// Job periodically purges orders soft deleted for longer than the retention
+type Job struct {
+	config  *Config
+	useCase *usecase.PurgeOrderUseCase
+}
+
+// NewJob creates a new purge job
+func NewJob(config *Config, useCase *usecase.PurgeOrderUseCase) *Job {
+	return &Job{
+		config:  config,
+		useCase: useCase,
+	}
+}
+
+// Run purges once immediately and then every interval until ctx is cancelled
+func (j *Job) Run(ctx context.Context) {
+	if !j.config.Enabled {
+		return
+	}
+
+	slog.Info("Purge job started", "retention", j.config.Retention.String(), "interval", j.config.Interval.String())
+
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge runs a single purge, logging the outcome
+func (j *Job) purge(ctx context.Context) {
+	purged, err := j.useCase.PurgeExpired(ctx, j.config.Retention)
+	if err != nil {
+		switch {
+		case ctx.Err() != nil:
+		case errors.Is(err, repository.ErrRowLevelSecurity):
+			slog.ErrorContext(ctx, "Purge job needs a database role bypassing row level security", "error", err)
+		default:
+			slog.ErrorContext(ctx, "Failed to purge deleted orders", "error", err)
+		}
+		return
+	}
+
+	if purged > 0 {
+		slog.InfoContext(ctx, "Purged deleted orders", "count", purged)
+	}
+}
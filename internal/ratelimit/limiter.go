@@ -0,0 +1,283 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config holds rate limiting configuration
+type Config struct {
+	Enabled    bool       `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	RPS        float64    `yaml:"rps" toml:"rps" env:"RATE_LIMIT_RPS"`
+	Burst      int        `yaml:"burst" toml:"burst" env:"RATE_LIMIT_BURST"`
+	Operations Operations `yaml:"operations" toml:"operations" env:"RATE_LIMIT_OPERATIONS"`
+	KeyBy      []string   `yaml:"key_by" toml:"key_by" env:"RATE_LIMIT_KEY_BY"`
+	// APIKeys are the keys accepted in X-API-Key, other keys are ignored
+	APIKeys []string `yaml:"api_keys" toml:"api_keys" env:"RATE_LIMIT_API_KEYS"`
+	// TrustedProxies are the IPs or CIDRs whose X-Forwarded-For is honoured
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES"`
+}
+
+// DefaultConfig returns the default rate limiting configuration
+func DefaultConfig() Config {
+	return Config{
+		Enabled:    true,
+		RPS:        10,
+		Burst:      20,
+		Operations: Operations{},
+		KeyBy:      []string{KeyAPIKey, KeyUser, KeyIP},
+	}
+}
+
+// Validate checks the rate limiting configuration
+func (c *Config) Validate() error {
+	var errs []error
+	if c.RPS <= 0 {
+		errs = append(errs, fmt.Errorf("rps: must be positive, got %g", c.RPS))
+	}
+	if c.Burst <= 0 {
+		errs = append(errs, fmt.Errorf("burst: must be positive, got %d", c.Burst))
+	}
+	for name, limit := range c.Operations {
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			errs = append(errs, fmt.Errorf("operations.%s: rate and burst must be positive", name))
+		}
+	}
+	if len(c.KeyBy) == 0 {
+		errs = append(errs, errors.New("key_by: must not be empty"))
+	}
+	for _, kind := range c.KeyBy {
+		switch kind {
+		case KeyAPIKey, KeyUser, KeyIP:
+		default:
+			errs = append(errs, fmt.Errorf("key_by: unknown key %q, must be api_key, user or ip", kind))
+		}
+	}
+	for _, key := range c.APIKeys {
+		if len(key) < 16 {
+			errs = append(errs, errors.New("api_keys: keys must have at least 16 characters"))
+			break
+		}
+	}
+	if _, err := parsePrefixes(c.TrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("trusted_proxies: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Operations maps operation names to their limits. From environment variables
+// and flags it is parsed as "CreateOrder=5:10,ListOrders=50:100" (rate per second : burst).
+type Operations map[string]Limit
+
+// String formats the operations in their flag syntax
+func (o Operations) String() string {
+	pairs := make([]string, 0, len(o))
+	for name, limit := range o {
+		pairs = append(pairs, fmt.Sprintf("%s=%g:%d", name, limit.Rate, limit.Burst))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set parses "Op=rate:burst" pairs separated by commas, replacing the current value
+func (o *Operations) Set(value string) error {
+	operations := make(Operations)
+	if value == "" {
+		*o = operations
+		return nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, spec, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		rateStr, burstStr, ok2 := strings.Cut(spec, ":")
+		if !ok || !ok2 || name == "" {
+			return fmt.Errorf("invalid entry %q: expected Operation=rate:burst", pair)
+		}
+
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rate in entry %q", pair)
+		}
+
+		burst, err := strconv.Atoi(burstStr)
+		if err != nil {
+			return fmt.Errorf("invalid burst in entry %q", pair)
+		}
+
+		operations[name] = Limit{Rate: rate, Burst: burst}
+	}
+
+	*o = operations
+	return nil
+}
+
+// Client key kinds, tried in the order configured in KeyBy
+const (
+	KeyAPIKey = "api_key"
+	KeyUser   = "user"
+	KeyIP     = "ip"
+)
+
+// IdentityFunc returns the authenticated identity of the caller presenting
+// header, empty when the header carries no valid credential
+type IdentityFunc func(header http.Header) string
+
+// Limiter applies per-client, per-operation token bucket limits
+type Limiter struct {
+	config         *Config
+	store          Store
+	identity       IdentityFunc
+	defaults       Limit
+	operations     map[string]Limit
+	trustedProxies []netip.Prefix
+}
+
+// NewLimiter creates a new rate limiter backed by the given store. identity
+// keys the user kind, nil disables it.
+func NewLimiter(config *Config, store Store, identity IdentityFunc) *Limiter {
+	// Operation names are matched case-insensitively across transports
+	operations := make(map[string]Limit, len(config.Operations))
+	for name, limit := range config.Operations {
+		operations[strings.ToLower(name)] = limit
+	}
+
+	// Validate rejects malformed entries before the limiter is built
+	trustedProxies, _ := parsePrefixes(config.TrustedProxies)
+
+	return &Limiter{
+		config:         config,
+		store:          store,
+		identity:       identity,
+		defaults:       Limit{Rate: config.RPS, Burst: config.Burst},
+		operations:     operations,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// Allow takes a token for the client identified by clientKey on the given operation
+func (l *Limiter) Allow(ctx context.Context, operation, clientKey string) (Result, error) {
+	if !l.config.Enabled {
+		return Result{Allowed: true}, nil
+	}
+
+	operation = strings.ToLower(operation)
+	limit, ok := l.operations[operation]
+	if !ok {
+		limit = l.defaults
+	}
+
+	return l.store.Take(ctx, operation+"|"+clientKey, limit)
+}
+
+// ClientKey identifies the caller from request headers and the remote address.
+// Only credentials the server validates key a client; anything else a client
+// can set freely falls back to its IP.
+func (l *Limiter) ClientKey(header http.Header, remoteAddr string) string {
+	for _, kind := range l.config.KeyBy {
+		switch kind {
+		case KeyAPIKey:
+			if key := header.Get("X-API-Key"); key != "" && l.validAPIKey(key) {
+				return KeyAPIKey + ":" + fingerprint(key)
+			}
+		case KeyUser:
+			if l.identity == nil {
+				continue
+			}
+			if user := l.identity(header); user != "" {
+				return KeyUser + ":" + user
+			}
+		case KeyIP:
+			return KeyIP + ":" + l.clientIP(header, remoteAddr)
+		}
+	}
+	return KeyIP + ":" + l.clientIP(header, remoteAddr)
+}
+
+// validAPIKey reports whether key is one of the configured API keys
+func (l *Limiter) validAPIKey(key string) bool {
+	for _, valid := range l.config.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(valid)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the remote address, or when it is a trusted proxy the
+// right-most X-Forwarded-For hop that is not one
+func (l *Limiter) clientIP(header http.Header, remoteAddr string) string {
+	ip := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		ip = host
+	}
+	if !l.trusted(ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !l.trusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// trusted reports whether ip belongs to a trusted proxy
+func (l *Limiter) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range l.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefixes parses IPs and CIDRs, an IP matching only itself
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", value)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP %q", value)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// fingerprint identifies a secret in limiter keys without storing it
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
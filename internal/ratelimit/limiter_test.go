@@ -0,0 +1,322 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const (
+	validAPIKey   = "api-key-0123456789abcdef"
+	unknownAPIKey = "api-key-unknown-0123456789"
+)
+
+// headerIdentity stands in for the authenticator, trusting only X-Test-Token
+func headerIdentity(header http.Header) string {
+	if header.Get("X-Test-Token") == "valid-token" {
+		return "admin:test"
+	}
+	return ""
+}
+
+// newTestLimiter creates a limiter with one API key, the headerIdentity and
+// the given key kinds and trusted proxies
+func newTestLimiter(t *testing.T, keyBy []string, trustedProxies ...string) *Limiter {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.APIKeys = []string{validAPIKey}
+	config.TrustedProxies = trustedProxies
+	if keyBy != nil {
+		config.KeyBy = keyBy
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("validating config: %v", err)
+	}
+	return NewLimiter(&config, NewMemoryStore(), headerIdentity)
+}
+
+// headers builds a header from name, value pairs, repeating names adds values
+func headers(pairs ...string) http.Header {
+	header := http.Header{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		header.Add(pairs[i], pairs[i+1])
+	}
+	return header
+}
+
+// TestClientKeyForwardedFor checks that X-Forwarded-For is only honoured from
+// trusted proxies and resolves to the right-most hop that is not one
+func TestClientKeyForwardedFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		header         http.Header
+		remoteAddr     string
+		want           string
+	}{
+		{
+			name:       "no forwarded for",
+			header:     headers(),
+			remoteAddr: "203.0.113.7:51234",
+			want:       "ip:203.0.113.7",
+		},
+		{
+			name:       "spoofed from an untrusted peer without trusted proxies",
+			header:     headers("X-Forwarded-For", "198.51.100.1"),
+			remoteAddr: "203.0.113.7:51234",
+			want:       "ip:203.0.113.7",
+		},
+		{
+			name:           "spoofed from an untrusted peer",
+			trustedProxies: []string{"10.0.0.0/8"},
+			header:         headers("X-Forwarded-For", "10.0.0.5, 198.51.100.1"),
+			remoteAddr:     "203.0.113.7:51234",
+			want:           "ip:203.0.113.7",
+		},
+		{
+			name:           "single trusted proxy",
+			trustedProxies: []string{"10.0.0.1"},
+			header:         headers("X-Forwarded-For", "198.51.100.1"),
+			remoteAddr:     "10.0.0.1:443",
+			want:           "ip:198.51.100.1",
+		},
+		{
+			name:           "client prepending a spoofed hop",
+			trustedProxies: []string{"10.0.0.1"},
+			header:         headers("X-Forwarded-For", "192.0.2.66, 198.51.100.1"),
+			remoteAddr:     "10.0.0.1:443",
+			want:           "ip:198.51.100.1",
+		},
+		{
+			name:           "chain of trusted proxies",
+			trustedProxies: []string{"10.0.0.0/8", "172.16.0.1"},
+			header:         headers("X-Forwarded-For", "192.0.2.66, 198.51.100.1, 172.16.0.1, 10.1.2.3"),
+			remoteAddr:     "10.0.0.1:443",
+			want:           "ip:198.51.100.1",
+		},
+		{
+			name:           "chain split across header lines",
+			trustedProxies: []string{"10.0.0.0/8"},
+			header:         headers("X-Forwarded-For", "198.51.100.1", "X-Forwarded-For", "10.1.2.3"),
+			remoteAddr:     "10.0.0.1:443",
+			want:           "ip:198.51.100.1",
+		},
+		{
+			name:           "every hop trusted",
+			trustedProxies: []string{"10.0.0.0/8"},
+			header:         headers("X-Forwarded-For", "10.9.9.9, 10.1.2.3"),
+			remoteAddr:     "10.0.0.1:443",
+			want:           "ip:10.9.9.9",
+		},
+		{
+			name:           "trusted proxy without forwarded for",
+			trustedProxies: []string{"10.0.0.0/8"},
+			header:         headers(),
+			remoteAddr:     "10.0.0.1:443",
+			want:           "ip:10.0.0.1",
+		},
+		{
+			name:           "empty hops skipped",
+			trustedProxies: []string{"10.0.0.0/8"},
+			header:         headers("X-Forwarded-For", "198.51.100.1, , "),
+			remoteAddr:     "10.0.0.1:443",
+			want:           "ip:198.51.100.1",
+		},
+		{
+			name:           "malformed hop is not trusted",
+			trustedProxies: []string{"10.0.0.0/8"},
+			header:         headers("X-Forwarded-For", "198.51.100.1, not-an-ip"),
+			remoteAddr:     "10.0.0.1:443",
+			want:           "ip:not-an-ip",
+		},
+		{
+			name:           "IPv6 trusted proxy",
+			trustedProxies: []string{"2001:db8::/32"},
+			header:         headers("X-Forwarded-For", "2001:db8:ffff::1, 198.51.100.1"),
+			remoteAddr:     "[2001:db8::1]:443",
+			want:           "ip:198.51.100.1",
+		},
+		{
+			name:           "IPv4-mapped peer matches an IPv4 proxy",
+			trustedProxies: []string{"10.0.0.1"},
+			header:         headers("X-Forwarded-For", "198.51.100.1"),
+			remoteAddr:     "[::ffff:10.0.0.1]:443",
+			want:           "ip:198.51.100.1",
+		},
+		{
+			name:       "remote address without port",
+			header:     headers(),
+			remoteAddr: "203.0.113.7",
+			want:       "ip:203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newTestLimiter(t, []string{KeyIP}, tt.trustedProxies...)
+			if got := limiter.ClientKey(tt.header, tt.remoteAddr); got != tt.want {
+				t.Errorf("ClientKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestClientKeyCredentials checks the KeyBy fallback order and that only
+// credentials the server validates key a client
+func TestClientKeyCredentials(t *testing.T) {
+	const remoteAddr = "203.0.113.7:51234"
+	apiKeyBucket := KeyAPIKey + ":" + fingerprint(validAPIKey)
+
+	tests := []struct {
+		name   string
+		keyBy  []string
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "valid API key",
+			header: headers("X-API-Key", validAPIKey),
+			want:   apiKeyBucket,
+		},
+		{
+			name:   "unknown API key falls back to the IP",
+			header: headers("X-API-Key", unknownAPIKey),
+			want:   "ip:203.0.113.7",
+		},
+		{
+			name:   "unknown API key falls back to the identity",
+			header: headers("X-API-Key", unknownAPIKey, "X-Test-Token", "valid-token"),
+			want:   "user:admin:test",
+		},
+		{
+			name:   "API key before identity",
+			header: headers("X-API-Key", validAPIKey, "X-Test-Token", "valid-token"),
+			want:   apiKeyBucket,
+		},
+		{
+			name:   "claimed user is ignored",
+			header: headers("X-User-ID", "alice"),
+			want:   "ip:203.0.113.7",
+		},
+		{
+			name:   "invalid credential falls back to the IP",
+			header: headers("X-Test-Token", "guessed-token"),
+			want:   "ip:203.0.113.7",
+		},
+		{
+			name:   "configured order is respected",
+			keyBy:  []string{KeyUser, KeyAPIKey, KeyIP},
+			header: headers("X-API-Key", validAPIKey, "X-Test-Token", "valid-token"),
+			want:   "user:admin:test",
+		},
+		{
+			name:   "IP first ignores credentials",
+			keyBy:  []string{KeyIP, KeyAPIKey},
+			header: headers("X-API-Key", validAPIKey),
+			want:   "ip:203.0.113.7",
+		},
+		{
+			name:   "IP when no configured kind matches",
+			keyBy:  []string{KeyAPIKey},
+			header: headers("X-API-Key", unknownAPIKey),
+			want:   "ip:203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newTestLimiter(t, tt.keyBy)
+			got := limiter.ClientKey(tt.header, remoteAddr)
+			if got != tt.want {
+				t.Errorf("ClientKey() = %q, want %q", got, tt.want)
+			}
+			if strings.Contains(got, validAPIKey) {
+				t.Errorf("ClientKey() = %q exposes the API key", got)
+			}
+		})
+	}
+}
+
+// TestClientKeyWithoutIdentity checks that the user kind is skipped when the
+// limiter has no identity function
+func TestClientKeyWithoutIdentity(t *testing.T) {
+	config := DefaultConfig()
+	config.KeyBy = []string{KeyUser}
+	limiter := NewLimiter(&config, NewMemoryStore(), nil)
+
+	if got := limiter.ClientKey(headers("X-Test-Token", "valid-token"), "203.0.113.7:51234"); got != "ip:203.0.113.7" {
+		t.Errorf("ClientKey() = %q, want the IP", got)
+	}
+}
+
+// TestSpoofedHeadersShareABucket checks that rotating unverified headers does
+// not give a client a fresh bucket
+func TestSpoofedHeadersShareABucket(t *testing.T) {
+	config := DefaultConfig()
+	config.Burst = 2
+	config.APIKeys = []string{validAPIKey}
+	config.TrustedProxies = []string{"10.0.0.1"}
+	limiter := NewLimiter(&config, NewMemoryStore(), headerIdentity)
+
+	spoofed := []http.Header{
+		headers("X-Forwarded-For", "192.0.2.1", "X-User-ID", "alice"),
+		headers("X-Forwarded-For", "192.0.2.2", "X-API-Key", unknownAPIKey),
+		headers("X-Forwarded-For", "192.0.2.3", "X-Test-Token", "guessed-token"),
+	}
+
+	var allowed int
+	for _, header := range spoofed {
+		result, err := limiter.Allow(context.Background(), "CreateOrder", limiter.ClientKey(header, "203.0.113.7:51234"))
+		if err != nil {
+			t.Fatalf("taking a token: %v", err)
+		}
+		if result.Allowed {
+			allowed++
+		}
+	}
+	if allowed != config.Burst {
+		t.Errorf("allowed %d of %d spoofed requests, want the burst of %d", allowed, len(spoofed), config.Burst)
+	}
+
+	// A valid API key has a bucket of its own
+	result, err := limiter.Allow(context.Background(), "CreateOrder", limiter.ClientKey(headers("X-API-Key", validAPIKey), "203.0.113.7:51234"))
+	if err != nil {
+		t.Fatalf("taking a token: %v", err)
+	}
+	if !result.Allowed {
+		t.Error("request with a valid API key was limited by the IP bucket")
+	}
+}
+
+// TestConfigValidateCredentials checks the validation of API keys and trusted proxies
+func TestConfigValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name           string
+		apiKeys        []string
+		trustedProxies []string
+		wantErr        string
+	}{
+		{name: "valid", apiKeys: []string{validAPIKey}, trustedProxies: []string{"10.0.0.1", "10.0.0.0/8", "2001:db8::/32"}},
+		{name: "short API key", apiKeys: []string{"short"}, wantErr: "api_keys"},
+		{name: "malformed IP", trustedProxies: []string{"10.0.0"}, wantErr: "trusted_proxies"},
+		{name: "malformed CIDR", trustedProxies: []string{"10.0.0.0/33"}, wantErr: "trusted_proxies"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.APIKeys = tt.apiKeys
+			config.TrustedProxies = tt.trustedProxies
+
+			err := config.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"curso-go-clean-arch/internal/problem"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// OperationFunc maps an HTTP request to the operation name used for limits
+type OperationFunc func(r *http.Request) string
+
+// Middleware enforces limits on HTTP requests, answering 429 with Retry-After when exceeded
+func (l *Limiter) Middleware(operation OperationFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := l.Allow(r.Context(), operation(r), l.ClientKey(r.Header, r.RemoteAddr))
+			if err != nil {
+				// Fail open: an unavailable store must not take the API down
+				slog.ErrorContext(r.Context(), "Rate limit store error", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			if !result.Allowed {
+				w.Header().Set("Retry-After", retryAfterSeconds(result.RetryAfter))
+				problem.Write(w, r, problem.New(problem.TypeRateLimited, http.StatusTooManyRequests, "Rate limit exceeded, retry after "+retryAfterSeconds(result.RetryAfter)+" seconds"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnaryServerInterceptor enforces limits on unary gRPC calls, keyed by method name
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.allowGRPC(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor enforces limits when a gRPC stream is opened
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.allowGRPC(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// allowGRPC checks the limit for a gRPC method and returns ResourceExhausted when exceeded
+func (l *Limiter) allowGRPC(ctx context.Context, fullMethod string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := http.Header{}
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	result, err := l.Allow(ctx, path.Base(fullMethod), l.ClientKey(header, remoteAddr))
+	if err != nil {
+		slog.ErrorContext(ctx, "Rate limit store error", "error", err)
+		return nil
+	}
+
+	if !result.Allowed {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfterSeconds(result.RetryAfter)))
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s", result.RetryAfter.Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+type clientKeyContextKey struct{}
+
+// IdentityMiddleware stores the client key of HTTP requests so the GraphQL extension can use it
+func (l *Limiter) IdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientKeyContextKey{}, l.ClientKey(r.Header, r.RemoteAddr))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GraphQLExtension returns a gqlgen extension enforcing limits per root field
+func (l *Limiter) GraphQLExtension() graphql.HandlerExtension {
+	return &graphQLExtension{limiter: l}
+}
+
+// graphQLExtension limits each query or mutation root field as a separate operation
+type graphQLExtension struct {
+	limiter *Limiter
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &graphQLExtension{}
+
+// ExtensionName returns the extension name
+func (e *graphQLExtension) ExtensionName() string {
+	return "RateLimit"
+}
+
+// Validate is a no-op, the extension works with any schema
+func (e *graphQLExtension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField checks the limit before resolving a root field
+func (e *graphQLExtension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !isRootObject(fc.Object) || strings.HasPrefix(fc.Field.Name, "__") {
+		return next(ctx)
+	}
+
+	clientKey, _ := ctx.Value(clientKeyContextKey{}).(string)
+	if clientKey == "" {
+		clientKey = KeyIP + ":unknown"
+	}
+
+	result, err := e.limiter.Allow(ctx, fc.Field.Name, clientKey)
+	if err != nil {
+		slog.ErrorContext(ctx, "Rate limit store error", "error", err)
+		return next(ctx)
+	}
+
+	if !result.Allowed {
+		return nil, &gqlerror.Error{
+			Message: fmt.Sprintf("rate limit exceeded, retry after %s", result.RetryAfter.Round(time.Millisecond)),
+			Path:    fc.Path(),
+			Extensions: map[string]any{
+				"code":       "RATE_LIMITED",
+				"retryAfter": math.Ceil(result.RetryAfter.Seconds()),
+			},
+		}
+	}
+
+	return next(ctx)
+}
+
+// isRootObject reports whether a type name is one of the GraphQL root operation types
+func isRootObject(object string) bool {
+	return object == "Query" || object == "Mutation" || object == "Subscription"
+}
+
+// retryAfterSeconds formats a wait duration as whole seconds, rounding up
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
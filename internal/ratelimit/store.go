@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket: Rate tokens are added per second up to Burst
+type Limit struct {
+	Rate  float64 `yaml:"rate" toml:"rate"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets. Implement it on top of Redis or another shared
+// store to enforce limits across several instances of the service.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket is the state of a single token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// MemoryStore is an in-process Store suitable for a single instance
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	calls   int
+}
+
+// sweepEvery controls how often idle buckets are evicted
+const sweepEvery = 1024
+
+// NewMemoryStore creates a new in-memory token bucket store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take removes a token from the bucket identified by key if one is available
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.calls++
+	if s.calls%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+
+	// Refill according to the time elapsed since the last call
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return Result{Allowed: false, Remaining: 0, RetryAfter: wait}, nil
+}
+
+// sweep evicts buckets that have been idle long enough to be full again
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		idle := time.Duration(float64(b.limit.Burst) / b.limit.Rate * float64(time.Second))
+		if now.Sub(b.last) > idle {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package requestid
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header used to propagate request IDs
+const Header = "X-Request-ID"
+
+// MetadataKey is the gRPC metadata key used to propagate request IDs
+const MetadataKey = "x-request-id"
+
+// validID limits propagated IDs to a safe length and character set
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type contextKey struct{}
+
+// New generates a new request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Sanitize returns id if it is an acceptable incoming request ID, otherwise a new one
+func Sanitize(id string) string {
+	if validID.MatchString(id) {
+		return id
+	}
+	return New()
+}
+
+// WithRequestID returns a copy of ctx carrying the given request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx or an empty string
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,213 @@
+package seed
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// product is an item of the catalog, price in centavos and weight being its
+// relative popularity
+type product struct {
+	name   string
+	price  int64
+	weight int
+}
+
+// catalog lists the products orders are made of, priced in entity.DefaultCurrency
+var catalog = []product{
+	{"Notebook", 449900, 6}, {"Monitor 27\"", 149900, 8}, {"Teclado mecânico", 34900, 12},
+	{"Mouse sem fio", 8990, 18}, {"Headset", 29900, 10}, {"Webcam Full HD", 24900, 7},
+	{"Cadeira ergonômica", 179900, 4}, {"Mesa regulável", 259900, 2}, {"SSD 1TB", 39900, 9},
+	{"Memória RAM 16GB", 27900, 7}, {"Hub USB-C", 15990, 11}, {"Cabo HDMI", 3990, 15},
+	{"Mousepad", 4990, 14}, {"Suporte para notebook", 12990, 6}, {"Carregador 65W", 19990, 8},
+	{"Smartphone", 229900, 5}, {"Fone Bluetooth", 19900, 12}, {"Impressora", 89900, 2},
+	{"Roteador Wi-Fi", 34990, 4}, {"Microfone USB", 39900, 5},
+}
+
+// Customer names, combined into the customer pool
+var (
+	firstNames = []string{
+		"Ana", "Bruno", "Camila", "Diego", "Eduarda", "Felipe", "Gabriela", "Henrique", "Isabela", "João",
+		"Larissa", "Lucas", "Mariana", "Mateus", "Natália", "Pedro", "Rafaela", "Rodrigo", "Sofia", "Thiago",
+	}
+	lastNames = []string{
+		"Silva", "Santos", "Oliveira", "Souza", "Rodrigues", "Ferreira", "Alves", "Pereira", "Lima", "Gomes",
+		"Costa", "Ribeiro", "Martins", "Carvalho", "Almeida", "Lopes", "Barbosa", "Rocha", "Dias", "Moreira",
+	}
+)
+
+// hourWeights is the relative order volume per hour of the day, peaking at lunch and in the evening
+var hourWeights = [24]float64{
+	0.15, 0.08, 0.05, 0.04, 0.04, 0.06, 0.15, 0.30, 0.50, 0.65, 0.75, 0.85,
+	0.95, 0.90, 0.80, 0.75, 0.75, 0.80, 0.85, 0.95, 1.00, 0.90, 0.60, 0.30,
+}
+
+// weekendWeight is the relative order volume on Saturdays and Sundays
+const weekendWeight = 0.7
+
+// cancelRate is the share of orders cancelled before being shipped
+const cancelRate = 0.08
+
+// Generator produces the orders of a plan, deterministically for a tenant and seed
+type Generator struct {
+	plan        Plan
+	random      *rand.ChaCha8
+	rng         *rand.Rand
+	customers   []string
+	customer    *rand.Zipf
+	totalWeight int
+}
+
+// NewGenerator creates the generator of plan for the tenant. The tenant is part
+// of the seed so seeding several tenants with the same seed gives distinct IDs.
+func NewGenerator(tenantID string, plan Plan) *Generator {
+	random := rand.NewChaCha8(sha256.Sum256(fmt.Appendf(nil, "%s:%d", tenantID, plan.Seed)))
+	rng := rand.New(random)
+
+	// A customer pool smaller than the orders, a few customers ordering often
+	customers := make([]string, max(20, plan.Count/4))
+	for i := range customers {
+		customers[i] = firstNames[rng.IntN(len(firstNames))] + " " + lastNames[rng.IntN(len(lastNames))]
+	}
+
+	totalWeight := 0
+	for _, p := range catalog {
+		totalWeight += p.weight
+	}
+
+	return &Generator{
+		plan:        plan,
+		random:      random,
+		rng:         rng,
+		customers:   customers,
+		customer:    rand.NewZipf(rng, 1.2, 4, uint64(len(customers)-1)),
+		totalWeight: totalWeight,
+	}
+}
+
+// Next returns the next order
+func (g *Generator) Next() *entity.Order {
+	id, err := uuid.NewRandomFromReader(g.random)
+	if err != nil {
+		// ChaCha8 reads never fail
+		panic(err)
+	}
+
+	createdAt := g.createdAt()
+	status, updatedAt := g.lifecycle(createdAt)
+	order := &entity.Order{
+		ID:          id,
+		Description: "Pedido de " + g.customers[g.customer.Uint64()],
+		Currency:    entity.DefaultCurrency,
+		Status:      status,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+	}
+	order.SetItems(g.items())
+	return order
+}
+
+// createdAt picks a time in the range by rejection sampling, volume growing
+// over the range and following the daily and weekly peaks
+func (g *Generator) createdAt() time.Time {
+	span := g.plan.To.Sub(g.plan.From)
+	for {
+		offset := time.Duration(g.rng.Int64N(int64(span)))
+		t := g.plan.From.Add(offset)
+
+		local := t.In(g.plan.Location)
+		weight := 0.5 + 0.5*float64(offset)/float64(span)
+		weight *= hourWeights[local.Hour()]
+		if day := local.Weekday(); day == time.Saturday || day == time.Sunday {
+			weight *= weekendWeight
+		}
+		if g.rng.Float64() < weight {
+			return t.Truncate(time.Second)
+		}
+	}
+}
+
+// lifecycle walks the order through its statuses with realistic delays and
+// returns the last status reached by the end of the range, with its time
+func (g *Generator) lifecycle(createdAt time.Time) (entity.OrderStatus, time.Time) {
+	if g.rng.Float64() < cancelRate {
+		cancelledAt := createdAt.Add(g.between(5*time.Minute, 48*time.Hour))
+		if cancelledAt.After(g.plan.To) {
+			return entity.OrderStatusPending, createdAt
+		}
+		return entity.OrderStatusCancelled, cancelledAt
+	}
+
+	status, at := entity.OrderStatusPending, createdAt
+	steps := []struct {
+		status   entity.OrderStatus
+		min, max time.Duration
+	}{
+		{entity.OrderStatusConfirmed, 10 * time.Minute, 6 * time.Hour},
+		{entity.OrderStatusShipped, 12 * time.Hour, 72 * time.Hour},
+		{entity.OrderStatusDelivered, 48 * time.Hour, 7 * 24 * time.Hour},
+	}
+	for _, step := range steps {
+		next := at.Add(g.between(step.min, step.max))
+		if next.After(g.plan.To) {
+			break
+		}
+		status, at = step.status, next
+	}
+	return status, at
+}
+
+// items picks one to four distinct products of an order, most orders having
+// a single unit of one product
+func (g *Generator) items() []entity.OrderItem {
+	items := make([]entity.OrderItem, 0, 4)
+	seen := make(map[string]bool, 4)
+	for range 1 + g.weighted([]float64{0.55, 0.25, 0.13, 0.07}) {
+		p := g.product()
+		if seen[p.name] {
+			continue
+		}
+		seen[p.name] = true
+		items = append(items, entity.OrderItem{
+			Name:      p.name,
+			Quantity:  1 + g.weighted([]float64{0.75, 0.17, 0.08}),
+			UnitPrice: p.price,
+		})
+	}
+	return items
+}
+
+// product picks a catalog product by popularity
+func (g *Generator) product() product {
+	n := g.rng.IntN(g.totalWeight)
+	for _, p := range catalog {
+		if n < p.weight {
+			return p
+		}
+		n -= p.weight
+	}
+	return catalog[len(catalog)-1]
+}
+
+// weighted picks an index of weights, which sum to 1
+func (g *Generator) weighted(weights []float64) int {
+	n := g.rng.Float64()
+	for i, weight := range weights {
+		if n < weight {
+			return i
+		}
+		n -= weight
+	}
+	return len(weights) - 1
+}
+
+// between picks a duration in [min, max)
+func (g *Generator) between(min, max time.Duration) time.Duration {
+	return min + time.Duration(g.rng.Int64N(int64(max-min)))
+}
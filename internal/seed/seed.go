@@ -0,0 +1,198 @@
+// Package seed fills a tenant with realistic fake orders for development and
+// load testing. The orders are deterministic: the same tenant, seed, count and
+// date range always produce the same orders.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+)
+
+// ErrAlreadySeeded is returned when the orders of a seed already exist in the tenant
+var ErrAlreadySeeded = errors.New("orders of this seed already exist")
+
+// MaxBatchSize bounds the orders written per transaction
+const MaxBatchSize = 5000
+
+// Config holds the seeding configuration
+type Config struct {
+	// Enabled seeds the tenant when the server starts, skipped once seeded
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"SEED_ENABLED"`
+	// Tenant to seed, the default tenant when empty
+	Tenant     string `yaml:"tenant" toml:"tenant" env:"SEED_TENANT"`
+	Count      int    `yaml:"count" toml:"count" env:"SEED_COUNT"`
+	RandomSeed int    `yaml:"random_seed" toml:"random_seed" env:"SEED_RANDOM_SEED"`
+	// From and To bound created_at, as RFC 3339 or YYYY-MM-DD in TimeZone.
+	// To defaults to now and From to 90 days before To.
+	From      string `yaml:"from" toml:"from" env:"SEED_FROM"`
+	To        string `yaml:"to" toml:"to" env:"SEED_TO"`
+	TimeZone  string `yaml:"time_zone" toml:"time_zone" env:"SEED_TIME_ZONE"`
+	BatchSize int    `yaml:"batch_size" toml:"batch_size" env:"SEED_BATCH_SIZE"`
+}
+
+// DefaultConfig returns the default seeding configuration
+func DefaultConfig() Config {
+	return Config{
+		Count:      1000,
+		RandomSeed: 1,
+		TimeZone:   "UTC",
+		BatchSize:  500,
+	}
+}
+
+// Validate checks the seeding configuration
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Tenant != "" {
+		if err := tenant.Validate(c.Tenant); err != nil {
+			errs = append(errs, fmt.Errorf("tenant: %w", err))
+		}
+	}
+	if c.Count <= 0 {
+		errs = append(errs, fmt.Errorf("count: must be positive, got %d", c.Count))
+	}
+	if c.BatchSize <= 0 || c.BatchSize > MaxBatchSize {
+		errs = append(errs, fmt.Errorf("batch_size: must be between 1 and %d, got %d", MaxBatchSize, c.BatchSize))
+	}
+	if _, err := c.Plan(time.Now()); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Plan resolves the configuration into a plan, with now as the default end of the range
+func (c *Config) Plan(now time.Time) (Plan, error) {
+	location, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return Plan{}, fmt.Errorf("time_zone: %w", err)
+	}
+	plan := Plan{
+		Count:     c.Count,
+		Seed:      int64(c.RandomSeed),
+		To:        now,
+		BatchSize: c.BatchSize,
+		Location:  location,
+	}
+	if c.To != "" {
+		if plan.To, err = ParseTime(c.To, location); err != nil {
+			return Plan{}, fmt.Errorf("to: %w", err)
+		}
+	}
+	plan.From = plan.To.AddDate(0, 0, -90)
+	if c.From != "" {
+		if plan.From, err = ParseTime(c.From, location); err != nil {
+			return Plan{}, fmt.Errorf("from: %w", err)
+		}
+	}
+	if !plan.From.Before(plan.To) {
+		return Plan{}, fmt.Errorf("from: must be before to, got %s and %s", plan.From.Format(time.RFC3339), plan.To.Format(time.RFC3339))
+	}
+	return plan, nil
+}
+
+// ParseTime parses an RFC 3339 timestamp or a YYYY-MM-DD date at midnight in location
+func ParseTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 timestamp or YYYY-MM-DD, got %q", value)
+	}
+	return t, nil
+}
+
+// Plan describes the orders to seed
+type Plan struct {
+	Count int
+	Seed  int64
+	// From and To bound created_at; status changes after To are not applied
+	From, To  time.Time
+	BatchSize int
+	// Location is the time zone of the daily and weekly order peaks
+	Location *time.Location
+}
+
+// Result reports a seeding run
+type Result struct {
+	Tenant string `json:"tenant"`
+	Seeded int    `json:"seeded"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// Seeder writes generated orders through the order repository in batches
+type Seeder struct {
+	orderRepository    repository.OrderRepository
+	transactionManager repository.TransactionManager
+}
+
+// NewSeeder creates a new seeder
+func NewSeeder(orderRepository repository.OrderRepository, transactionManager repository.TransactionManager) *Seeder {
+	return &Seeder{
+		orderRepository:    orderRepository,
+		transactionManager: transactionManager,
+	}
+}
+
+// Run seeds the tenant of ctx following plan, one transaction per batch. It
+// returns ErrAlreadySeeded when the first order of the plan already exists,
+// so running the same seed twice does nothing. On error the batches already
+// committed stay seeded, as reported by the result.
+func (s *Seeder) Run(ctx context.Context, plan Plan) (*Result, error) {
+	tenantID, err := tenant.MustFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Tenant: tenantID,
+		From:   plan.From.Format(time.RFC3339),
+		To:     plan.To.Format(time.RFC3339),
+	}
+	generator := NewGenerator(tenantID, plan)
+	batch := make([]*entity.Order, 0, plan.BatchSize)
+	for i := range plan.Count {
+		order := generator.Next()
+		if i == 0 {
+			if _, err := s.orderRepository.GetByIDIncludingDeleted(ctx, order.ID.String()); err == nil {
+				return result, fmt.Errorf("%w: tenant %s, seed %d", ErrAlreadySeeded, tenantID, plan.Seed)
+			} else if !errors.Is(err, repository.ErrOrderNotFound) {
+				return result, err
+			}
+		}
+
+		batch = append(batch, order)
+		if len(batch) == plan.BatchSize || i == plan.Count-1 {
+			if err := s.write(ctx, batch); err != nil {
+				return result, err
+			}
+			result.Seeded += len(batch)
+			slog.DebugContext(ctx, "Seeded orders", "tenant", tenantID, "seeded", result.Seeded, "count", plan.Count)
+			batch = make([]*entity.Order, 0, plan.BatchSize)
+		}
+	}
+	return result, nil
+}
+
+// write saves a batch in a transaction, in bulk when the repository supports it
+func (s *Seeder) write(ctx context.Context, orders []*entity.Order) error {
+	return s.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if bulk, ok := s.orderRepository.(repository.OrderBulkCreator); ok {
+			return bulk.CreateMany(ctx, orders)
+		}
+		for _, order := range orders {
+			if err := s.orderRepository.Create(ctx, order); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
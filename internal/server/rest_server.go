@@ -1,11 +1,18 @@
 package server
 
 import (
+	"context"
 	"curso-go-clean-arch/internal/container"
 	"curso-go-clean-arch/internal/handlers"
-	"log"
+	"curso-go-clean-arch/internal/locale"
+	"curso-go-clean-arch/internal/logger"
+	"curso-go-clean-arch/internal/openapi"
+	"curso-go-clean-arch/internal/problem"
+	"curso-go-clean-arch/internal/tracing"
+	"log/slog"
 	"net/http"
-	"os"
+	"slices"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -14,38 +21,80 @@ import (
 type RESTServer struct {
 	router    *mux.Router
 	container *container.Container
-	port      string
+	spec      *openapi.Spec
+	server    *http.Server
 }
 
 // NewRESTServer creates a new REST server
 func NewRESTServer(container *container.Container) *RESTServer {
-	port := os.Getenv("REST_PORT")
-	if port == "" {
-		port = "8081"
-	}
-
 	return &RESTServer{
 		router:    mux.NewRouter(),
 		container: container,
-		port:      port,
 	}
 }
 
-// SetupRoutes configures all the routes for the REST API
-func (s *RESTServer) SetupRoutes() {
+// SetupRoutes configures all the routes for the REST API, warning about the
+// ones missing from the OpenAPI document, which rest_server_test.go rejects
+func (s *RESTServer) SetupRoutes() error {
+	spec, err := openapi.Load()
+	if err != nil {
+		return err
+	}
+	s.spec = spec
+
 	// Create handlers
 	orderHandler := handlers.NewOrderHandler(s.container)
+	webhookHandler := handlers.NewWebhookHandler(s.container)
+	adminHandler := handlers.NewAdminHandler(s.container)
+	reportHandler := handlers.NewReportHandler(s.container)
 
 	// Health check
-	s.router.HandleFunc("/health", s.healthCheck).Methods("GET")
+	s.router.HandleFunc("/health", s.healthCheck).Methods("GET").Name("Health")
+
+	// Prometheus metrics, behind a metrics token as they cover every tenant
+	if s.container.Config.Features.Metrics {
+		if len(s.container.Config.Auth.MetricsTokens) == 0 {
+			slog.Warn("Metrics are enabled without METRICS_TOKENS, every scrape of /metrics is rejected")
+		}
+		s.router.Handle("/metrics", s.container.Authenticator.MetricsMiddleware(s.container.Metrics.Handler())).Methods("GET").Name("Metrics")
+	}
+
+	// OpenAPI document and its reference page
+	s.router.Handle("/openapi.json", s.spec.Handler()).Methods("GET").Name("OpenAPI")
+	s.router.Handle("/docs", openapi.DocsHandler()).Methods("GET").Name("Docs")
 
 	// API routes
 	api := s.router.PathPrefix("/api/v1").Subrouter()
 
 	// Orders routes
 	orders := api.PathPrefix("/orders").Subrouter()
-	orders.HandleFunc("", orderHandler.ListOrders).Methods("GET")
-	orders.HandleFunc("", orderHandler.CreateOrder).Methods("POST")
+	orders.HandleFunc("", orderHandler.ListOrders).Methods("GET").Name("ListOrders")
+	orders.HandleFunc("", orderHandler.CreateOrder).Methods("POST").Name("CreateOrder")
+	orders.HandleFunc("/search", orderHandler.SearchOrders).Methods("GET").Name("SearchOrders")
+	orders.HandleFunc("/{id}", orderHandler.DeleteOrder).Methods("DELETE").Name("DeleteOrder")
+	orders.HandleFunc("/{id}/restore", orderHandler.RestoreOrder).Methods("POST").Name("RestoreOrder")
+	orders.HandleFunc("/{id}/purge", orderHandler.PurgeOrder).Methods("DELETE").Name("PurgeOrder")
+	orders.HandleFunc("/{id}/history", orderHandler.GetOrderHistory).Methods("GET").Name("GetOrderHistory")
+
+	// Reports routes
+	reports := api.PathPrefix("/reports").Subrouter()
+	reports.HandleFunc("/orders", reportHandler.OrderStats).Methods("GET").Name("GetOrderStats")
+
+	// Webhooks routes, admin only
+	webhooks := api.PathPrefix("/webhooks").Subrouter()
+	webhooks.HandleFunc("", webhookHandler.ListWebhookEndpoints).Methods("GET").Name("ListWebhookEndpoints")
+	webhooks.HandleFunc("", webhookHandler.CreateWebhookEndpoint).Methods("POST").Name("CreateWebhookEndpoint")
+	webhooks.HandleFunc("/{id}", webhookHandler.DeleteWebhookEndpoint).Methods("DELETE").Name("DeleteWebhookEndpoint")
+	webhooks.HandleFunc("/{id}/deliveries", webhookHandler.ListWebhookDeliveries).Methods("GET").Name("ListWebhookDeliveries")
+	webhooks.HandleFunc("/{id}/deliveries/{deliveryId}/retry", webhookHandler.RetryWebhookDelivery).Methods("POST").Name("RetryWebhookDelivery")
+
+	// Maintenance routes, admin only
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/projections/rebuild", adminHandler.RebuildProjections).Methods("POST").Name("RebuildProjections")
+
+	// Unknown routes and methods answer with problems too
+	s.router.NotFoundHandler = problem.NotFoundHandler()
+	s.router.MethodNotAllowedHandler = problem.MethodNotAllowedHandler()
 
 	// Root redirect to health
 	s.router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -53,8 +102,19 @@ func (s *RESTServer) SetupRoutes() {
 	})
 
 	// Add middleware
-	s.router.Use(s.loggingMiddleware)
+	s.router.Use(tracing.Middleware("rest", routeName))
+	s.router.Use(s.container.Metrics.Middleware("rest", routeName))
 	s.router.Use(s.corsMiddleware)
+	s.router.Use(locale.Middleware)
+	api.Use(s.container.RateLimiter.Middleware(routeName))
+	api.Use(s.container.TenantResolver.Middleware)
+	api.Use(s.container.Authenticator.Middleware)
+
+	// The test of the routes keeps the document in sync, a gap here is only reported
+	if err := s.spec.CheckRoutes(s.router); err != nil {
+		slog.Warn("OpenAPI document is incomplete", "error", err)
+	}
+	return nil
 }
 
 // healthCheck handles health check requests
@@ -64,20 +124,44 @@ func (s *RESTServer) healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status": "ok", "service": "orders-api"}`))
 }
 
-// loggingMiddleware logs all requests
-func (s *RESTServer) loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("%s %s %s", r.RemoteAddr, r.Method, r.URL)
-		next.ServeHTTP(w, r)
-	})
+// routeName returns the name of the matched route, used as the operation for
+// rate limits and metrics, falling back to its path template
+func routeName(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "unmatched"
+	}
+	if name := route.GetName(); name != "" {
+		return name
+	}
+	if template, err := route.GetPathTemplate(); err == nil {
+		return r.Method + " " + template
+	}
+	return "unmatched"
 }
 
 // corsMiddleware handles CORS
 func (s *RESTServer) corsMiddleware(next http.Handler) http.Handler {
+	cors := s.container.Config.CORS
+	allowAny := slices.Contains(cors.AllowedOrigins, "*")
+	methods := strings.Join(cors.AllowedMethods, ", ")
+	headers := strings.Join(cors.AllowedHeaders, ", ")
+	exposed := strings.Join(cors.ExposedHeaders, ", ")
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origin := r.Header.Get("Origin")
+		switch {
+		case allowAny:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && slices.Contains(cors.AllowedOrigins, origin):
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+		if exposed != "" {
+			w.Header().Set("Access-Control-Expose-Headers", exposed)
+		}
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -90,11 +174,38 @@ func (s *RESTServer) corsMiddleware(next http.Handler) http.Handler {
 
 // Start starts the REST server
 func (s *RESTServer) Start() error {
-	s.SetupRoutes()
+	if err := s.SetupRoutes(); err != nil {
+		return err
+	}
 
-	log.Printf("REST API server starting on port %s", s.port)
-	log.Printf("Health check: http://localhost:%s/health", s.port)
-	log.Printf("API base: http://localhost:%s/api/v1", s.port)
+	config := s.container.Config.Server
+	port := config.RESTPort
 
-	return http.ListenAndServe(":"+s.port, s.router)
+	// Logging wraps the router so unmatched routes are logged too
+	s.server = &http.Server{
+		Addr:         ":" + port,
+		Handler:      logger.Middleware("rest")(s.router),
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+	}
+
+	slog.Info("REST API server starting",
+		"port", port,
+		"health_check", "http://localhost:"+port+"/health",
+		"api_base", "http://localhost:"+port+"/api/v1",
+	)
+
+	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting requests and waits for in-flight ones to finish
+func (s *RESTServer) Shutdown(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
 }
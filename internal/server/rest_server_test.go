@@ -0,0 +1,35 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"curso-go-clean-arch/internal/config"
+	"curso-go-clean-arch/internal/container"
+	"curso-go-clean-arch/internal/database"
+)
+
+// TestRoutesAreDocumented fails when a route of the REST router is missing from
+// the OpenAPI document
+func TestRoutesAreDocumented(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Driver = database.DriverSQLite
+	cfg.Database.SQLitePath = filepath.Join(t.TempDir(), "orders.db")
+	// Register the optional routes too
+	cfg.Features.Metrics = true
+
+	c, err := container.NewContainer(cfg)
+	if err != nil {
+		t.Fatalf("creating container: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	s := NewRESTServer(c)
+	if err := s.SetupRoutes(); err != nil {
+		t.Fatalf("setting up routes: %v", err)
+	}
+
+	if err := s.spec.CheckRoutes(s.router); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,91 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"curso-go-clean-arch/internal/problem"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Middleware resolves the tenant for every HTTP request and stores it in the request context
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tenantID, err := r.Resolve(req.Header, req.Host)
+		if errors.Is(err, ErrTenantForbidden) {
+			problem.Forbidden(w, req, "Tenant resolution failed: "+err.Error())
+			return
+		}
+		if err != nil {
+			problem.Write(w, req, problem.New(problem.TypeInvalidTenant, http.StatusBadRequest, "Tenant resolution failed: "+err.Error()))
+			return
+		}
+
+		next.ServeHTTP(w, req.WithContext(WithTenant(req.Context(), tenantID)))
+	})
+}
+
+// UnaryServerInterceptor resolves the tenant from gRPC metadata for every unary call
+func (r *Resolver) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := r.fromIncomingContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor resolves the tenant from gRPC metadata for every streaming call
+func (r *Resolver) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := r.fromIncomingContext(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// fromIncomingContext resolves the tenant from incoming gRPC metadata
+func (r *Resolver) fromIncomingContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	header := http.Header{}
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	var host string
+	if authority := md.Get(":authority"); len(authority) > 0 {
+		host = authority[0]
+	}
+
+	tenantID, err := r.Resolve(header, host)
+	if errors.Is(err, ErrTenantForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "tenant resolution failed: "+err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "tenant resolution failed: "+err.Error())
+	}
+
+	return WithTenant(ctx, tenantID), nil
+}
+
+// tenantStream overrides the context of a server stream
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the tenant-aware context
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
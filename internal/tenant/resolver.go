@@ -0,0 +1,186 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config holds tenant resolution configuration
+type Config struct {
+	Header     string `yaml:"header" toml:"header" env:"TENANT_HEADER"`
+	JWTSecret  string `yaml:"jwt_secret" toml:"jwt_secret" env:"TENANT_JWT_SECRET"`
+	JWTClaim   string `yaml:"jwt_claim" toml:"jwt_claim" env:"TENANT_JWT_CLAIM"`
+	BaseDomain string `yaml:"base_domain" toml:"base_domain" env:"TENANT_BASE_DOMAIN"`
+	Default    string `yaml:"default" toml:"default" env:"TENANT_DEFAULT"`
+	// TrustHeader accepts the header and subdomain of requests without a token
+	// even when JWTSecret is set, for deployments behind a gateway that sets them
+	TrustHeader bool `yaml:"trust_header" toml:"trust_header" env:"TENANT_TRUST_HEADER"`
+}
+
+// DefaultConfig returns the default tenant resolution configuration
+func DefaultConfig() Config {
+	return Config{
+		Header:   "X-Tenant-ID",
+		JWTClaim: "tenant_id",
+	}
+}
+
+// Validate checks the tenant resolution configuration
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Header == "" {
+		errs = append(errs, errors.New("header: must not be empty"))
+	}
+	if c.JWTSecret != "" && c.JWTClaim == "" {
+		errs = append(errs, errors.New("jwt_claim: must not be empty when jwt_secret is set"))
+	}
+	if c.Default != "" {
+		if err := Validate(c.Default); err != nil {
+			errs = append(errs, fmt.Errorf("default: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Resolver extracts the tenant ID from incoming requests
+type Resolver struct {
+	config *Config
+}
+
+// NewResolver creates a new tenant resolver
+func NewResolver(config *Config) *Resolver {
+	return &Resolver{
+		config: config,
+	}
+}
+
+// Resolve determines the tenant for a request. When JWTSecret is set the
+// tenant of a request carrying a token is the verified claim, and a header or
+// subdomain naming another tenant is rejected with ErrTenantForbidden; requests
+// without a token may only pick their tenant when TrustHeader is set. Otherwise
+// the header, the subdomain and the default tenant are tried in that order.
+func (r *Resolver) Resolve(header http.Header, host string) (string, error) {
+	requested := header.Get(r.config.Header)
+	subdomain := r.fromSubdomain(host)
+
+	if r.config.JWTSecret != "" {
+		if token, ok := bearerToken(header.Get("Authorization")); ok {
+			tenantID, err := r.claimFromJWT(token)
+			if err != nil {
+				return "", err
+			}
+			if tenantID == "" {
+				return "", fmt.Errorf("token has no %s claim", r.config.JWTClaim)
+			}
+			for _, other := range []string{requested, subdomain} {
+				if other != "" && other != tenantID {
+					return "", fmt.Errorf("%w: %q does not match the tenant of the token", ErrTenantForbidden, other)
+				}
+			}
+			return tenantID, Validate(tenantID)
+		}
+
+		if !r.config.TrustHeader && (requested != "" || subdomain != "") {
+			return "", fmt.Errorf("%w: send a token to choose the tenant", ErrTenantForbidden)
+		}
+	}
+
+	if requested != "" {
+		return requested, Validate(requested)
+	}
+
+	if subdomain != "" {
+		return subdomain, Validate(subdomain)
+	}
+
+	if r.config.Default != "" {
+		return r.config.Default, nil
+	}
+
+	return "", ErrMissingTenant
+}
+
+// fromSubdomain returns the left-most label of host when it is a subdomain of BaseDomain
+func (r *Resolver) fromSubdomain(host string) string {
+	if r.config.BaseDomain == "" || host == "" {
+		return ""
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	suffix := "." + strings.TrimPrefix(r.config.BaseDomain, ".")
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+
+	sub := strings.TrimSuffix(host, suffix)
+	if strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
+
+// claimFromJWT verifies an HS256 token and returns the configured tenant claim
+func (r *Resolver) claimFromJWT(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("invalid token header: %w", err)
+	}
+	if header.Alg != "HS256" {
+		return "", fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+
+	mac := hmac.New(sha256.New, []byte(r.config.JWTSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errors.New("invalid token signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	if exp, ok := claims["exp"].(float64); ok && time.Now().Unix() > int64(exp) {
+		return "", errors.New("token expired")
+	}
+
+	tenantID, _ := claims[r.config.JWTClaim].(string)
+	return tenantID, nil
+}
+
+// decodeSegment decodes a base64url JWT segment into v
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// bearerToken extracts the token from an Authorization header value
+func bearerToken(value string) (string, bool) {
+	const prefix = "Bearer "
+	if len(value) > len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+		return value[len(prefix):], true
+	}
+	return "", false
+}
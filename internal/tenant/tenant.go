@@ -0,0 +1,55 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// ErrMissingTenant is returned when an operation requires a tenant and none is present in the context
+var ErrMissingTenant = errors.New("tenant not found in context")
+
+// ErrInvalidTenant is returned when a tenant ID does not match the allowed format
+var ErrInvalidTenant = errors.New("invalid tenant ID")
+
+// ErrTenantForbidden is returned when a request asks for a tenant its credentials do not grant
+var ErrTenantForbidden = errors.New("tenant not allowed")
+
+// validID restricts tenant IDs to a safe subset that fits the tenant_id column
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+type contextKey struct{}
+
+// WithTenant returns a copy of ctx carrying the given tenant ID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// WithoutTenant returns a copy of ctx carrying no tenant, for operations
+// spanning every tenant
+func WithoutTenant(ctx context.Context) context.Context {
+	return WithTenant(ctx, "")
+}
+
+// FromContext returns the tenant ID stored in ctx, if any
+func FromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(contextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// MustFromContext returns the tenant ID stored in ctx or ErrMissingTenant
+func MustFromContext(ctx context.Context) (string, error) {
+	tenantID, ok := FromContext(ctx)
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	return tenantID, nil
+}
+
+// Validate checks that a tenant ID has an acceptable format
+func Validate(tenantID string) error {
+	if !validID.MatchString(tenantID) {
+		return ErrInvalidTenant
+	}
+	return nil
+}
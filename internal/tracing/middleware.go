@@ -0,0 +1,230 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Middleware starts a server span for every HTTP request, continuing the trace
+// from an incoming traceparent header. operation names the span.
+func Middleware(transport string, operation func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			ctx, span := Tracer().Start(ctx, transport+" "+operation(r),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("transport", transport),
+				),
+			)
+			defer span.End()
+
+			rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.response.status_code", rw.status))
+			if rw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rw.status))
+			}
+		})
+	}
+}
+
+// UnaryServerInterceptor starts a server span for every unary gRPC call
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, span := startGRPCSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		endGRPCSpan(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor starts a server span for every gRPC stream
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startGRPCSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		endGRPCSpan(span, err)
+		return err
+	}
+}
+
+// startGRPCSpan extracts the trace context from gRPC metadata and starts a span
+func startGRPCSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return Tracer().Start(ctx, fullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+			attribute.String("transport", "grpc"),
+		),
+	)
+}
+
+// endGRPCSpan records the gRPC status code on the span
+func endGRPCSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, code.String())
+	}
+}
+
+// GraphQLExtension returns a gqlgen extension that names the request span after
+// the GraphQL operation and records resolver errors on it
+func GraphQLExtension() graphql.HandlerExtension {
+	return graphQLExtension{}
+}
+
+// graphQLExtension enriches the span started by Middleware
+type graphQLExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = graphQLExtension{}
+
+// ExtensionName returns the extension name
+func (graphQLExtension) ExtensionName() string {
+	return "Tracing"
+}
+
+// Validate is a no-op, the extension works with any schema
+func (graphQLExtension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse annotates the current span with the operation details
+func (graphQLExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+
+	span := trace.SpanFromContext(ctx)
+	if !graphql.HasOperationContext(ctx) || !span.IsRecording() {
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil {
+		span.SetAttributes(attribute.String("graphql.operation.type", string(oc.Operation.Operation)))
+	}
+	if oc.OperationName != "" {
+		span.SetName("graphql " + oc.OperationName)
+		span.SetAttributes(attribute.String("graphql.operation.name", oc.OperationName))
+	}
+	if resp != nil && len(resp.Errors) > 0 {
+		span.SetStatus(codes.Error, resp.Errors.Error())
+	}
+
+	return resp
+}
+
+// UseCaseObserver implements usecase.Observer, wrapping every use case execution in a span
+type UseCaseObserver struct{}
+
+// Start starts a span for the use case and ends it with its result
+func (UseCaseObserver) Start(ctx context.Context, useCase string) (context.Context, func(err error)) {
+	ctx, span := Tracer().Start(ctx, "usecase."+useCase)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+// Get returns the first value for key
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set sets a value for key
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys lists all keys in the carrier
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// serverStream overrides the context of a server stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the span
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// statusRecorder records the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write marks the header as written with the default status
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming responses
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
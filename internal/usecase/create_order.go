@@ -2,51 +2,126 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"curso-go-clean-arch/internal/domain/entity"
 	"curso-go-clean-arch/internal/domain/repository"
 )
 
-// CreateOrderInput represents the input data for creating an order
+// CreateOrderInput represents the input data for creating an order. Currency is
+// the ISO 4217 code of the item prices, entity.DefaultCurrency when empty.
 type CreateOrderInput struct {
-	Description string `json:"description" validate:"required"`
+	Description    string      `json:"description" validate:"required,max=255,validtext"`
+	Items          []OrderItem `json:"items,omitempty" validate:"max=100,dive"`
+	Currency       string      `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	IdempotencyKey string      `json:"idempotency_key,omitempty" validate:"max=255,validtext"`
 }
 
 // CreateOrderOutput represents the output data for creating an order
 type CreateOrderOutput struct {
-	ID          string `json:"id"`
-	Description string `json:"description"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items"`
+	Currency    string      `json:"currency"`
+	TotalAmount int64       `json:"total_amount"`
+	Status      string      `json:"status"`
+	CreatedAt   string      `json:"created_at"`
+	UpdatedAt   string      `json:"updated_at"`
 }
 
 // CreateOrderUseCase handles the business logic for creating orders
 type CreateOrderUseCase struct {
-	orderRepository repository.OrderRepository
+	orderRepository    repository.OrderRepository
+	transactionManager repository.TransactionManager
+	observer           Observer
 }
 
 // NewCreateOrderUseCase creates a new instance of CreateOrderUseCase
-func NewCreateOrderUseCase(orderRepository repository.OrderRepository) *CreateOrderUseCase {
+func NewCreateOrderUseCase(orderRepository repository.OrderRepository, transactionManager repository.TransactionManager, observer Observer) *CreateOrderUseCase {
 	return &CreateOrderUseCase{
-		orderRepository: orderRepository,
+		orderRepository:    orderRepository,
+		transactionManager: transactionManager,
+		observer:           observer,
 	}
 }
 
 // Execute performs the create order operation
-func (uc *CreateOrderUseCase) Execute(ctx context.Context, input CreateOrderInput) (*CreateOrderOutput, error) {
+func (uc *CreateOrderUseCase) Execute(ctx context.Context, input CreateOrderInput) (_ *CreateOrderOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "CreateOrder")
+	defer func() { finish(err) }()
+
+	input.Description = strings.TrimSpace(input.Description)
+	trimItems(input.Items)
+	input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+	input.IdempotencyKey = strings.TrimSpace(input.IdempotencyKey)
+	if err := inputValidator.Struct(ctx, input); err != nil {
+		return nil, err
+	}
+
+	var output *CreateOrderOutput
+	err = uc.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var txErr error
+		output, txErr = uc.createOrder(ctx, input)
+		return txErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// createOrder creates the order or replays the one created with the same idempotency key
+func (uc *CreateOrderUseCase) createOrder(ctx context.Context, input CreateOrderInput) (*CreateOrderOutput, error) {
+	// Replay a previous request with the same idempotency key
+	if input.IdempotencyKey != "" {
+		existing, err := uc.orderRepository.GetByIdempotencyKey(ctx, input.IdempotencyKey)
+		if err == nil {
+			return newCreateOrderOutput(existing), nil
+		}
+		if !errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, err
+		}
+	}
+
 	// Create new order entity
 	order := entity.NewOrder(input.Description)
+	order.SetItems(newEntityItems(input.Items))
+	if input.Currency != "" {
+		order.Currency = input.Currency
+	}
+	order.IdempotencyKey = input.IdempotencyKey
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Save to repository
 	if err := uc.orderRepository.Create(ctx, order); err != nil {
+		// A concurrent request with the same key won the race
+		if errors.Is(err, repository.ErrDuplicateIdempotencyKey) {
+			existing, getErr := uc.orderRepository.GetByIdempotencyKey(ctx, input.IdempotencyKey)
+			if getErr != nil {
+				return nil, getErr
+			}
+			return newCreateOrderOutput(existing), nil
+		}
 		return nil, err
 	}
 
-	// Return output
+	return newCreateOrderOutput(order), nil
+}
+
+// newCreateOrderOutput converts an order entity to CreateOrderOutput
+func newCreateOrderOutput(order *entity.Order) *CreateOrderOutput {
 	return &CreateOrderOutput{
 		ID:          order.ID.String(),
 		Description: order.Description,
+		Items:       newOrderItems(order.Items),
+		Currency:    order.Currency,
+		TotalAmount: order.TotalAmount,
+		Status:      string(order.Status),
 		CreatedAt:   order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:   order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	}
 }
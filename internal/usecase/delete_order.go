@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// DeleteOrderUseCase handles the business logic for soft deleting orders
+type DeleteOrderUseCase struct {
+	orderRepository repository.OrderRepository
+	observer        Observer
+}
+
+// NewDeleteOrderUseCase creates a new instance of DeleteOrderUseCase
+func NewDeleteOrderUseCase(orderRepository repository.OrderRepository, observer Observer) *DeleteOrderUseCase {
+	return &DeleteOrderUseCase{
+		orderRepository: orderRepository,
+		observer:        observer,
+	}
+}
+
+// Execute soft deletes the order, it can be restored until it is purged
+func (uc *DeleteOrderUseCase) Execute(ctx context.Context, id string) (err error) {
+	ctx, finish := uc.observer.Start(ctx, "DeleteOrder")
+	defer func() { finish(err) }()
+
+	return uc.orderRepository.Delete(ctx, id)
+}
@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// DeleteWebhookUseCase handles the business logic for deleting webhook endpoints
+type DeleteWebhookUseCase struct {
+	webhookRepository repository.WebhookRepository
+	observer          Observer
+}
+
+// NewDeleteWebhookUseCase creates a new instance of DeleteWebhookUseCase
+func NewDeleteWebhookUseCase(webhookRepository repository.WebhookRepository, observer Observer) *DeleteWebhookUseCase {
+	return &DeleteWebhookUseCase{
+		webhookRepository: webhookRepository,
+		observer:          observer,
+	}
+}
+
+// Execute deletes a webhook endpoint along with its delivery log, pending
+// deliveries are not sent. Only admins may delete endpoints.
+func (uc *DeleteWebhookUseCase) Execute(ctx context.Context, id string) (err error) {
+	ctx, finish := uc.observer.Start(ctx, "DeleteWebhook")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return err
+	}
+
+	return uc.webhookRepository.DeleteEndpoint(ctx, id)
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// GetOrderOutput represents the output data for getting an order
+type GetOrderOutput struct {
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items"`
+	Currency    string      `json:"currency"`
+	TotalAmount int64       `json:"total_amount"`
+	Status      string      `json:"status"`
+	CreatedAt   string      `json:"created_at"`
+	UpdatedAt   string      `json:"updated_at"`
+	DeletedAt   string      `json:"deleted_at,omitempty"`
+}
+
+// GetOrderUseCase handles the business logic for getting a single order
+type GetOrderUseCase struct {
+	orderRepository repository.OrderRepository
+	observer        Observer
+}
+
+// NewGetOrderUseCase creates a new instance of GetOrderUseCase
+func NewGetOrderUseCase(orderRepository repository.OrderRepository, observer Observer) *GetOrderUseCase {
+	return &GetOrderUseCase{
+		orderRepository: orderRepository,
+		observer:        observer,
+	}
+}
+
+// Execute returns an order, soft deleted orders only when includeDeleted is set
+func (uc *GetOrderUseCase) Execute(ctx context.Context, id string, includeDeleted bool) (_ *GetOrderOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "GetOrder")
+	defer func() { finish(err) }()
+
+	get := uc.orderRepository.GetByID
+	if includeDeleted {
+		get = uc.orderRepository.GetByIDIncludingDeleted
+	}
+	order, err := get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &GetOrderOutput{
+		ID:          order.ID.String(),
+		Description: order.Description,
+		Items:       newOrderItems(order.Items),
+		Currency:    order.Currency,
+		TotalAmount: order.TotalAmount,
+		Status:      string(order.Status),
+		CreatedAt:   order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if order.DeletedAt != nil {
+		output.DeletedAt = order.DeletedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return output, nil
+}
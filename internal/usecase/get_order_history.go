@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// FieldChangeOutput represents the before and after value of a changed field
+type FieldChangeOutput struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// OrderHistoryOutput represents a single entry of the history of an order
+type OrderHistoryOutput struct {
+	ID           string              `json:"id"`
+	OrderID      string              `json:"order_id"`
+	Action       string              `json:"action"`
+	Actor        string              `json:"actor"`
+	ClaimedActor string              `json:"claimed_actor,omitempty"`
+	RequestID    string              `json:"request_id,omitempty"`
+	Changes      []FieldChangeOutput `json:"changes"`
+	CreatedAt    string              `json:"created_at"`
+}
+
+// GetOrderHistoryUseCase handles the business logic for reading the audit history of an order
+type GetOrderHistoryUseCase struct {
+	orderRepository   repository.OrderRepository
+	historyRepository repository.OrderHistoryRepository
+	observer          Observer
+}
+
+// NewGetOrderHistoryUseCase creates a new instance of GetOrderHistoryUseCase
+func NewGetOrderHistoryUseCase(orderRepository repository.OrderRepository, historyRepository repository.OrderHistoryRepository, observer Observer) *GetOrderHistoryUseCase {
+	return &GetOrderHistoryUseCase{
+		orderRepository:   orderRepository,
+		historyRepository: historyRepository,
+		observer:          observer,
+	}
+}
+
+// Execute returns the history of an order, oldest entry first. The history of
+// soft deleted and purged orders remains available.
+func (uc *GetOrderHistoryUseCase) Execute(ctx context.Context, orderID string) (_ []*OrderHistoryOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "GetOrderHistory")
+	defer func() { finish(err) }()
+
+	entries, err := uc.historyRepository.ListByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Orders created before the history was recorded have no entries yet,
+	// only report unknown orders as not found
+	if len(entries) == 0 {
+		if _, err := uc.orderRepository.GetByIDIncludingDeleted(ctx, orderID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Convert to output format
+	output := make([]*OrderHistoryOutput, 0, len(entries))
+	for _, entry := range entries {
+		output = append(output, orderHistoryOutput(entry))
+	}
+
+	return output, nil
+}
+
+// ExecuteMany returns the history of several orders in one lookup, grouped by
+// order ID, oldest entry first. It serves lists of orders the caller already
+// read, so orders without entries are not checked for existence.
+func (uc *GetOrderHistoryUseCase) ExecuteMany(ctx context.Context, orderIDs []string) (_ map[string][]*OrderHistoryOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "GetOrdersHistory")
+	defer func() { finish(err) }()
+
+	entries, err := uc.historyRepository.ListByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make(map[string][]*OrderHistoryOutput, len(orderIDs))
+	for _, entry := range entries {
+		orderID := entry.OrderID.String()
+		output[orderID] = append(output[orderID], orderHistoryOutput(entry))
+	}
+
+	return output, nil
+}
+
+// orderHistoryOutput converts a history entry to its output format
+func orderHistoryOutput(entry *entity.OrderHistoryEntry) *OrderHistoryOutput {
+	output := &OrderHistoryOutput{
+		ID:           entry.ID.String(),
+		OrderID:      entry.OrderID.String(),
+		Action:       string(entry.Action),
+		Actor:        entry.Actor,
+		ClaimedActor: entry.ClaimedActor,
+		RequestID:    entry.RequestID,
+		Changes:      make([]FieldChangeOutput, 0, len(entry.Changes)),
+		CreatedAt:    entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for _, change := range entry.Changes {
+		output.Changes = append(output.Changes, FieldChangeOutput(change))
+	}
+	return output
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// GetOrderStatsInput represents the input data for the order statistics. From
+// and To accept RFC 3339 timestamps or dates, read in TimeZone; both default to
+// a window ending with the current period.
+type GetOrderStatsInput struct {
+	GroupBy        string `json:"group_by"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	TimeZone       string `json:"time_zone"`
+	IncludeDeleted bool   `json:"include_deleted"`
+}
+
+// OrderStatsBucketOutput represents the orders created in one period
+type OrderStatsBucketOutput struct {
+	Start    string           `json:"start"`
+	End      string           `json:"end"`
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"`
+	Revenue  map[string]int64 `json:"revenue"`
+}
+
+// GetOrderStatsOutput represents the order statistics of a time window. Revenue
+// sums the totals of the orders not cancelled per currency, in minor units.
+type GetOrderStatsOutput struct {
+	GroupBy  string                    `json:"group_by"`
+	TimeZone string                    `json:"time_zone"`
+	From     string                    `json:"from"`
+	To       string                    `json:"to"`
+	Total    int64                     `json:"total"`
+	ByStatus map[string]int64          `json:"by_status"`
+	Revenue  map[string]int64          `json:"revenue"`
+	Buckets  []*OrderStatsBucketOutput `json:"buckets"`
+}
+
+// defaultStatsPeriods is how many periods the statistics span when From is omitted
+var defaultStatsPeriods = map[entity.StatsGroupBy]int{
+	entity.StatsGroupByDay:   30,
+	entity.StatsGroupByWeek:  12,
+	entity.StatsGroupByMonth: 12,
+}
+
+// GetOrderStatsUseCase handles the business logic for the order reports. It
+// reads the order summaries of the query side.
+type GetOrderStatsUseCase struct {
+	queryService repository.OrderQueryService
+	observer     Observer
+}
+
+// NewGetOrderStatsUseCase creates a new instance of GetOrderStatsUseCase
+func NewGetOrderStatsUseCase(queryService repository.OrderQueryService, observer Observer) *GetOrderStatsUseCase {
+	return &GetOrderStatsUseCase{
+		queryService: queryService,
+		observer:     observer,
+	}
+}
+
+// Execute counts the orders created per period and status and sums their
+// revenue. The window is widened to whole periods and periods without orders
+// are reported with zeros.
+func (uc *GetOrderStatsUseCase) Execute(ctx context.Context, input GetOrderStatsInput) (_ *GetOrderStatsOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "GetOrderStats")
+	defer func() { finish(err) }()
+
+	query, err := parseOrderStatsQuery(input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := uc.queryService.Stats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// One bucket per period of the window, filled with the counted rows
+	output := &GetOrderStatsOutput{
+		GroupBy:  string(query.GroupBy),
+		TimeZone: query.Location.String(),
+		From:     query.From.Format(time.RFC3339),
+		To:       query.To.Format(time.RFC3339),
+		ByStatus: emptyStatusCounts(),
+		Revenue:  make(map[string]int64),
+	}
+	buckets := make(map[int64]*OrderStatsBucketOutput)
+	for start := query.From; start.Before(query.To); start = query.GroupBy.Next(start) {
+		bucket := &OrderStatsBucketOutput{
+			Start:    start.Format(time.RFC3339),
+			End:      query.GroupBy.Next(start).Format(time.RFC3339),
+			ByStatus: emptyStatusCounts(),
+			Revenue:  make(map[string]int64),
+		}
+		buckets[start.Unix()] = bucket
+		output.Buckets = append(output.Buckets, bucket)
+	}
+
+	for _, row := range rows {
+		bucket, ok := buckets[row.Bucket.Unix()]
+		if !ok {
+			return nil, fmt.Errorf("error reading order stats: unexpected period %s", row.Bucket.Format(time.RFC3339))
+		}
+		bucket.Total += row.Count
+		bucket.ByStatus[string(row.Status)] += row.Count
+		output.Total += row.Count
+		output.ByStatus[string(row.Status)] += row.Count
+		// Cancelled orders were not paid for
+		if row.Status != entity.OrderStatusCancelled {
+			bucket.Revenue[row.Currency] += row.Amount
+			output.Revenue[row.Currency] += row.Amount
+		}
+	}
+
+	return output, nil
+}
+
+// parseOrderStatsQuery validates the input and resolves its defaults relative to now
+func parseOrderStatsQuery(input GetOrderStatsInput, now time.Time) (entity.OrderStatsQuery, error) {
+	query := entity.OrderStatsQuery{
+		GroupBy:        entity.StatsGroupBy(input.GroupBy),
+		IncludeDeleted: input.IncludeDeleted,
+	}
+	if query.GroupBy == "" {
+		query.GroupBy = entity.StatsGroupByDay
+	}
+	if !query.GroupBy.Valid() {
+		return query, fmt.Errorf("%w: group by must be day, week or month, got %q", entity.ErrInvalidStatsQuery, input.GroupBy)
+	}
+
+	timeZone := input.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil || timeZone == "Local" {
+		return query, fmt.Errorf("%w: unknown time zone %q", entity.ErrInvalidStatsQuery, timeZone)
+	}
+	query.Location = location
+
+	// The window always covers whole periods, which also keeps the cache keys
+	// of the default window stable within a period
+	query.To = query.GroupBy.Next(query.GroupBy.Truncate(now, location))
+	if input.To != "" {
+		to, err := parseStatsTime(input.To, location)
+		if err != nil {
+			return query, fmt.Errorf("%w: to: %v", entity.ErrInvalidStatsQuery, err)
+		}
+		query.To = query.GroupBy.Truncate(to, location)
+		if !query.To.Equal(to) {
+			query.To = query.GroupBy.Next(query.To)
+		}
+	}
+
+	query.From = query.To
+	for range defaultStatsPeriods[query.GroupBy] {
+		query.From = query.GroupBy.Truncate(query.From.Add(-time.Nanosecond), location)
+	}
+	if input.From != "" {
+		from, err := parseStatsTime(input.From, location)
+		if err != nil {
+			return query, fmt.Errorf("%w: from: %v", entity.ErrInvalidStatsQuery, err)
+		}
+		query.From = query.GroupBy.Truncate(from, location)
+	}
+
+	return query, query.Validate()
+}
+
+// parseStatsTime parses an RFC 3339 timestamp, or a date at midnight in location
+func parseStatsTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 timestamp or a YYYY-MM-DD date, got %q", value)
+	}
+	return t, nil
+}
+
+// emptyStatusCounts returns a count of zero for every order status
+func emptyStatusCounts() map[string]int64 {
+	counts := make(map[string]int64, len(entity.OrderStatuses))
+	for _, status := range entity.OrderStatuses {
+		counts[string(status)] = 0
+	}
+	return counts
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// importBatchSize is the number of orders written per transaction
+const importBatchSize = 500
+
+// ImportOrderInput represents an order to import. Empty fields get the values
+// of a new order: a random ID, the default currency, pending and the current time.
+type ImportOrderInput struct {
+	ID          string      `json:"id" validate:"omitempty,uuid"`
+	Description string      `json:"description" validate:"required,max=255,validtext"`
+	Items       []OrderItem `json:"items,omitempty" validate:"max=100,dive"`
+	Currency    string      `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	Status      string      `json:"status" validate:"omitempty,oneof=pending confirmed shipped delivered cancelled"`
+	CreatedAt   string      `json:"created_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// importOrdersInput validates every imported order, reporting fields as orders[i].name
+type importOrdersInput struct {
+	Orders []ImportOrderInput `json:"orders" validate:"dive"`
+}
+
+// ImportOrdersOutput represents the output data for importing orders
+type ImportOrdersOutput struct {
+	Imported int `json:"imported"`
+}
+
+// ImportOrdersUseCase handles the business logic for importing orders in bulk
+type ImportOrdersUseCase struct {
+	orderRepository    repository.OrderRepository
+	transactionManager repository.TransactionManager
+	observer           Observer
+}
+
+// NewImportOrdersUseCase creates a new instance of ImportOrdersUseCase
+func NewImportOrdersUseCase(orderRepository repository.OrderRepository, transactionManager repository.TransactionManager, observer Observer) *ImportOrdersUseCase {
+	return &ImportOrdersUseCase{
+		orderRepository:    orderRepository,
+		transactionManager: transactionManager,
+		observer:           observer,
+	}
+}
+
+// Execute validates every order first, then writes them in batches of
+// importBatchSize, each in its own transaction. On error the orders of the
+// batches already committed stay imported, as reported by the output.
+// Only admins may import orders.
+func (uc *ImportOrdersUseCase) Execute(ctx context.Context, inputs []ImportOrderInput) (_ *ImportOrdersOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "ImportOrders")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	for i := range inputs {
+		inputs[i].ID = strings.TrimSpace(inputs[i].ID)
+		inputs[i].Description = strings.TrimSpace(inputs[i].Description)
+		trimItems(inputs[i].Items)
+		inputs[i].Currency = strings.ToUpper(strings.TrimSpace(inputs[i].Currency))
+		inputs[i].Status = strings.TrimSpace(inputs[i].Status)
+		inputs[i].CreatedAt = strings.TrimSpace(inputs[i].CreatedAt)
+	}
+	if err := inputValidator.Struct(ctx, importOrdersInput{Orders: inputs}); err != nil {
+		return nil, err
+	}
+
+	orders := make([]*entity.Order, 0, len(inputs))
+	for i, input := range inputs {
+		order, err := newImportedOrder(input)
+		if err != nil {
+			return nil, fmt.Errorf("order %d: %w", i+1, err)
+		}
+		orders = append(orders, order)
+	}
+
+	output := &ImportOrdersOutput{}
+	for start := 0; start < len(orders); start += importBatchSize {
+		batch := orders[start:min(start+importBatchSize, len(orders))]
+		err := uc.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			return createOrders(ctx, uc.orderRepository, batch)
+		})
+		if err != nil {
+			return output, err
+		}
+		output.Imported += len(batch)
+	}
+
+	return output, nil
+}
+
+// newImportedOrder builds the order of an import input
+func newImportedOrder(input ImportOrderInput) (*entity.Order, error) {
+	order := entity.NewOrder(input.Description)
+	order.SetItems(newEntityItems(input.Items))
+	if input.Currency != "" {
+		order.Currency = input.Currency
+	}
+	if input.ID != "" {
+		id, err := uuid.Parse(input.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", repository.ErrInvalidOrderID, err)
+		}
+		order.ID = id
+	}
+	if input.Status != "" {
+		order.Status = entity.OrderStatus(input.Status)
+	}
+	if input.CreatedAt != "" {
+		createdAt, err := time.Parse(time.RFC3339, input.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: created_at must be an RFC 3339 timestamp, got %q", entity.ErrInvalidOrder, input.CreatedAt)
+		}
+		order.CreatedAt = createdAt
+		order.UpdatedAt = createdAt
+	}
+
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// createOrders saves orders in bulk when the repository supports it, one by one otherwise
+func createOrders(ctx context.Context, orderRepository repository.OrderRepository, orders []*entity.Order) error {
+	if bulk, ok := orderRepository.(repository.OrderBulkCreator); ok {
+		return bulk.CreateMany(ctx, orders)
+	}
+	for _, order := range orders {
+		if err := orderRepository.Create(ctx, order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
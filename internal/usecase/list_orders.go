@@ -6,30 +6,46 @@ import (
 	"curso-go-clean-arch/internal/domain/repository"
 )
 
+// ListOrdersInput represents the input data for listing orders
+type ListOrdersInput struct {
+	IncludeDeleted bool `json:"include_deleted"`
+}
+
 // ListOrdersOutput represents the output data for listing orders
 type ListOrdersOutput struct {
-	ID          string `json:"id"`
-	Description string `json:"description"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items"`
+	Currency    string      `json:"currency"`
+	TotalAmount int64       `json:"total_amount"`
+	Status      string      `json:"status"`
+	CreatedAt   string      `json:"created_at"`
+	UpdatedAt   string      `json:"updated_at"`
+	DeletedAt   string      `json:"deleted_at,omitempty"`
 }
 
-// ListOrdersUseCase handles the business logic for listing orders
+// ListOrdersUseCase handles the business logic for listing orders. It reads
+// the order summaries of the query side, not the orders written by the commands.
 type ListOrdersUseCase struct {
-	orderRepository repository.OrderRepository
+	queryService repository.OrderQueryService
+	observer     Observer
 }
 
 // NewListOrdersUseCase creates a new instance of ListOrdersUseCase
-func NewListOrdersUseCase(orderRepository repository.OrderRepository) *ListOrdersUseCase {
+func NewListOrdersUseCase(queryService repository.OrderQueryService, observer Observer) *ListOrdersUseCase {
 	return &ListOrdersUseCase{
-		orderRepository: orderRepository,
+		queryService: queryService,
+		observer:     observer,
 	}
 }
 
 // Execute performs the list orders operation
-func (uc *ListOrdersUseCase) Execute(ctx context.Context) ([]*ListOrdersOutput, error) {
-	// Get orders from repository
-	orders, err := uc.orderRepository.List(ctx)
+func (uc *ListOrdersUseCase) Execute(ctx context.Context, input ListOrdersInput) (_ []*ListOrdersOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "ListOrders")
+	defer func() { finish(err) }()
+
+	// Get order summaries from the query side
+	orders, err := uc.queryService.List(ctx, repository.ListOptions{IncludeDeleted: input.IncludeDeleted})
 	if err != nil {
 		return nil, err
 	}
@@ -37,12 +53,20 @@ func (uc *ListOrdersUseCase) Execute(ctx context.Context) ([]*ListOrdersOutput,
 	// Convert to output format
 	var output []*ListOrdersOutput
 	for _, order := range orders {
-		output = append(output, &ListOrdersOutput{
+		item := &ListOrdersOutput{
 			ID:          order.ID.String(),
 			Description: order.Description,
+			Items:       newOrderItems(order.Items),
+			Currency:    order.Currency,
+			TotalAmount: order.TotalAmount,
+			Status:      string(order.Status),
 			CreatedAt:   order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:   order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		})
+		}
+		if order.DeletedAt != nil {
+			item.DeletedAt = order.DeletedAt.Format("2006-01-02T15:04:05Z07:00")
+		}
+		output = append(output, item)
 	}
 
 	return output, nil
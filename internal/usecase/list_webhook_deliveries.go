@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// webhookDeliveriesLimit is the number of most recent deliveries listed per endpoint
+const webhookDeliveriesLimit = 100
+
+// WebhookDeliveryOutput represents a delivery of an event to a webhook endpoint
+type WebhookDeliveryOutput struct {
+	ID             string  `json:"id"`
+	EndpointID     string  `json:"endpoint_id"`
+	EventID        string  `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	NextAttemptAt  string  `json:"next_attempt_at"`
+	LastStatusCode int     `json:"last_status_code,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+	DeliveredAt    *string `json:"delivered_at,omitempty"`
+}
+
+// ListWebhookDeliveriesUseCase handles the business logic for reading the delivery log of a webhook endpoint
+type ListWebhookDeliveriesUseCase struct {
+	webhookRepository repository.WebhookRepository
+	observer          Observer
+}
+
+// NewListWebhookDeliveriesUseCase creates a new instance of ListWebhookDeliveriesUseCase
+func NewListWebhookDeliveriesUseCase(webhookRepository repository.WebhookRepository, observer Observer) *ListWebhookDeliveriesUseCase {
+	return &ListWebhookDeliveriesUseCase{
+		webhookRepository: webhookRepository,
+		observer:          observer,
+	}
+}
+
+// Execute returns the most recent deliveries of an endpoint, newest first,
+// optionally only those with the given status. Only admins may read the log.
+func (uc *ListWebhookDeliveriesUseCase) Execute(ctx context.Context, endpointID, status string) (_ []*WebhookDeliveryOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "ListWebhookDeliveries")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if status != "" && !entity.WebhookDeliveryStatus(status).Valid() {
+		return nil, fmt.Errorf("%w: %q", entity.ErrInvalidWebhookDeliveryStatus, status)
+	}
+
+	// Unknown endpoints are reported as not found rather than an empty log
+	if _, err := uc.webhookRepository.GetEndpoint(ctx, endpointID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := uc.webhookRepository.ListDeliveries(ctx, endpointID, entity.WebhookDeliveryStatus(status), webhookDeliveriesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*WebhookDeliveryOutput, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		outputs = append(outputs, newWebhookDeliveryOutput(delivery))
+	}
+
+	return outputs, nil
+}
+
+// newWebhookDeliveryOutput converts a webhook delivery
+func newWebhookDeliveryOutput(delivery *entity.WebhookDelivery) *WebhookDeliveryOutput {
+	output := &WebhookDeliveryOutput{
+		ID:             delivery.ID.String(),
+		EndpointID:     delivery.EndpointID.String(),
+		EventID:        delivery.EventID.String(),
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt.Format("2006-01-02T15:04:05Z07:00"),
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      delivery.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if delivery.DeliveredAt != nil {
+		deliveredAt := delivery.DeliveredAt.Format("2006-01-02T15:04:05Z07:00")
+		output.DeliveredAt = &deliveredAt
+	}
+	return output
+}
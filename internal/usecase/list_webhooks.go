@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// ListWebhooksUseCase handles the business logic for listing webhook endpoints
+type ListWebhooksUseCase struct {
+	webhookRepository repository.WebhookRepository
+	observer          Observer
+}
+
+// NewListWebhooksUseCase creates a new instance of ListWebhooksUseCase
+func NewListWebhooksUseCase(webhookRepository repository.WebhookRepository, observer Observer) *ListWebhooksUseCase {
+	return &ListWebhooksUseCase{
+		webhookRepository: webhookRepository,
+		observer:          observer,
+	}
+}
+
+// Execute returns the webhook endpoints of the tenant. Only admins may list endpoints.
+func (uc *ListWebhooksUseCase) Execute(ctx context.Context) (_ []*WebhookEndpointOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "ListWebhooks")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	endpoints, err := uc.webhookRepository.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*WebhookEndpointOutput, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		outputs = append(outputs, newWebhookEndpointOutput(endpoint))
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+)
+
+// Observer is notified around every use case execution, which lets metrics and
+// tracing instrument use cases without the use cases depending on them
+type Observer interface {
+	// Start is called before the use case runs. The returned context is passed to
+	// the use case and the returned function is called with its result.
+	Start(ctx context.Context, useCase string) (context.Context, func(err error))
+}
+
+// NoopObserver is an Observer that does nothing
+type NoopObserver struct{}
+
+// Start returns ctx unchanged and a no-op finish function
+func (NoopObserver) Start(ctx context.Context, useCase string) (context.Context, func(err error)) {
+	return ctx, func(error) {}
+}
+
+// Observers fans out to several observers, finishing them in reverse order
+type Observers []Observer
+
+// Start starts every observer in order
+func (o Observers) Start(ctx context.Context, useCase string) (context.Context, func(err error)) {
+	finishers := make([]func(error), 0, len(o))
+	for _, observer := range o {
+		var finish func(error)
+		ctx, finish = observer.Start(ctx, useCase)
+		finishers = append(finishers, finish)
+	}
+
+	return ctx, func(err error) {
+		for i := len(finishers) - 1; i >= 0; i-- {
+			finishers[i](err)
+		}
+	}
+}
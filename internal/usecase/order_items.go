@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"strings"
+
+	"curso-go-clean-arch/internal/domain/entity"
+)
+
+// OrderItem is an item of an order in the inputs and outputs of the use cases,
+// its rules matching entity.MaxItemQuantity and entity.MaxItemUnitPrice
+type OrderItem struct {
+	Name     string `json:"name" validate:"required,max=255,validtext"`
+	Quantity int    `json:"quantity" validate:"min=1,max=10000"`
+	// UnitPrice is the price of one unit in minor units of the currency, e.g. cents
+	UnitPrice int64 `json:"unit_price" validate:"min=0,max=100000000"`
+}
+
+// trimItems trims the names of items in place
+func trimItems(items []OrderItem) {
+	for i := range items {
+		items[i].Name = strings.TrimSpace(items[i].Name)
+	}
+}
+
+// newEntityItems converts the items of an input to order items, nil when there are none
+func newEntityItems(items []OrderItem) []entity.OrderItem {
+	if len(items) == 0 {
+		return nil
+	}
+	converted := make([]entity.OrderItem, len(items))
+	for i, item := range items {
+		converted[i] = entity.OrderItem{Name: item.Name, Quantity: item.Quantity, UnitPrice: item.UnitPrice}
+	}
+	return converted
+}
+
+// newOrderItems converts order items to the items of an output, an empty list when there are none
+func newOrderItems(items []entity.OrderItem) []OrderItem {
+	converted := make([]OrderItem, len(items))
+	for i, item := range items {
+		converted[i] = OrderItem{Name: item.Name, Quantity: item.Quantity, UnitPrice: item.UnitPrice}
+	}
+	return converted
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+)
+
+// PurgeOrderUseCase handles the business logic for permanently removing soft deleted orders
+type PurgeOrderUseCase struct {
+	orderRepository repository.OrderRepository
+	observer        Observer
+}
+
+// NewPurgeOrderUseCase creates a new instance of PurgeOrderUseCase
+func NewPurgeOrderUseCase(orderRepository repository.OrderRepository, observer Observer) *PurgeOrderUseCase {
+	return &PurgeOrderUseCase{
+		orderRepository: orderRepository,
+		observer:        observer,
+	}
+}
+
+// Execute permanently removes a soft deleted order. Only admins may purge orders.
+func (uc *PurgeOrderUseCase) Execute(ctx context.Context, id string) (err error) {
+	ctx, finish := uc.observer.Start(ctx, "PurgeOrder")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return err
+	}
+
+	return uc.orderRepository.Purge(ctx, id)
+}
+
+// purgeJobActor is recorded in the history of the orders purged by the purge job
+const purgeJobActor = "purge-job"
+
+// PurgeExpired permanently removes the orders of every tenant soft deleted for longer
+// than retention. It runs on behalf of the purge job, not of a caller: each order is
+// purged through Purge for its tenant, so its history, projections, webhooks and
+// events are written as for a purge requested by an admin.
+func (uc *PurgeOrderUseCase) PurgeExpired(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, finish := uc.observer.Start(ctx, "PurgeExpiredOrders")
+	defer func() { finish(err) }()
+
+	deleted, err := uc.orderRepository.ListDeletedBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = auth.WithPrincipal(ctx, auth.Principal{Actor: purgeJobActor, Admin: true})
+	var purged int64
+	for _, order := range deleted {
+		err := uc.orderRepository.Purge(tenant.WithTenant(ctx, order.TenantID), order.ID)
+		switch {
+		case err == nil:
+			purged++
+		case errors.Is(err, repository.ErrOrderNotFound), errors.Is(err, repository.ErrOrderConcurrentModification):
+			// Restored or purged since it was listed
+		default:
+			return purged, fmt.Errorf("error purging order %s of tenant %s: %w", order.ID, order.TenantID, err)
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+)
+
+// RebuildOrderProjectionsOutput represents the output data for rebuilding the order projections
+type RebuildOrderProjectionsOutput struct {
+	Projection string `json:"projection"`
+	Orders     int64  `json:"orders"`
+}
+
+// RebuildOrderProjectionsUseCase handles the business logic for rebuilding the
+// read model of orders from scratch
+type RebuildOrderProjectionsUseCase struct {
+	summaryRepository  repository.OrderSummaryRepository
+	transactionManager repository.TransactionManager
+	observer           Observer
+}
+
+// NewRebuildOrderProjectionsUseCase creates a new instance of RebuildOrderProjectionsUseCase
+func NewRebuildOrderProjectionsUseCase(summaryRepository repository.OrderSummaryRepository, transactionManager repository.TransactionManager, observer Observer) *RebuildOrderProjectionsUseCase {
+	return &RebuildOrderProjectionsUseCase{
+		summaryRepository:  summaryRepository,
+		transactionManager: transactionManager,
+		observer:           observer,
+	}
+}
+
+// Execute recomputes the order summaries of every tenant from the orders and
+// their history, in a single transaction. Only admins may rebuild projections.
+// The transaction carries no tenant, so with row level security it needs a
+// role bypassing the policies, or the repository returns ErrRowLevelSecurity.
+func (uc *RebuildOrderProjectionsUseCase) Execute(ctx context.Context) (_ *RebuildOrderProjectionsOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "RebuildOrderProjections")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	// The rebuild spans every tenant, app.tenant_id must not narrow it to the caller's
+	ctx = tenant.WithoutTenant(ctx)
+
+	var orders int64
+	err = uc.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		orders, err = uc.summaryRepository.Rebuild(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RebuildOrderProjectionsOutput{
+		Projection: "order_summaries",
+		Orders:     orders,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// RegisterWebhookInput represents the input data for registering a webhook endpoint
+type RegisterWebhookInput struct {
+	URL        string   `json:"url" validate:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=order.created order.updated order.status_changed order.deleted order.restored order.purged"`
+	// Secret signs the deliveries, one is generated when empty
+	Secret string `json:"secret,omitempty" validate:"omitempty,min=16,max=256,validtext"`
+}
+
+// WebhookEndpointOutput represents a webhook endpoint. Secret is only set when
+// the endpoint is registered, it cannot be read afterwards.
+type WebhookEndpointOutput struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// RegisterWebhookUseCase handles the business logic for registering webhook endpoints
+type RegisterWebhookUseCase struct {
+	webhookRepository repository.WebhookRepository
+	observer          Observer
+}
+
+// NewRegisterWebhookUseCase creates a new instance of RegisterWebhookUseCase
+func NewRegisterWebhookUseCase(webhookRepository repository.WebhookRepository, observer Observer) *RegisterWebhookUseCase {
+	return &RegisterWebhookUseCase{
+		webhookRepository: webhookRepository,
+		observer:          observer,
+	}
+}
+
+// Execute registers a webhook endpoint notified of the given order events of
+// the tenant. Only admins may register endpoints.
+func (uc *RegisterWebhookUseCase) Execute(ctx context.Context, input RegisterWebhookInput) (_ *WebhookEndpointOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "RegisterWebhook")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	input.URL = strings.TrimSpace(input.URL)
+	if err := inputValidator.Struct(ctx, input); err != nil {
+		return nil, err
+	}
+
+	endpoint, err := entity.NewWebhookEndpoint(input.URL, input.EventTypes, input.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.webhookRepository.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	output := newWebhookEndpointOutput(endpoint)
+	output.Secret = endpoint.Secret
+	return output, nil
+}
+
+// newWebhookEndpointOutput converts a webhook endpoint, without its secret
+func newWebhookEndpointOutput(endpoint *entity.WebhookEndpoint) *WebhookEndpointOutput {
+	return &WebhookEndpointOutput{
+		ID:         endpoint.ID.String(),
+		URL:        endpoint.URL,
+		EventTypes: endpoint.EventTypes,
+		CreatedAt:  endpoint.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
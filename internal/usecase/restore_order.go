@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// RestoreOrderOutput represents the output data for restoring an order
+type RestoreOrderOutput struct {
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items"`
+	Currency    string      `json:"currency"`
+	TotalAmount int64       `json:"total_amount"`
+	Status      string      `json:"status"`
+	CreatedAt   string      `json:"created_at"`
+	UpdatedAt   string      `json:"updated_at"`
+}
+
+// RestoreOrderUseCase handles the business logic for restoring soft deleted orders
+type RestoreOrderUseCase struct {
+	orderRepository    repository.OrderRepository
+	transactionManager repository.TransactionManager
+	observer           Observer
+}
+
+// NewRestoreOrderUseCase creates a new instance of RestoreOrderUseCase
+func NewRestoreOrderUseCase(orderRepository repository.OrderRepository, transactionManager repository.TransactionManager, observer Observer) *RestoreOrderUseCase {
+	return &RestoreOrderUseCase{
+		orderRepository:    orderRepository,
+		transactionManager: transactionManager,
+		observer:           observer,
+	}
+}
+
+// Execute restores a soft deleted order. Only admins may restore orders.
+func (uc *RestoreOrderUseCase) Execute(ctx context.Context, id string) (_ *RestoreOrderOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "RestoreOrder")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var output *RestoreOrderOutput
+	err = uc.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.orderRepository.Restore(ctx, id); err != nil {
+			return err
+		}
+
+		order, err := uc.orderRepository.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		output = &RestoreOrderOutput{
+			ID:          order.ID.String(),
+			Description: order.Description,
+			Items:       newOrderItems(order.Items),
+			Currency:    order.Currency,
+			TotalAmount: order.TotalAmount,
+			Status:      string(order.Status),
+			CreatedAt:   order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:   order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+
+	"curso-go-clean-arch/internal/auth"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// RetryWebhookDeliveryUseCase handles the business logic for redelivering webhook events
+type RetryWebhookDeliveryUseCase struct {
+	webhookRepository repository.WebhookRepository
+	observer          Observer
+}
+
+// NewRetryWebhookDeliveryUseCase creates a new instance of RetryWebhookDeliveryUseCase
+func NewRetryWebhookDeliveryUseCase(webhookRepository repository.WebhookRepository, observer Observer) *RetryWebhookDeliveryUseCase {
+	return &RetryWebhookDeliveryUseCase{
+		webhookRepository: webhookRepository,
+		observer:          observer,
+	}
+}
+
+// Execute schedules a dead or succeeded delivery of an endpoint to be sent
+// again, with a fresh attempt budget. Pending deliveries are left as they are.
+// Only admins may retry deliveries.
+func (uc *RetryWebhookDeliveryUseCase) Execute(ctx context.Context, endpointID, deliveryID string) (_ *WebhookDeliveryOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "RetryWebhookDelivery")
+	defer func() { finish(err) }()
+
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	delivery, err := uc.webhookRepository.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.EndpointID.String() != endpointID {
+		return nil, repository.ErrWebhookDeliveryNotFound
+	}
+
+	if delivery.Status != entity.WebhookDeliveryPending {
+		delivery.Redeliver()
+		if err := uc.webhookRepository.UpdateDelivery(ctx, delivery); err != nil {
+			return nil, err
+		}
+	}
+
+	return newWebhookDeliveryOutput(delivery), nil
+}
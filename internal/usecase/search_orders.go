@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// Search result limits
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchOrdersInput represents the input data for searching orders
+type SearchOrdersInput struct {
+	Query          string `json:"query"`
+	Limit          int    `json:"limit"`
+	IncludeDeleted bool   `json:"include_deleted"`
+}
+
+// SearchOrdersOutput represents a single order matching a search
+type SearchOrdersOutput struct {
+	ListOrdersOutput
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// SearchOrdersUseCase handles the business logic for the full-text search of orders
+type SearchOrdersUseCase struct {
+	queryService repository.OrderQueryService
+	observer     Observer
+}
+
+// NewSearchOrdersUseCase creates a new instance of SearchOrdersUseCase
+func NewSearchOrdersUseCase(queryService repository.OrderQueryService, observer Observer) *SearchOrdersUseCase {
+	return &SearchOrdersUseCase{
+		queryService: queryService,
+		observer:     observer,
+	}
+}
+
+// Execute searches the descriptions and item names of the orders, most relevant first. The
+// snippets mark the matches with <mark></mark> and are not HTML escaped.
+func (uc *SearchOrdersUseCase) Execute(ctx context.Context, input SearchOrdersInput) (_ []*SearchOrdersOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "SearchOrders")
+	defer func() { finish(err) }()
+
+	query, err := entity.ParseSearchQuery(input.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := input.Limit
+	switch {
+	case limit == 0:
+		limit = DefaultSearchLimit
+	case limit < 0 || limit > MaxSearchLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", entity.ErrInvalidSearchQuery, MaxSearchLimit)
+	}
+
+	results, err := uc.queryService.Search(ctx, query, repository.SearchOptions{
+		IncludeDeleted: input.IncludeDeleted,
+		Limit:          limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to output format
+	output := make([]*SearchOrdersOutput, 0, len(results))
+	for _, result := range results {
+		order := result.Summary
+		item := &SearchOrdersOutput{
+			ListOrdersOutput: ListOrdersOutput{
+				ID:          order.ID.String(),
+				Description: order.Description,
+				Items:       newOrderItems(order.Items),
+				Currency:    order.Currency,
+				TotalAmount: order.TotalAmount,
+				Status:      string(order.Status),
+				CreatedAt:   order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+				UpdatedAt:   order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			},
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		}
+		if order.DeletedAt != nil {
+			item.DeletedAt = order.DeletedAt.Format("2006-01-02T15:04:05Z07:00")
+		}
+		output = append(output, item)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// UpdateOrderInput represents the input data for updating an order, nil fields are left unchanged
+type UpdateOrderInput struct {
+	ID          string       `json:"id"`
+	Description *string      `json:"description,omitempty" validate:"omitnil,min=1,max=255,validtext"`
+	Items       *[]OrderItem `json:"items,omitempty" validate:"omitnil,max=100,dive"`
+	Status      *string      `json:"status,omitempty" validate:"omitnil,oneof=pending confirmed shipped delivered cancelled"`
+}
+
+// UpdateOrderOutput represents the output data for updating an order
+type UpdateOrderOutput struct {
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Items       []OrderItem `json:"items"`
+	Currency    string      `json:"currency"`
+	TotalAmount int64       `json:"total_amount"`
+	Status      string      `json:"status"`
+	CreatedAt   string      `json:"created_at"`
+	UpdatedAt   string      `json:"updated_at"`
+}
+
+// UpdateOrderUseCase handles the business logic for changing the description, items or status of an order
+type UpdateOrderUseCase struct {
+	orderRepository    repository.OrderRepository
+	transactionManager repository.TransactionManager
+	observer           Observer
+}
+
+// NewUpdateOrderUseCase creates a new instance of UpdateOrderUseCase
+func NewUpdateOrderUseCase(orderRepository repository.OrderRepository, transactionManager repository.TransactionManager, observer Observer) *UpdateOrderUseCase {
+	return &UpdateOrderUseCase{
+		orderRepository:    orderRepository,
+		transactionManager: transactionManager,
+		observer:           observer,
+	}
+}
+
+// Execute applies the given changes to an order that is not soft deleted
+func (uc *UpdateOrderUseCase) Execute(ctx context.Context, input UpdateOrderInput) (_ *UpdateOrderOutput, err error) {
+	ctx, finish := uc.observer.Start(ctx, "UpdateOrder")
+	defer func() { finish(err) }()
+
+	if input.Description == nil && input.Items == nil && input.Status == nil {
+		return nil, fmt.Errorf("%w: nothing to update", entity.ErrInvalidOrder)
+	}
+	if input.Description != nil {
+		description := strings.TrimSpace(*input.Description)
+		input.Description = &description
+	}
+	if input.Items != nil {
+		trimItems(*input.Items)
+	}
+	if err := inputValidator.Struct(ctx, input); err != nil {
+		return nil, err
+	}
+
+	var output *UpdateOrderOutput
+	err = uc.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		order, err := uc.orderRepository.GetByID(ctx, input.ID)
+		if err != nil {
+			return err
+		}
+
+		if input.Description != nil {
+			order.UpdateDescription(*input.Description)
+		}
+		if input.Items != nil {
+			order.ReplaceItems(newEntityItems(*input.Items))
+		}
+		if input.Status != nil {
+			if err := order.ChangeStatus(entity.OrderStatus(*input.Status)); err != nil {
+				return err
+			}
+		}
+		if err := order.Validate(); err != nil {
+			return err
+		}
+
+		if err := uc.orderRepository.Update(ctx, order); err != nil {
+			return err
+		}
+
+		output = &UpdateOrderOutput{
+			ID:          order.ID.String(),
+			Description: order.Description,
+			Items:       newOrderItems(order.Items),
+			Currency:    order.Currency,
+			TotalAmount: order.TotalAmount,
+			Status:      string(order.Status),
+			CreatedAt:   order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:   order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
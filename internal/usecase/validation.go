@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"curso-go-clean-arch/internal/locale"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+)
+
+// ErrValidation is matched by every ValidationError
+var ErrValidation = errors.New("validation failed")
+
+// FieldError describes an invalid field of a use case input
+type FieldError struct {
+	// Field is the JSON path of the field, such as description or orders[2].status
+	Field string `json:"field"`
+	// Code is the failed rule, such as required or max
+	Code string `json:"code"`
+	// Param is the parameter of the rule, such as 255 for max=255
+	Param string `json:"param,omitempty"`
+	// Message is translated to the locale of the caller
+	Message string `json:"message"`
+}
+
+// ValidationError lists the invalid fields of a use case input
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(messages, "; ")
+}
+
+// Is makes errors.Is(err, ErrValidation) match
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// validTextTag rejects text the API would store or render unsafely
+const validTextTag = "validtext"
+
+// inputValidator validates the inputs of every use case, built once as the
+// translations are shared
+var inputValidator = newInputValidator()
+
+// translations of the rules the validator translations lack
+var translations = map[string]map[string]string{
+	locale.English: {
+		validTextTag: "{0} must be valid UTF-8 without control characters or < >",
+		"iso4217":    "{0} must be an ISO 4217 currency code such as BRL",
+	},
+	locale.BrazilianPortuguese: {
+		validTextTag: "{0} deve ser UTF-8 válido, sem caracteres de controle nem < >",
+		"datetime":   "{0} deve estar no formato {1}",
+		"iso4217":    "{0} deve ser um código de moeda ISO 4217, como BRL",
+	},
+}
+
+// validatorWithTranslations wraps go-playground/validator with the translators of the supported locales
+type validatorWithTranslations struct {
+	validate    *validator.Validate
+	translators *ut.UniversalTranslator
+}
+
+// newInputValidator creates the validator with field names taken from the JSON
+// tags and messages in English and Brazilian Portuguese
+func newInputValidator() *validatorWithTranslations {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	validate.RegisterValidation(validTextTag, func(fl validator.FieldLevel) bool {
+		return validText(fl.Field().String())
+	})
+
+	english := en.New()
+	translators := ut.New(english, english, pt_BR.New())
+	enTranslator, _ := translators.GetTranslator(locale.English)
+	ptTranslator, _ := translators.GetTranslator(locale.BrazilianPortuguese)
+	// Registration only fails for malformed built-in translations
+	must(en_translations.RegisterDefaultTranslations(validate, enTranslator))
+	must(pt_BR_translations.RegisterDefaultTranslations(validate, ptTranslator))
+
+	for name, messages := range translations {
+		translator, _ := translators.GetTranslator(name)
+		for tag, message := range messages {
+			must(validate.RegisterTranslation(tag, translator, func(t ut.Translator) error {
+				return t.Add(tag, message, true)
+			}, translateField))
+		}
+	}
+
+	return &validatorWithTranslations{validate: validate, translators: translators}
+}
+
+// Struct validates input, returning a ValidationError with the messages in the locale of ctx
+func (v *validatorWithTranslations) Struct(ctx context.Context, input any) error {
+	err := v.validate.StructCtx(ctx, input)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	translator, _ := v.translators.GetTranslator(locale.FromContext(ctx))
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		// The namespace starts with the input struct, which the caller does not see
+		_, path, _ := strings.Cut(fieldErr.Namespace(), ".")
+		// Name nested fields by their path, so the message says which item is invalid
+		message := fieldErr.Translate(translator)
+		if rest, ok := strings.CutPrefix(message, fieldErr.Field()); ok {
+			message = path + rest
+		}
+		fields = append(fields, FieldError{
+			Field:   path,
+			Code:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: message,
+		})
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// translateField translates a rule registered through translations
+func translateField(t ut.Translator, fe validator.FieldError) string {
+	message, err := t.T(fe.Tag(), fe.Field(), fe.Param())
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
+
+// validText reports whether s is valid UTF-8 free of control characters and of
+// the angle brackets search snippets are marked with
+func validText(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) || r == '<' || r == '>' {
+			return false
+		}
+	}
+	return true
+}
+
+// must panics on an error setting up the validator
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	"curso-go-clean-arch/internal/tenant"
+)
+
+// Delivery attempt results reported to the Observer
+const (
+	ResultSucceeded = "succeeded"
+	ResultRetrying  = "retrying"
+	ResultDead      = "dead"
+)
+
+// maxErrorBodySize is how much of a failed response body is kept in the delivery log
+const maxErrorBodySize = 1024
+
+// Observer is notified of every delivery attempt, metrics.Metrics implements it
+type Observer interface {
+	ObserveWebhookDelivery(eventType, result string, duration time.Duration)
+}
+
+// Dispatcher sends the due webhook deliveries of every tenant. Claimed
+// deliveries are leased, so several instances can run a Dispatcher against
+// the same database without sending a delivery twice at the same time.
+type Dispatcher struct {
+	config   *Config
+	store    repository.WebhookRepository
+	client   *http.Client
+	observer Observer
+}
+
+// NewDispatcher creates a new instance of Dispatcher, observer may be nil
+func NewDispatcher(config *Config, store repository.WebhookRepository, client *http.Client, observer Observer) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	return &Dispatcher{
+		config:   config,
+		store:    store,
+		client:   client,
+		observer: observer,
+	}
+}
+
+// Run sends due deliveries every poll interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	if !d.config.Enabled {
+		return
+	}
+
+	slog.Info("Webhook dispatcher started", "poll_interval", d.config.PollInterval.String(), "workers", d.config.Workers)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means more deliveries may be due, keep draining
+		for claimed := d.config.BatchSize; claimed == d.config.BatchSize && ctx.Err() == nil; {
+			claimed = d.dispatch(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch claims and sends a batch of due deliveries, returning how many were claimed
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	// The lease covers the worst case of every worker timing out on its share of the batch
+	perWorker := (d.config.BatchSize + d.config.Workers - 1) / d.config.Workers
+	lease := time.Duration(perWorker+1) * d.config.Timeout
+
+	deliveries, err := d.store.ClaimDueDeliveries(ctx, time.Now(), d.config.BatchSize, lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to claim webhook deliveries", "error", err)
+		}
+		return 0
+	}
+
+	queue := make(chan *entity.WebhookDelivery)
+	var wg sync.WaitGroup
+	for range min(d.config.Workers, len(deliveries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range queue {
+				d.deliver(tenant.WithTenant(ctx, delivery.TenantID), delivery)
+			}
+		}()
+	}
+	for _, delivery := range deliveries {
+		queue <- delivery
+	}
+	close(queue)
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+// deliver sends a single delivery and saves its outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery *entity.WebhookDelivery) {
+	endpoint, err := d.store.GetEndpoint(ctx, delivery.EndpointID.String())
+	if err != nil {
+		// The endpoint was deleted along with its deliveries after they were claimed
+		if !errors.Is(err, repository.ErrWebhookEndpointNotFound) {
+			slog.ErrorContext(ctx, "Failed to get webhook endpoint", "delivery_id", delivery.ID, "error", err)
+		}
+		return
+	}
+
+	start := time.Now()
+	statusCode, err := d.send(ctx, endpoint, delivery)
+	duration := time.Since(start)
+
+	result := ResultSucceeded
+	if err == nil {
+		delivery.Succeed(statusCode)
+	} else {
+		delivery.Fail(statusCode, err.Error(), d.config.MaxAttempts, time.Now().Add(d.config.Backoff(delivery.Attempts+1)))
+		result = ResultRetrying
+		if delivery.Status == entity.WebhookDeliveryDead {
+			result = ResultDead
+		}
+	}
+
+	if d.observer != nil {
+		d.observer.ObserveWebhookDelivery(delivery.EventType, result, duration)
+	}
+
+	logArgs := []any{
+		"delivery_id", delivery.ID,
+		"endpoint_id", endpoint.ID,
+		"event_type", delivery.EventType,
+		"attempt", delivery.Attempts,
+		"status_code", statusCode,
+		"duration", duration.String(),
+	}
+	switch result {
+	case ResultSucceeded:
+		slog.DebugContext(ctx, "Webhook delivered", logArgs...)
+	case ResultRetrying:
+		slog.WarnContext(ctx, "Webhook delivery failed, retrying", append(logArgs, "next_attempt_at", delivery.NextAttemptAt, "error", err)...)
+	case ResultDead:
+		slog.ErrorContext(ctx, "Webhook delivery failed, giving up", append(logArgs, "error", err)...)
+	}
+
+	// Saved even when ctx is cancelled, otherwise the attempt is repeated once the lease expires
+	if err := d.store.UpdateDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+		slog.ErrorContext(ctx, "Failed to save webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send POSTs the signed payload of delivery to endpoint, returning an error
+// unless it is acknowledged with a 2xx response
+func (d *Dispatcher) send(ctx context.Context, endpoint *entity.WebhookEndpoint, delivery *entity.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "curso-go-clean-arch-webhooks/1.0")
+	req.Header.Set(HeaderEventID, delivery.EventID.String())
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+		return resp.StatusCode, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if len(body) == 0 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+}
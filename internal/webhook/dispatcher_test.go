@@ -0,0 +1,327 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"curso-go-clean-arch/internal/database"
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+	postgres "curso-go-clean-arch/internal/infrastructure/repository"
+	"curso-go-clean-arch/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// testSecret signs the deliveries of the test endpoints
+const testSecret = "test-webhook-secret-0123456789"
+
+// receivedRequest is a delivery as seen by the receiver
+type receivedRequest struct {
+	header    http.Header
+	body      []byte
+	verifyErr error
+}
+
+// receiver is an endpoint answering each request with the next of its status
+// codes, the last one repeated, and verifying the signature of every request
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	status := rc.statuses[min(len(rc.requests), len(rc.statuses)-1)]
+	rc.requests = append(rc.requests, receivedRequest{
+		header:    r.Header.Clone(),
+		body:      body,
+		verifyErr: Verify(testSecret, r.Header, body, time.Minute),
+	})
+	rc.mu.Unlock()
+
+	w.WriteHeader(status)
+	if status >= 300 {
+		_, _ = io.WriteString(w, "receiver unavailable\n")
+	}
+}
+
+// received returns a copy of the requests received so far
+func (rc *receiver) received() []receivedRequest {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]receivedRequest(nil), rc.requests...)
+}
+
+// observation is a delivery attempt reported to the Observer
+type observation struct {
+	eventType string
+	result    string
+}
+
+// recordingObserver records the delivery attempts
+type recordingObserver struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (o *recordingObserver) ObserveWebhookDelivery(eventType, result string, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observations = append(o.observations, observation{eventType: eventType, result: result})
+}
+
+// dispatcherFixture is a Dispatcher sending the deliveries of a SQLite store to a receiver
+type dispatcherFixture struct {
+	config     *Config
+	dispatcher *Dispatcher
+	store      repository.WebhookRepository
+	receiver   *receiver
+	observer   *recordingObserver
+	ctx        context.Context
+	endpoint   *entity.WebhookEndpoint
+}
+
+// newDispatcherFixture creates an endpoint of the tenant acme answered by a
+// receiver replying with statuses, retried after short backoffs
+func newDispatcherFixture(t *testing.T, maxAttempts int, statuses ...int) *dispatcherFixture {
+	t.Helper()
+
+	dbConfig := database.DefaultConfig()
+	dbConfig.Driver = database.DriverSQLite
+	dbConfig.SQLitePath = filepath.Join(t.TempDir(), "webhooks.db")
+
+	db, err := database.ConnectSQLite(&dbConfig)
+	if err != nil {
+		t.Fatalf("connecting to SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	rc := &receiver{statuses: statuses}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig()
+	config.Timeout = 5 * time.Second
+	config.MaxAttempts = maxAttempts
+	config.InitialBackoff = 100 * time.Millisecond
+	config.MaxBackoff = 200 * time.Millisecond
+
+	store := postgres.NewSQLiteWebhookRepository(db)
+	observer := &recordingObserver{}
+	ctx := tenant.WithTenant(context.Background(), "acme")
+
+	endpoint, err := entity.NewWebhookEndpoint(server.URL, []string{entity.OrderEventType(entity.OrderHistoryCreated)}, testSecret)
+	if err != nil {
+		t.Fatalf("creating endpoint: %v", err)
+	}
+	if err := store.CreateEndpoint(ctx, endpoint); err != nil {
+		t.Fatalf("saving endpoint: %v", err)
+	}
+
+	return &dispatcherFixture{
+		config:     &config,
+		dispatcher: NewDispatcher(&config, store, server.Client(), observer),
+		store:      store,
+		receiver:   rc,
+		observer:   observer,
+		ctx:        ctx,
+		endpoint:   endpoint,
+	}
+}
+
+// enqueue saves a delivery of an order.created event to the endpoint
+func (f *dispatcherFixture) enqueue(t *testing.T) *entity.WebhookDelivery {
+	t.Helper()
+
+	payload, _ := json.Marshal(map[string]string{"order_id": uuid.NewString()})
+	delivery := entity.NewWebhookDelivery(f.endpoint, uuid.New(), entity.OrderEventType(entity.OrderHistoryCreated), payload)
+	if err := f.store.CreateDelivery(f.ctx, delivery); err != nil {
+		t.Fatalf("saving delivery: %v", err)
+	}
+	return delivery
+}
+
+// attempt runs one dispatch, expecting it to claim a single delivery, and
+// returns the delivery as saved afterwards
+func (f *dispatcherFixture) attempt(t *testing.T, id uuid.UUID) *entity.WebhookDelivery {
+	t.Helper()
+
+	if claimed := f.dispatcher.dispatch(context.Background()); claimed != 1 {
+		t.Fatalf("dispatch claimed %d deliveries, want 1", claimed)
+	}
+	delivery, err := f.store.GetDelivery(f.ctx, id.String())
+	if err != nil {
+		t.Fatalf("getting delivery: %v", err)
+	}
+	return delivery
+}
+
+// waitUntilDue sleeps until the delivery may be claimed again
+func waitUntilDue(delivery *entity.WebhookDelivery) {
+	time.Sleep(time.Until(delivery.NextAttemptAt) + 5*time.Millisecond)
+}
+
+// TestDispatcherSignsDeliveries checks the headers of a delivery and that the
+// receiver can verify its signature and timestamp
+func TestDispatcherSignsDeliveries(t *testing.T) {
+	f := newDispatcherFixture(t, 3, http.StatusNoContent)
+	queued := f.enqueue(t)
+
+	delivery := f.attempt(t, queued.ID)
+
+	requests := f.receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if req.verifyErr != nil {
+		t.Errorf("verifying signature: %v", req.verifyErr)
+	}
+	if string(req.body) != string(queued.Payload) {
+		t.Errorf("body = %s, want %s", req.body, queued.Payload)
+	}
+	if got := req.header.Get(HeaderEventID); got != queued.EventID.String() {
+		t.Errorf("%s = %q, want %q", HeaderEventID, got, queued.EventID)
+	}
+	if got := req.header.Get(HeaderEvent); got != queued.EventType {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, queued.EventType)
+	}
+
+	// A signature made with another secret, or too long ago, is rejected
+	if err := Verify("another-webhook-secret-0123", req.header, req.body, time.Minute); err == nil {
+		t.Error("signature verified with the wrong secret")
+	}
+	stale := req.header.Clone()
+	signedAt := time.Now().Add(-2 * time.Minute)
+	stale.Set(HeaderTimestamp, strconv.FormatInt(signedAt.Unix(), 10))
+	stale.Set(HeaderSignature, Sign(testSecret, signedAt, req.body))
+	if err := Verify(testSecret, stale, req.body, time.Minute); err == nil {
+		t.Error("stale signature verified")
+	}
+
+	if delivery.Status != entity.WebhookDeliverySucceeded || delivery.Attempts != 1 {
+		t.Errorf("delivery status %s after %d attempts, want succeeded after 1", delivery.Status, delivery.Attempts)
+	}
+	if delivery.LastStatusCode != http.StatusNoContent || delivery.LastError != "" || delivery.DeliveredAt == nil {
+		t.Errorf("delivery log = status code %d, error %q, delivered at %v, want %d, no error and a delivery time",
+			delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt, http.StatusNoContent)
+	}
+}
+
+// TestDispatcherRetriesWithBackoff checks that 5xx responses are retried after
+// the configured backoff until the receiver acknowledges the delivery
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	f := newDispatcherFixture(t, 5, http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK)
+	queued := f.enqueue(t)
+
+	for attempt, wantStatus := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		before := time.Now()
+		delivery := f.attempt(t, queued.ID)
+		after := time.Now()
+
+		if delivery.Status != entity.WebhookDeliveryPending || delivery.Attempts != attempt+1 {
+			t.Fatalf("delivery status %s after %d attempts, want pending after %d", delivery.Status, delivery.Attempts, attempt+1)
+		}
+		if delivery.LastStatusCode != wantStatus || !strings.Contains(delivery.LastError, "receiver unavailable") {
+			t.Errorf("delivery log = status code %d, error %q, want %d with the response body", delivery.LastStatusCode, delivery.LastError, wantStatus)
+		}
+
+		backoff := f.config.Backoff(attempt + 1)
+		if delivery.NextAttemptAt.Before(before.Add(backoff)) || delivery.NextAttemptAt.After(after.Add(backoff)) {
+			t.Errorf("attempt %d: next attempt at %s, want %s after the attempt", attempt+1, delivery.NextAttemptAt, backoff)
+		}
+
+		// Not due yet, so not claimed again
+		if claimed := f.dispatcher.dispatch(context.Background()); claimed != 0 {
+			t.Fatalf("dispatch claimed %d deliveries before the backoff elapsed, want 0", claimed)
+		}
+		waitUntilDue(delivery)
+	}
+
+	delivery := f.attempt(t, queued.ID)
+	if delivery.Status != entity.WebhookDeliverySucceeded || delivery.Attempts != 3 {
+		t.Errorf("delivery status %s after %d attempts, want succeeded after 3", delivery.Status, delivery.Attempts)
+	}
+	if delivery.LastStatusCode != http.StatusOK || delivery.LastError != "" {
+		t.Errorf("delivery log = status code %d, error %q, want %d without error", delivery.LastStatusCode, delivery.LastError, http.StatusOK)
+	}
+
+	// Every retry carries the same event ID, so the receiver can deduplicate
+	for i, req := range f.receiver.received() {
+		if req.verifyErr != nil {
+			t.Errorf("request %d: verifying signature: %v", i, req.verifyErr)
+		}
+		if got := req.header.Get(HeaderEventID); got != queued.EventID.String() {
+			t.Errorf("request %d: %s = %q, want %q", i, HeaderEventID, got, queued.EventID)
+		}
+	}
+
+	want := []string{ResultRetrying, ResultRetrying, ResultSucceeded}
+	if len(f.observer.observations) != len(want) {
+		t.Fatalf("observed %d attempts, want %d", len(f.observer.observations), len(want))
+	}
+	for i, o := range f.observer.observations {
+		if o.result != want[i] || o.eventType != queued.EventType {
+			t.Errorf("observation %d = %s %s, want %s %s", i, o.eventType, o.result, queued.EventType, want[i])
+		}
+	}
+}
+
+// TestDispatcherDeadLettersAfterMaxAttempts checks that a delivery failing
+// MaxAttempts times is dead, kept in the delivery log and no longer sent
+func TestDispatcherDeadLettersAfterMaxAttempts(t *testing.T) {
+	const maxAttempts = 3
+	f := newDispatcherFixture(t, maxAttempts, http.StatusBadGateway)
+	queued := f.enqueue(t)
+
+	var delivery *entity.WebhookDelivery
+	for range maxAttempts {
+		if delivery != nil {
+			waitUntilDue(delivery)
+		}
+		delivery = f.attempt(t, queued.ID)
+	}
+
+	if delivery.Status != entity.WebhookDeliveryDead || delivery.Attempts != maxAttempts {
+		t.Fatalf("delivery status %s after %d attempts, want dead after %d", delivery.Status, delivery.Attempts, maxAttempts)
+	}
+	if delivery.LastStatusCode != http.StatusBadGateway || !strings.Contains(delivery.LastError, "502") {
+		t.Errorf("delivery log = status code %d, error %q, want %d", delivery.LastStatusCode, delivery.LastError, http.StatusBadGateway)
+	}
+
+	time.Sleep(f.config.MaxBackoff)
+	if claimed := f.dispatcher.dispatch(context.Background()); claimed != 0 {
+		t.Errorf("dispatch claimed %d dead deliveries, want 0", claimed)
+	}
+	if got := len(f.receiver.received()); got != maxAttempts {
+		t.Errorf("receiver got %d requests, want %d", got, maxAttempts)
+	}
+
+	dead, err := f.store.ListDeliveries(f.ctx, f.endpoint.ID.String(), entity.WebhookDeliveryDead, 10)
+	if err != nil {
+		t.Fatalf("listing dead deliveries: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != queued.ID {
+		t.Fatalf("dead deliveries = %d, want the failed one", len(dead))
+	}
+	if dead[0].Attempts != maxAttempts || dead[0].LastError != delivery.LastError {
+		t.Errorf("listed delivery = %d attempts, error %q, want %d and %q", dead[0].Attempts, dead[0].LastError, maxAttempts, delivery.LastError)
+	}
+
+	if last := f.observer.observations[len(f.observer.observations)-1]; last.result != ResultDead {
+		t.Errorf("last attempt observed as %s, want %s", last.result, ResultDead)
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"curso-go-clean-arch/internal/domain/entity"
+	"curso-go-clean-arch/internal/domain/repository"
+)
+
+// Event is the JSON body POSTed to webhook endpoints
+type Event struct {
+	// ID is the event ID, also sent in HeaderEventID
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	TenantID  string    `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      EventData `json:"data"`
+}
+
+// EventData is the order change carried by an Event
+type EventData struct {
+	// Order is the order after the change, or before it when it was purged
+	Order        *entity.Order        `json:"order"`
+	Changes      []entity.FieldChange `json:"changes"`
+	Actor        string               `json:"actor"`
+	ClaimedActor string               `json:"claimed_actor,omitempty"`
+	RequestID    string               `json:"request_id,omitempty"`
+}
+
+// Notifier queues a delivery of every order change to the endpoints of the
+// tenant subscribed to it. It is an order change hook, so deliveries are
+// queued in the transaction of the change and only sent once it commits.
+type Notifier struct {
+	store repository.WebhookRepository
+}
+
+// NewNotifier creates a new instance of Notifier
+func NewNotifier(store repository.WebhookRepository) *Notifier {
+	return &Notifier{store: store}
+}
+
+// OrderChanged queues a delivery of the change to every subscribed endpoint
+func (n *Notifier) OrderChanged(ctx context.Context, entry *entity.OrderHistoryEntry, order *entity.Order) error {
+	endpoints, err := n.store.ListEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	eventType := entity.OrderEventType(entry.Action)
+	var payload []byte
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(eventType) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(Event{
+				ID:        entry.ID.String(),
+				Type:      eventType,
+				TenantID:  entry.TenantID,
+				CreatedAt: entry.CreatedAt,
+				Data: EventData{
+					Order:        order,
+					Changes:      entry.Changes,
+					Actor:        entry.Actor,
+					ClaimedActor: entry.ClaimedActor,
+					RequestID:    entry.RequestID,
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("error encoding webhook event: %w", err)
+			}
+		}
+
+		if err := n.store.CreateDelivery(ctx, entity.NewWebhookDelivery(endpoint, entry.ID, eventType, payload)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	// HeaderEventID identifies the event, it is the same for every retry so receivers can deduplicate
+	HeaderEventID = "X-Webhook-ID"
+	// HeaderEvent is the event type, e.g. order.created
+	HeaderEvent = "X-Webhook-Event"
+	// HeaderTimestamp is the Unix time in seconds at which the delivery was signed
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature is "sha256=" followed by the hex HMAC-SHA256 of "{timestamp}.{body}"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// ErrInvalidSignature is returned by Verify when a delivery was not signed with the secret
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Config holds webhook delivery configuration
+type Config struct {
+	// Enabled runs the delivery worker, events are queued for registered endpoints either way
+	Enabled      bool          `yaml:"enabled" toml:"enabled" env:"WEBHOOK_ENABLED"`
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL"`
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size" env:"WEBHOOK_BATCH_SIZE"`
+	Workers      int           `yaml:"workers" toml:"workers" env:"WEBHOOK_WORKERS"`
+	Timeout      time.Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOK_TIMEOUT"`
+	// MaxAttempts is the number of failed attempts after which a delivery is dead
+	MaxAttempts    int           `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff" env:"WEBHOOK_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"WEBHOOK_MAX_BACKOFF"`
+}
+
+// DefaultConfig returns the default webhook delivery configuration
+func DefaultConfig() Config {
+	return Config{
+		Enabled:        true,
+		PollInterval:   time.Second,
+		BatchSize:      50,
+		Workers:        4,
+		Timeout:        10 * time.Second,
+		MaxAttempts:    8,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Hour,
+	}
+}
+
+// Validate checks the webhook delivery configuration
+func (c *Config) Validate() error {
+	var errs []error
+	for name, value := range map[string]time.Duration{
+		"poll_interval":   c.PollInterval,
+		"timeout":         c.Timeout,
+		"initial_backoff": c.InitialBackoff,
+		"max_backoff":     c.MaxBackoff,
+	} {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive, got %s", name, value))
+		}
+	}
+	for name, value := range map[string]int{
+		"batch_size":   c.BatchSize,
+		"workers":      c.Workers,
+		"max_attempts": c.MaxAttempts,
+	} {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive, got %d", name, value))
+		}
+	}
+	if c.MaxBackoff > 0 && c.InitialBackoff > c.MaxBackoff {
+		errs = append(errs, fmt.Errorf("initial_backoff: must not exceed max_backoff %s, got %s", c.MaxBackoff, c.InitialBackoff))
+	}
+	return errors.Join(errs...)
+}
+
+// Backoff returns the delay before the attempt following the given number of
+// failed attempts, doubling from InitialBackoff up to MaxBackoff
+func (c *Config) Backoff(attempts int) time.Duration {
+	backoff := c.InitialBackoff
+	for i := 1; i < attempts && backoff < c.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, c.MaxBackoff)
+}
+
+// Sign returns the HeaderSignature value of body signed with secret at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a received delivery and that it was signed
+// within tolerance of now, rejecting replayed deliveries. Receivers written in
+// Go can use it as is, others reproduce Sign.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or malformed %s", ErrInvalidSignature, HeaderTimestamp)
+	}
+
+	timestamp := time.Unix(seconds, 0)
+	if age := time.Since(timestamp); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside the %s tolerance", ErrInvalidSignature, tolerance)
+	}
+
+	if !hmac.Equal([]byte(header.Get(HeaderSignature)), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
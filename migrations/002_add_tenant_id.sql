@@ -0,0 +1,23 @@
+-- Add tenant_id to orders so every row belongs to a tenant
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- Add idempotency_key so clients can safely retry order creation
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+
+-- Create index on tenant_id and created_at for tenant scoped list queries
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_created_at ON orders(tenant_id, created_at);
+
+-- Idempotency keys are unique per tenant only
+CREATE UNIQUE INDEX IF NOT EXISTS uq_orders_tenant_idempotency_key
+    ON orders(tenant_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
+
+-- Row level security policy used when the application sets app.tenant_id per connection.
+-- Table owners and superusers bypass RLS, so run the application with a dedicated role
+-- (or ALTER TABLE orders FORCE ROW LEVEL SECURITY) to have PostgreSQL enforce it.
+ALTER TABLE orders ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS orders_tenant_isolation ON orders;
+CREATE POLICY orders_tenant_isolation ON orders
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,9 @@
+-- Add status to orders, existing orders start as pending
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'pending';
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_status;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+    CHECK (status IN ('pending', 'confirmed', 'shipped', 'delivered', 'cancelled'));
+
+-- Create index on tenant_id and status for status breakdowns
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_status ON orders(tenant_id, status);
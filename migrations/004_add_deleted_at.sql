@@ -0,0 +1,5 @@
+-- Add deleted_at for soft deletes, deleted orders keep their row until purged
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Create partial index on deleted_at for the purge of expired soft deleted orders
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders(deleted_at) WHERE deleted_at IS NOT NULL;
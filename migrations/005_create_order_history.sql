@@ -0,0 +1,37 @@
+-- Create order_history, the append-only audit trail of order changes.
+-- There is no foreign key to orders so the history outlives purged orders.
+CREATE TABLE IF NOT EXISTS order_history (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    order_id UUID NOT NULL,
+    action VARCHAR(32) NOT NULL
+        CHECK (action IN ('created', 'updated', 'status_changed', 'deleted', 'restored', 'purged')),
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255),
+    changes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create index on tenant_id, order_id and created_at for the history of an order
+CREATE INDEX IF NOT EXISTS idx_order_history_tenant_order
+    ON order_history(tenant_id, order_id, created_at);
+
+-- Entries are immutable, reject any update or delete
+CREATE OR REPLACE FUNCTION order_history_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'order_history entries are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_order_history_immutable ON order_history;
+CREATE TRIGGER trg_order_history_immutable
+    BEFORE UPDATE OR DELETE ON order_history
+    FOR EACH ROW EXECUTE FUNCTION order_history_immutable();
+
+-- Same tenant isolation policy as orders, see 002_add_tenant_id.sql
+ALTER TABLE order_history ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS order_history_tenant_isolation ON order_history;
+CREATE POLICY order_history_tenant_isolation ON order_history
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,61 @@
+-- Create webhook_endpoints, the URLs of a tenant notified of order events
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create index on tenant_id for the endpoints of a tenant
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_tenant ON webhook_endpoints(tenant_id, created_at);
+
+-- Create webhook_deliveries, the delivery log and retry queue of order events.
+-- Deliveries are created in the transaction of the order change (outbox) and
+-- removed together with their endpoint.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_status_code INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+-- An event is delivered at most once per endpoint
+CREATE UNIQUE INDEX IF NOT EXISTS uq_webhook_deliveries_endpoint_event
+    ON webhook_deliveries(endpoint_id, event_id);
+
+-- Create partial index on next_attempt_at for the delivery worker
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+    ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- Create index on tenant_id, endpoint_id and created_at for the delivery log of an endpoint
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint
+    ON webhook_deliveries(tenant_id, endpoint_id, created_at);
+
+-- Same tenant isolation policy as orders, see 002_add_tenant_id.sql. The delivery
+-- worker claims deliveries of every tenant, like the purge job, so it needs a role
+-- bypassing RLS when the policies are forced.
+ALTER TABLE webhook_endpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS webhook_endpoints_tenant_isolation ON webhook_endpoints;
+CREATE POLICY webhook_endpoints_tenant_isolation ON webhook_endpoints
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS webhook_deliveries_tenant_isolation ON webhook_deliveries;
+CREATE POLICY webhook_deliveries_tenant_isolation ON webhook_deliveries
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
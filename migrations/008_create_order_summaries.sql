@@ -0,0 +1,51 @@
+-- Create order_summaries, the denormalized read model of orders served by the
+-- query side. It is projected from the order changes recorded in order_history
+-- and can be rebuilt at any time from orders and order_history.
+CREATE TABLE IF NOT EXISTS order_summaries (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    description TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    -- Number of recorded changes of the order
+    version BIGINT NOT NULL DEFAULT 0,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    last_action VARCHAR(32) NOT NULL DEFAULT '',
+    last_actor VARCHAR(255) NOT NULL DEFAULT '',
+    last_changed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status_changed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create index on tenant_id and created_at for listing the orders of a tenant
+CREATE INDEX IF NOT EXISTS idx_order_summaries_tenant_created_at
+    ON order_summaries(tenant_id, created_at DESC);
+
+-- Same tenant isolation policy as orders, see 002_add_tenant_id.sql
+ALTER TABLE order_summaries ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS order_summaries_tenant_isolation ON order_summaries;
+CREATE POLICY order_summaries_tenant_isolation ON order_summaries
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- Project the existing orders, same query as OrderSummaryRepository.Rebuild
+INSERT INTO order_summaries (id, tenant_id, description, status, created_at, updated_at, deleted_at,
+                             version, created_by, last_action, last_actor, last_changed_at, status_changed_at)
+SELECT o.id, o.tenant_id, o.description, o.status, o.created_at, o.updated_at, o.deleted_at,
+    (SELECT COUNT(*) FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id),
+    COALESCE((SELECT h.actor FROM order_history h
+              WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id AND h.action = 'created'
+              ORDER BY h.created_at LIMIT 1), ''),
+    COALESCE((SELECT h.action FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id
+              ORDER BY h.created_at DESC, h.id DESC LIMIT 1), ''),
+    COALESCE((SELECT h.actor FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id
+              ORDER BY h.created_at DESC, h.id DESC LIMIT 1), ''),
+    COALESCE((SELECT MAX(h.created_at) FROM order_history h WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id),
+             o.updated_at),
+    COALESCE((SELECT MAX(h.created_at) FROM order_history h
+              WHERE h.tenant_id = o.tenant_id AND h.order_id = o.id AND h.action = 'status_changed'),
+             o.created_at)
+FROM orders o
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Add full-text search over the order summaries. The 'simple' configuration
+-- lowercases words without stemming or stop words, so it suits descriptions in
+-- any language and matches the in-memory search of the other backends.
+ALTER TABLE order_summaries
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', description)) STORED;
+
+-- Create GIN index on search_vector for the @@ matches of the search
+CREATE INDEX IF NOT EXISTS idx_order_summaries_search_vector
+    ON order_summaries USING GIN (search_vector);
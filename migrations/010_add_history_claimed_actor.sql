@@ -0,0 +1,3 @@
+-- Add claimed_actor, the caller named by the actor header. It is not verified,
+-- so it is kept apart from actor, the identity of the credential presented.
+ALTER TABLE order_history ADD COLUMN IF NOT EXISTS claimed_actor VARCHAR(255) NOT NULL DEFAULT '';
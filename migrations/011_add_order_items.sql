@@ -0,0 +1,15 @@
+-- Add items, the lines of an order as a JSON array of {name, quantity, unit_price}
+-- with the price in minor units of the currency
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS items JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE order_summaries ADD COLUMN IF NOT EXISTS items JSONB NOT NULL DEFAULT '[]';
+
+-- Search the item names along with the description. jsonb_to_tsvector keeps the
+-- string values of items, the names, as quantities and prices are numbers.
+ALTER TABLE order_summaries DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE order_summaries
+    ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', description) || jsonb_to_tsvector('simple', items, '["string"]')) STORED;
+
+-- Recreate the GIN index dropped with the previous search_vector
+CREATE INDEX IF NOT EXISTS idx_order_summaries_search_vector
+    ON order_summaries USING GIN (search_vector);
@@ -0,0 +1,15 @@
+-- Add the currency of the item prices and the order total, the sum of the items
+-- in minor units of the currency, so the reports can sum revenue in SQL
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE order_summaries ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE order_summaries ADD COLUMN IF NOT EXISTS total_amount BIGINT NOT NULL DEFAULT 0;
+
+-- Total the orders stored with items before this migration
+UPDATE orders SET total_amount = (
+    SELECT COALESCE(SUM((item->>'quantity')::BIGINT * (item->>'unit_price')::BIGINT), 0)
+    FROM jsonb_array_elements(orders.items) item
+) WHERE items <> '[]';
+UPDATE order_summaries SET total_amount = orders.total_amount
+FROM orders
+WHERE order_summaries.id = orders.id AND order_summaries.total_amount <> orders.total_amount;